@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/config"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+// replays dead-lettered messages back onto the topic they originally failed on, e.g.
+//
+//	go run ./cmd/dlq-replay -topic payment_event -limit 100
+func main() {
+	topic := flag.String("topic", "", "source topic whose dead-letter topic should be replayed")
+	limit := flag.Int("limit", 0, "maximum number of messages to replay, 0 replays everything")
+	idle := flag.Duration("idle", 10*time.Second, "stop after no message arrived for this long")
+	flag.Parse()
+
+	if *topic == "" {
+		log.Fatal("-topic is required")
+	}
+
+	deadLetterTopic := kafka.DeadLetterTopic(*topic)
+
+	//own consumer group so replaying never moves the offsets of anything else reading the dead-letter topic
+	reader := kafka.NewConsumer(config.Envs.KAFKA_BROKERS, deadLetterTopic, config.Envs.KAFKA_GROUP_ID+"-dlq-replay")
+	defer reader.Close()
+
+	producer, err := kafka.NewProducerFromConfig(kafka.ProducerConfigFromEnv("notification-service-dlq-replay", ""))
+	if err != nil {
+		log.Fatal("failed to create kafka producer: ", err)
+	}
+	defer producer.Close()
+
+	replayed := 0
+	for *limit == 0 || replayed < *limit {
+		ctx, cancel := context.WithTimeout(context.Background(), *idle)
+		msg, err := reader.Fetch(ctx)
+		cancel()
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			log.Fatalf("failed to read from %s: %v", deadLetterTopic, err)
+		}
+
+		target := msg.Headers[kafka.HeaderOriginalTopic]
+		if target == "" {
+			target = *topic
+		}
+
+		//the message goes back as if it was new, failure bookkeeping from the previous run is dropped
+		headers := make(map[string]string, len(msg.Headers))
+		for key, value := range msg.Headers {
+			headers[key] = value
+		}
+		for _, key := range []string{
+			kafka.HeaderOriginalTopic,
+			kafka.HeaderRetryAttempt,
+			kafka.HeaderNotBefore,
+			kafka.HeaderLastError,
+			kafka.HeaderFailureReason,
+			kafka.HeaderFailedAt,
+		} {
+			delete(headers, key)
+		}
+
+		if err := producer.Publish(context.Background(), kafka.Message{
+			Topic:   target,
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: headers,
+		}); err != nil {
+			log.Fatalf("failed to replay offset %d to %s: %v", msg.Offset, target, err)
+		}
+
+		//committed only after the replay is written, an interrupted run replays the message again instead of dropping it
+		if err := reader.Commit(context.Background(), msg); err != nil {
+			log.Fatalf("failed to commit offset %d on %s: %v", msg.Offset, deadLetterTopic, err)
+		}
+
+		log.Printf("replayed %s[%d]@%d to %s (failed with: %s)", deadLetterTopic, msg.Partition, msg.Offset, target, msg.Headers[kafka.HeaderFailureReason])
+		replayed++
+	}
+
+	log.Printf("replayed %d messages from %s", replayed, deadLetterTopic)
+}
@@ -5,11 +5,15 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/config"
+	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/db"
 	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/internal/client"
 	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/internal/consumer"
 	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/internal/events"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/idempotency"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
 	"go.uber.org/zap"
 )
 
@@ -25,16 +29,42 @@ func main() {
 	//SIGTERM: Signal Terminate (kill command, Kubernetes pod shutdown, etc.)
 	//Purpose: Catch when someone wants to stop the app
 
+	database, err := db.NewPostgresStore()
+	if err != nil {
+		logger.Fatal("failed to connect to database", zap.Error(err))
+	}
+
+	//redeliveries are expected with at-least-once consumption, the store turns them into no-ops
+	processed_events := idempotency.NewStore(database, config.Envs.PROCESSED_EVENT_LEASE)
+	go processed_events.RunCleanup(ctx, config.Envs.PROCESSED_EVENT_TTL, time.Hour)
+
 	whatsAppNotifier := client.NewWhatsAppService() //the one that will send messages to whatsapp
 
 	//router: to route different topics to different handlers
 	router := events.NewRouter()
 
-	payment_handler := events.NewPaymentHandler(*whatsAppNotifier)
+	payment_handler := idempotency.NewGuard(
+		processed_events,
+		config.Envs.KAFKA_GROUP_ID,
+		events.NewPaymentHandler(*whatsAppNotifier),
+	)
 	router.Register("payment_event", payment_handler)
 	router.Register("order_event", payment_handler)
 
-	consumer_manager := consumer.NewManager(router, logger)
+	//no default topic, retry and dead-letter messages carry their own
+	retry_producer, err := kafka.NewProducerFromConfig(kafka.ProducerConfigFromEnv("notification-service", ""))
+	if err != nil {
+		logger.Fatal("failed to create kafka producer", zap.Error(err))
+	}
+	defer retry_producer.Close()
+
+	consumer_manager := consumer.NewManager(router, retry_producer, consumer.KafkaConsumerFactory, logger)
+
+	//payment notifications are the ones customers wait on, give twilio more room to recover
+	payment_policy := consumer.DefaultRetryPolicy()
+	payment_policy.RetryTopics = 3
+	consumer_manager.SetRetryPolicy("payment_event", payment_policy)
+
 	consumer_manager.Run(ctx, config.Envs.KAFKA_TOPICS)
 
 	<-sigchan
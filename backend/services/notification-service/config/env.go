@@ -1,12 +1,20 @@
 package config
 
 import (
-	"github.com/Flow-Indo/LAKOO/backend/shared/env"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/env"
 	"github.com/lpernett/godotenv"
 )
 
 type Config struct {
 	NOTIFICATION_SERVICE_PORT string
+	DB_HOST                   string
+	DB_USER                   string
+	DB_PASSWORD               string
+	DB_NAME                   string
+	DB_PORT                   string
+	DB_SSL                    string
 	TWILIO_ACCOUNT_SID        string
 	TWILIO_AUTH_TOKEN         string
 	TWILIO_WHATSAPP_NUMBER    string
@@ -16,6 +24,14 @@ type Config struct {
 	KAFKA_BROKERS             []string
 	KAFKA_GROUP_ID            string
 	KAFKA_TOPICS              []string
+	KAFKA_RETRY_ATTEMPTS      int
+	KAFKA_RETRY_BACKOFF       time.Duration
+	KAFKA_RETRY_TOPIC_COUNT   int
+	KAFKA_RETRY_TOPIC_DELAY   time.Duration
+	KAFKA_COMMIT_BATCH_SIZE   int
+	KAFKA_COMMIT_INTERVAL     time.Duration
+	PROCESSED_EVENT_LEASE     time.Duration
+	PROCESSED_EVENT_TTL       time.Duration
 }
 
 var Envs = initConfig()
@@ -25,6 +41,12 @@ func initConfig() *Config {
 
 	return &Config{
 		NOTIFICATION_SERVICE_PORT: env.GetEnv("NOTIFICATION_SERVICE_PORT", "3007"),
+		DB_HOST:                   env.GetEnv("DB_HOST", "localhost"),
+		DB_USER:                   env.GetEnv("DB_USER", "postgres"),
+		DB_PASSWORD:               env.GetEnv("DB_PASSWORD", "password"),
+		DB_NAME:                   env.GetEnv("DB_NAME", "notificationdb"),
+		DB_PORT:                   env.GetEnv("DB_PORT", "5432"),
+		DB_SSL:                    env.GetEnv("DB_SSL", "disable"),
 		TWILIO_ACCOUNT_SID:        env.GetEnv("TWILIO_ACCOUNT_SID", "123"),
 		TWILIO_AUTH_TOKEN:         env.GetEnv("TWILIO_AUTH_TOKEN", "123"),
 		TWILIO_WHATSAPP_NUMBER:    env.GetEnv("TWILIO_WHATSAPP_NUMBER", "123"),
@@ -34,5 +56,13 @@ func initConfig() *Config {
 		KAFKA_BROKERS:             env.GetEnvAsSlice("KAFKA_BROKERS", []string{"localhost:9092"}, ","),
 		KAFKA_GROUP_ID:            env.GetEnv("KAFKA_GROUP_ID", "kafka_group"),
 		KAFKA_TOPICS:              env.GetEnvAsSlice("KAFKA_TOPICS", []string{"kafka_topic"}, ","),
+		KAFKA_RETRY_ATTEMPTS:      env.GetEnvAsInt("KAFKA_RETRY_ATTEMPTS", 3),
+		KAFKA_RETRY_BACKOFF:       env.GetEnvAsDuration("KAFKA_RETRY_BACKOFF", 200*time.Millisecond),
+		KAFKA_RETRY_TOPIC_COUNT:   env.GetEnvAsInt("KAFKA_RETRY_TOPIC_COUNT", 2),
+		KAFKA_RETRY_TOPIC_DELAY:   env.GetEnvAsDuration("KAFKA_RETRY_TOPIC_DELAY", 30*time.Second),
+		KAFKA_COMMIT_BATCH_SIZE:   env.GetEnvAsInt("KAFKA_COMMIT_BATCH_SIZE", 50),
+		KAFKA_COMMIT_INTERVAL:     env.GetEnvAsDuration("KAFKA_COMMIT_INTERVAL", time.Second),
+		PROCESSED_EVENT_LEASE:     env.GetEnvAsDuration("PROCESSED_EVENT_LEASE", 5*time.Minute),
+		PROCESSED_EVENT_TTL:       env.GetEnvAsDuration("PROCESSED_EVENT_TTL", 14*24*time.Hour),
 	}
 }
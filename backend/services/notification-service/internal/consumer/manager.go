@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	// "github.com/segmentio/kafka-go"
 	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/config"
-	kafkaService "github.com/Flow-Indo/LAKOO/backend/shared/kafka"
+	kafkaService "github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
 	"go.uber.org/zap"
 )
 
@@ -15,44 +16,116 @@ type MessageRouter interface {
 	Route(ctx context.Context, topic string, key []byte, message []byte) error
 }
 
+// opens a group member on topic, swapped for kafkatest.Broker.NewConsumer when running without brokers
+type ConsumerFactory func(topic string, groupId string) kafkaService.Consumer
+
+// connects to the brokers from config
+func KafkaConsumerFactory(topic string, groupId string) kafkaService.Consumer {
+	return kafkaService.NewConsumer(config.Envs.KAFKA_BROKERS, topic, groupId)
+}
+
 type Manager struct {
-	router    MessageRouter
-	wg        sync.WaitGroup
-	logger    *zap.Logger
-	consumers []*kafkaService.KafkaConsumer
+	router        MessageRouter
+	producer      kafkaService.Producer //writes to the retry and dead-letter topics
+	newConsumer   ConsumerFactory
+	defaultPolicy kafkaService.RetryPolicy
+	policies      map[string]kafkaService.RetryPolicy
+	wg            sync.WaitGroup
+	logger        *zap.Logger
 }
 
-func NewManager(router MessageRouter, logger *zap.Logger) *Manager {
+func NewManager(router MessageRouter, producer kafkaService.Producer, newConsumer ConsumerFactory, logger *zap.Logger) *Manager {
 	return &Manager{
-		router: router,
-		logger: logger,
+		router:        router,
+		producer:      producer,
+		newConsumer:   newConsumer,
+		defaultPolicy: DefaultRetryPolicy(),
+		policies:      make(map[string]kafkaService.RetryPolicy),
+		logger:        logger,
 	}
 }
 
-func (m *Manager) consume(ctx context.Context, topic string, consumerId string) {
+// must be called before Run, the policy decides how many retry topics get consumers
+func (m *Manager) SetRetryPolicy(topic string, policy kafkaService.RetryPolicy) {
+	m.policies[topic] = policy
+}
+
+func (m *Manager) policyFor(topic string) kafkaService.RetryPolicy {
+	if policy, ok := m.policies[topic]; ok {
+		return policy
+	}
+	return m.defaultPolicy
+}
+
+// subscribedTopic is what the reader consumes, sourceTopic is what the router knows the handler by,
+// they only differ for retry topics where level is the position in the retry chain
+func (m *Manager) consume(ctx context.Context, subscribedTopic string, sourceTopic string, level int, consumerId string) {
 	defer m.wg.Done() //says to parent that my job is done, will be called when this consume exits
 
-	kafka_consumer := kafkaService.NewConsumer(config.Envs.KAFKA_BROKERS, topic, config.Envs.KAFKA_GROUP_ID)
-	m.consumers = append(m.consumers, kafka_consumer) //for one worker append its consumer to then close all consumers all at once
+	kafka_consumer := m.newConsumer(subscribedTopic, config.Envs.KAFKA_GROUP_ID)
+	committer := kafkaService.NewCommitBatcher(kafka_consumer, config.Envs.KAFKA_COMMIT_BATCH_SIZE, config.Envs.KAFKA_COMMIT_INTERVAL)
+
+	//offsets are flushed before the reader leaves the group, otherwise whoever gets the partition next replays handled messages
+	defer m.closeConsumer(kafka_consumer, committer, consumerId)
+
+	//commits what was handled before a quiet spell, the ticker stops before the final flush above
+	tickerCtx, stopTicker := context.WithCancel(ctx)
+	tickerDone := make(chan struct{})
+	go func() {
+		defer close(tickerDone)
+		committer.Run(tickerCtx, func(err error) {
+			m.logger.Warn("Failed to commit offsets on interval, will retry",
+				zap.String("topic", subscribedTopic),
+				zap.String("consumer", consumerId),
+				zap.Error(err),
+			)
+		})
+	}()
+	defer func() {
+		stopTicker()
+		<-tickerDone
+	}()
 
 	m.logger.Info(
 		"Consumer started",
-		zap.String("topic", topic),
+		zap.String("topic", subscribedTopic),
 		zap.String("consumer", consumerId),
 	)
+
+	policy := m.policyFor(sourceTopic)
+	readFailures := 0
 	for {
-		key, value, err := kafka_consumer.ReadMessage(ctx)
+		msg, err := kafka_consumer.Fetch(ctx)
 		if err != nil {
 			if ctx.Err() != nil { //context was cancelled, should be a clean shutdown, cancel what the worker is doing
 				break
 			}
 
+			m.logger.Warn("Failed to fetch message",
+				zap.String("topic", subscribedTopic),
+				zap.String("consumer", consumerId),
+				zap.Error(err),
+			)
+			if !sleep(ctx, policy.AttemptBackoff(readFailures)) {
+				break
+			}
+			readFailures++
 			continue
 		}
+		readFailures = 0
+
+		if level > 0 && !kafkaService.WaitUntilDue(ctx, msg) {
+			break
+		}
 
-		if err := m.router.Route(ctx, topic, key, value); err != nil {
-			m.logger.Error("Failed to route message",
-				zap.String("topic", topic),
+		//an unfinished message is never committed, it is fetched again after restart
+		if !m.handle(ctx, sourceTopic, level, policy, msg, consumerId) {
+			break
+		}
+
+		if err := committer.MarkDone(ctx, msg); err != nil {
+			m.logger.Warn("Failed to commit offsets, will retry with the next batch",
+				zap.String("topic", subscribedTopic),
 				zap.String("consumer", consumerId),
 				zap.Error(err),
 			)
@@ -60,6 +133,81 @@ func (m *Manager) consume(ctx context.Context, topic string, consumerId string)
 	}
 }
 
+func (m *Manager) closeConsumer(kafka_consumer kafkaService.Consumer, committer *kafkaService.CommitBatcher, consumerId string) {
+	//ctx is already cancelled at this point, the final flush gets its own deadline
+	flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := committer.Flush(flushCtx); err != nil {
+		m.logger.Error("Failed to flush offsets on shutdown", zap.String("consumer", consumerId), zap.Error(err))
+	}
+
+	if err := kafka_consumer.Close(); err != nil {
+		m.logger.Error("failed to close kafka reader", zap.String("consumer", consumerId), zap.Error(err))
+	}
+}
+
+// returns true once the message is finished, either handled or moved to a retry or dead-letter topic
+func (m *Manager) handle(ctx context.Context, sourceTopic string, level int, policy kafkaService.RetryPolicy, msg kafkaService.Message, consumerId string) bool {
+	var err error
+	for attempt := 0; attempt < max(policy.Attempts, 1); attempt++ {
+		if attempt > 0 && !sleep(ctx, policy.AttemptBackoff(attempt-1)) {
+			return false
+		}
+
+		if err = m.router.Route(ctx, sourceTopic, msg.Key, msg.Value); err == nil {
+			return true
+		}
+
+		m.logger.Warn("Failed to route message",
+			zap.String("topic", msg.Topic),
+			zap.String("consumer", consumerId),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+
+		if kafkaService.IsNonRetryable(err) {
+			break
+		}
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return m.escalate(ctx, sourceTopic, level, policy, msg, err)
+}
+
+// moves the message one step down the retry chain, or to the dead-letter topic once the chain is used up or the message can't be decoded.
+// keeps trying to publish until it succeeds, skipping ahead would commit past a message that went nowhere
+func (m *Manager) escalate(ctx context.Context, sourceTopic string, level int, policy kafkaService.RetryPolicy, msg kafkaService.Message, handleErr error) bool {
+	next := kafkaService.NextRetryMessage(msg, sourceTopic, level, policy, handleErr)
+
+	for attempt := 0; ; attempt++ {
+		err := m.producer.Publish(ctx, next)
+		if err == nil {
+			break
+		}
+
+		m.logger.Error("Failed to move message",
+			zap.String("topic", msg.Topic),
+			zap.String("target", next.Topic),
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+		if !sleep(ctx, policy.AttemptBackoff(attempt)) {
+			return false
+		}
+	}
+
+	m.logger.Info("Moved failed message",
+		zap.String("topic", msg.Topic),
+		zap.String("target", next.Topic),
+		zap.Error(handleErr),
+	)
+	return true
+}
+
 func (m *Manager) Run(ctx context.Context, topics []string) {
 	consumersPerTopic := 3
 
@@ -71,20 +219,40 @@ func (m *Manager) Run(ctx context.Context, topics []string) {
 		for i := 0; i < consumersPerTopic; i++ {
 			m.wg.Add(1)
 
-			go m.consume(ctx, topic, fmt.Sprintf("%s-consumer-%d", topic, i))
+			go m.consume(ctx, topic, topic, 0, fmt.Sprintf("%s-consumer-%d", topic, i))
+		}
+
+		//retry topics carry little traffic, one consumer per level is enough
+		for level := 1; level <= m.policyFor(topic).RetryTopics; level++ {
+			retryTopic := kafkaService.RetryTopic(topic, level)
+			m.wg.Add(1)
+
+			go m.consume(ctx, retryTopic, topic, level, fmt.Sprintf("%s-consumer", retryTopic))
 		}
 	}
 }
 
+// expects the context passed to Run to be cancelled already, every worker flushes its offsets and closes its own reader
 func (m *Manager) Shutdown() {
 	m.logger.Info("shutting down consumer manager")
 
-	for _, r := range m.consumers {
-		if err := r.Close(); err != nil {
-			m.logger.Error("failed to close kafka reader", zap.Error(err))
-		}
-	}
-
 	m.wg.Wait() //waits for all go routines to call Done()
 	m.logger.Info("all workers have shut down")
 }
+
+// returns false when ctx was cancelled before the delay passed
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
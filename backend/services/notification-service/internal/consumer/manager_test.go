@@ -0,0 +1,150 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/config"
+	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/internal/consumer"
+	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/internal/events"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka/kafkatest"
+	"go.uber.org/zap"
+)
+
+const topic = "payment_event"
+
+// counts calls and fails every one of them with err when it is set
+type countingHandler struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (h *countingHandler) Handle(ctx context.Context, key []byte, message []byte) error {
+	h.calls.Add(1)
+	if _, err := kafka.DecodeEvent(message); err != nil {
+		return err
+	}
+	return h.err
+}
+
+// runs a manager for topic against an in-memory broker, stopped when the test ends
+func startManager(t *testing.T, handler events.Handler) *kafkatest.Broker {
+	t.Helper()
+
+	//commit after every message so the test can wait on committed offsets
+	config.Envs.KAFKA_COMMIT_BATCH_SIZE = 1
+
+	broker := kafkatest.NewBroker(1)
+	router := events.NewRouter()
+	router.Register(topic, handler)
+
+	manager := consumer.NewManager(router, broker.NewProducer(""), func(topic string, groupId string) kafka.Consumer {
+		return broker.NewConsumer(topic, groupId)
+	}, zap.NewNop())
+	manager.SetRetryPolicy(topic, kafka.RetryPolicy{
+		Attempts:    2,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		RetryTopics: 1,
+		TopicDelay:  time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager.Run(ctx, []string{topic})
+	t.Cleanup(func() {
+		cancel()
+		manager.Shutdown()
+	})
+
+	return broker
+}
+
+func publishPayment(t *testing.T, broker *kafkatest.Broker) {
+	t.Helper()
+
+	envelope, err := kafka.NewEnvelope(kafka.EventPaymentSucceeded, 1, "order-1", "payment-service", kafka.PaymentSucceeded{OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	if err := broker.NewProducer(topic).PublishEvent(context.Background(), envelope); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+}
+
+func waitForCommit(t *testing.T, broker *kafkatest.Broker, topic string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := broker.WaitForCommit(ctx, config.Envs.KAFKA_GROUP_ID, topic); err != nil {
+		t.Fatalf("%s was not committed: %v", topic, err)
+	}
+}
+
+func TestManagerCommitsHandledMessage(t *testing.T) {
+	handler := &countingHandler{}
+	broker := startManager(t, handler)
+
+	publishPayment(t, broker)
+	waitForCommit(t, broker, topic)
+
+	if calls := handler.calls.Load(); calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if messages := broker.Messages(kafka.RetryTopic(topic, 1)); len(messages) != 0 {
+		t.Fatalf("handled message was moved to the retry topic: %+v", messages)
+	}
+}
+
+func TestManagerEscalatesThroughRetryTopicToDeadLetter(t *testing.T) {
+	handler := &countingHandler{err: errors.New("twilio down")}
+	broker := startManager(t, handler)
+
+	publishPayment(t, broker)
+	waitForCommit(t, broker, topic)
+	waitForCommit(t, broker, kafka.RetryTopic(topic, 1))
+
+	retried := broker.Messages(kafka.RetryTopic(topic, 1))
+	if len(retried) != 1 || retried[0].Headers[kafka.HeaderRetryAttempt] != "1" {
+		t.Fatalf("retry topic = %+v, want the message with attempt 1", retried)
+	}
+
+	deadLettered := broker.Messages(kafka.DeadLetterTopic(topic))
+	if len(deadLettered) != 1 {
+		t.Fatalf("dead-letter topic has %d messages, want 1", len(deadLettered))
+	}
+	headers := deadLettered[0].Headers
+	if headers[kafka.HeaderOriginalTopic] != topic || headers[kafka.HeaderFailureReason] != "twilio down" {
+		t.Fatalf("dead-letter headers = %v", headers)
+	}
+
+	//two in process attempts on the source topic and two on the retry topic
+	if calls := handler.calls.Load(); calls != 4 {
+		t.Fatalf("handler called %d times, want 4", calls)
+	}
+}
+
+func TestManagerDeadLettersUndecodableMessageWithoutRetrying(t *testing.T) {
+	handler := &countingHandler{}
+	broker := startManager(t, handler)
+
+	if err := broker.NewProducer(topic).PublishMessage(context.Background(), []byte("order-1"), []byte("not json")); err != nil {
+		t.Fatalf("PublishMessage: %v", err)
+	}
+	waitForCommit(t, broker, topic)
+
+	if messages := broker.Messages(kafka.RetryTopic(topic, 1)); len(messages) != 0 {
+		t.Fatalf("undecodable message was retried: %+v", messages)
+	}
+	if messages := broker.Messages(kafka.DeadLetterTopic(topic)); len(messages) != 1 {
+		t.Fatalf("dead-letter topic has %d messages, want 1", len(messages))
+	}
+	if calls := handler.calls.Load(); calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+}
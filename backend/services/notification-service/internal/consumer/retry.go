@@ -0,0 +1,19 @@
+package consumer
+
+import (
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/config"
+	kafkaService "github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+// used for every topic that has no policy of its own, see Manager.SetRetryPolicy
+func DefaultRetryPolicy() kafkaService.RetryPolicy {
+	return kafkaService.RetryPolicy{
+		Attempts:    config.Envs.KAFKA_RETRY_ATTEMPTS,
+		Backoff:     config.Envs.KAFKA_RETRY_BACKOFF,
+		MaxBackoff:  5 * time.Second,
+		RetryTopics: config.Envs.KAFKA_RETRY_TOPIC_COUNT,
+		TopicDelay:  config.Envs.KAFKA_RETRY_TOPIC_DELAY,
+	}
+}
@@ -2,10 +2,12 @@ package events
 
 import (
 	"context"
+	"fmt"
 	"log"
 
 	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/internal/client"
 	"github.com/Flow-Indo/LAKOO/backend/services/notification-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
 )
 
 type PaymentHandler struct {
@@ -19,11 +21,49 @@ func NewPaymentHandler(notifier client.WhatsAppService) *PaymentHandler {
 }
 
 func (h *PaymentHandler) Handle(ctx context.Context, key []byte, message []byte) error {
-	log.Printf("key: %s, value: %s", string(key), string(message))
+	envelope, err := kafka.DecodeEvent(message)
+	if err != nil {
+		return err
+	}
+
+	var userId, text string
+	switch envelope.Type {
+	case kafka.EventPaymentSucceeded:
+		var payment kafka.PaymentSucceeded
+		if err := envelope.DecodePayload(&payment); err != nil {
+			return err
+		}
+		text = fmt.Sprintf("Pembayaran %s sebesar Rp%d berhasil diterima.", payment.PaymentNumber, payment.Amount)
+		userId = payment.UserID
+
+	case kafka.EventPaymentFailed:
+		var payment kafka.PaymentFailed
+		if err := envelope.DecodePayload(&payment); err != nil {
+			return err
+		}
+		text = fmt.Sprintf("Pembayaran %s gagal diproses, silakan coba lagi.", payment.PaymentNumber)
+		userId = payment.UserID
+
+	case kafka.EventPaymentExpired:
+		var payment kafka.PaymentExpired
+		if err := envelope.DecodePayload(&payment); err != nil {
+			return err
+		}
+		text = fmt.Sprintf("Pembayaran %s telah kedaluwarsa.", payment.PaymentNumber)
+		userId = payment.UserID
+
+	default:
+		log.Printf("skipping event %s v%d (%s), no notification for this type", envelope.Type, envelope.Version, envelope.ID)
+		return nil
+	}
+
 	whatsAppPayload := types.WhatsAppMessage{
-		UserId: string(key), PhoneNumber: "08119883223", Message: string(message),
+		UserId: userId, PhoneNumber: "08119883223", Message: text,
 	}
-	h.notifier.Send(whatsAppPayload)
-	log.Print("sent to whatsapp")
+	if err := h.notifier.Send(whatsAppPayload); err != nil {
+		return err
+	}
+
+	log.Printf("sent %s notification for event %s to whatsapp", envelope.Type, envelope.ID)
 	return nil
 }
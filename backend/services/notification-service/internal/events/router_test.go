@@ -0,0 +1,48 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type recordingHandler struct {
+	messages []string
+	err      error
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, key []byte, message []byte) error {
+	h.messages = append(h.messages, string(message))
+	return h.err
+}
+
+func TestRouteDispatchesByTopic(t *testing.T) {
+	payments := &recordingHandler{}
+	orders := &recordingHandler{err: errors.New("twilio down")}
+
+	router := NewRouter()
+	router.Register("payment_event", payments)
+	router.Register("order_event", orders)
+
+	if err := router.Route(context.Background(), "payment_event", nil, []byte("paid")); err != nil {
+		t.Fatalf("Route payment_event: %v", err)
+	}
+	if err := router.Route(context.Background(), "order_event", nil, []byte("created")); !errors.Is(err, orders.err) {
+		t.Fatalf("Route order_event error = %v, want the handler's error", err)
+	}
+
+	if len(payments.messages) != 1 || payments.messages[0] != "paid" {
+		t.Fatalf("payment handler got %v", payments.messages)
+	}
+	if len(orders.messages) != 1 || orders.messages[0] != "created" {
+		t.Fatalf("order handler got %v", orders.messages)
+	}
+}
+
+func TestRouteRejectsUnknownTopic(t *testing.T) {
+	router := NewRouter()
+
+	if err := router.Route(context.Background(), "review_event", nil, []byte("{}")); err == nil {
+		t.Fatal("Route to a topic without handler succeeded")
+	}
+}
@@ -7,5 +7,5 @@ type WhatsAppMessage struct {
 }
 
 type BulkWhatsAppPayload struct {
-	messages []WhatsAppMessage `json:"messages"`
+	Messages []WhatsAppMessage `json:"messages"`
 }
@@ -5,21 +5,25 @@ import (
 	"net/http"
 
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/controller"
-	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/middleware"
 	"github.com/gorilla/mux"
-	"gorm.io/gorm"
 )
 
 type APIServer struct {
-	addr string
-	db   *gorm.DB
+	addr          string
+	orderService  *service.OrderService
+	couponService *service.CouponService
+	idempotency   *middleware.IdempotencyStore
 }
 
-func NewAPIServer(addr string, db *gorm.DB) *APIServer {
+// the service is built by main since the kafka consumers share it with the http handlers
+func NewAPIServer(addr string, orderService *service.OrderService, couponService *service.CouponService, idempotency *middleware.IdempotencyStore) *APIServer {
 	return &APIServer{
-		addr: addr,
-		db:   db,
+		addr:          addr,
+		orderService:  orderService,
+		couponService: couponService,
+		idempotency:   idempotency,
 	}
 }
 
@@ -28,12 +32,17 @@ func (s *APIServer) Start() error {
 
 	subrouter := router.PathPrefix("/api/orders").Subrouter()
 
-	orderRepository := repository.NewOrderRepository(s.db)
-	orderService := service.NewService(orderRepository)
-	orderHandler := controller.NewHandler(orderService)
+	idempotent := middleware.IdempotencyMiddleware(s.idempotency)
+	orderHandler := controller.NewHandler(s.orderService, idempotent)
 
 	orderHandler.RegisterRoutes(subrouter)
 
+	groupSessionHandler := controller.NewGroupSessionHandler(s.orderService, idempotent)
+	groupSessionHandler.RegisterRoutes(router.PathPrefix("/api/group-sessions").Subrouter())
+
+	couponHandler := controller.NewCouponHandler(s.couponService, s.orderService)
+	couponHandler.RegisterRoutes(router.PathPrefix("/api/coupons").Subrouter())
+
 	// subrouter.Use(func(next http.Handler) http.Handler {
 	// 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 	// 		fmt.Printf("Received request: %s %s\n", r.Method, r.URL.Path)
@@ -1,12 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/cmd/api"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/config"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/db"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/events"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/jobs"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/idempotency"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/middleware"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/outbox"
 	"gorm.io/gorm"
 )
 
@@ -19,7 +30,87 @@ func main() {
 	}
 
 	initDatabase(database)
-	apiServer := api.NewAPIServer(config.Envs.ORDER_SERVICE_PORT, database)
+
+	//events written by the order service land in the outbox, the relay is the only thing that talks to kafka.
+	//brokers, security and per event type topics come from KAFKA_* env, see kafka.ProducerConfigFromEnv
+	producer, err := kafka.NewProducerFromConfig(kafka.ProducerConfigFromEnv("order-service", "order_event"))
+	if err != nil {
+		log.Fatal("Failed to create kafka producer: ", err)
+	}
+	defer producer.Close()
+
+	relay := outbox.NewRelay(database, producer, outbox.DefaultRelayConfig("order-service"))
+	go relay.Run(context.Background())
+
+	orderRepository := repository.NewOrderRepository(database)
+	//order-service signs its calls to other services the way serviceAuthMiddleware verifies them
+	serviceAuth := clients.ServiceAuth{ServiceName: "order-service", Secret: config.Envs.SERVICE_SECRET}
+	productClient := clients.NewHTTPProductClient(config.Envs.PRODUCT_SERVICE_URL, serviceAuth, config.Envs.SERVICE_TIMEOUT)
+	warehouseClient := clients.NewHTTPWarehouseClient(config.Envs.WAREHOUSE_SERVICE_URL, serviceAuth, config.Envs.SERVICE_TIMEOUT)
+	rateClient := clients.NewHTTPRateClient(config.Envs.LOGISTIC_SERVICE_URL, serviceAuth, config.Envs.SERVICE_TIMEOUT)
+
+	orderService := service.NewService(orderRepository, productClient, warehouseClient, rateClient)
+
+	orderExpiry := jobs.NewOrderExpiry(orderService, jobs.OrderExpiryConfig{
+		Interval:  config.Envs.ORDER_EXPIRY_INTERVAL,
+		BatchSize: config.Envs.ORDER_EXPIRY_BATCH_SIZE,
+		DryRun:    config.Envs.ORDER_EXPIRY_DRY_RUN,
+	})
+	go orderExpiry.Run(context.Background())
+
+	orderCompletion := jobs.NewOrderCompletion(orderService, jobs.OrderCompletionConfig{
+		Interval:  config.Envs.ORDER_COMPLETION_INTERVAL,
+		Window:    config.Envs.ORDER_COMPLETION_WINDOW,
+		BatchSize: config.Envs.ORDER_COMPLETION_BATCH_SIZE,
+	})
+	go orderCompletion.Run(context.Background())
+
+	groupSessions := jobs.NewGroupSessions(orderService, jobs.GroupSessionsConfig{
+		Interval:  config.Envs.GROUP_SESSION_INTERVAL,
+		BatchSize: config.Envs.GROUP_SESSION_BATCH_SIZE,
+	})
+	go groupSessions.Run(context.Background())
+
+	stockReservations := jobs.NewStockReservations(orderService, jobs.StockReservationsConfig{
+		Interval:  config.Envs.RESERVATION_INTERVAL,
+		Lease:     config.Envs.RESERVATION_CLAIM_LEASE,
+		BatchSize: config.Envs.RESERVATION_BATCH_SIZE,
+	})
+	go stockReservations.Run(context.Background())
+
+	//payment events are redelivered on rebalance or restart, the guard keeps an order from being paid twice
+	processedEvents := idempotency.NewStore(database, config.Envs.PROCESSED_EVENT_LEASE)
+	go processedEvents.RunCleanup(context.Background(), config.Envs.PROCESSED_EVENT_TTL, time.Hour)
+
+	//no default topic, retry and dead-letter messages carry their own
+	retryProducer, err := kafka.NewProducerFromConfig(kafka.ProducerConfigFromEnv("order-service", ""))
+	if err != nil {
+		log.Fatal("Failed to create kafka retry producer: ", err)
+	}
+	defer retryProducer.Close()
+
+	//a payment that keeps failing moves down payment_event.retry.N to payment_event.dlq instead of blocking its partition
+	paymentRetries := events.RetryChain{
+		SourceTopic: config.Envs.KAFKA_PAYMENT_TOPIC,
+		Policy: kafka.RetryPolicy{
+			Attempts:    config.Envs.KAFKA_RETRY_ATTEMPTS,
+			Backoff:     config.Envs.KAFKA_RETRY_BACKOFF,
+			MaxBackoff:  30 * time.Second,
+			RetryTopics: config.Envs.KAFKA_RETRY_TOPIC_COUNT,
+			TopicDelay:  config.Envs.KAFKA_RETRY_TOPIC_DELAY,
+		},
+		Producer: retryProducer,
+	}
+	paymentHandler := idempotency.NewGuard(processedEvents, config.Envs.KAFKA_GROUP_ID, events.NewPaymentHandler(orderService))
+	paymentRetries.Run(context.Background(), func(topic string) kafka.Consumer {
+		return kafka.NewConsumer(config.Envs.KAFKA_BROKERS, topic, config.Envs.KAFKA_GROUP_ID)
+	}, paymentHandler)
+
+	//mobile clients retry checkout on flaky networks, a retry with the same Idempotency-Key gets the first response back
+	idempotencyKeys := middleware.NewIdempotencyStore(database, config.Envs.IDEMPOTENCY_KEY_LEASE)
+	go idempotencyKeys.RunCleanup(context.Background(), config.Envs.IDEMPOTENCY_KEY_TTL, time.Hour)
+
+	apiServer := api.NewAPIServer(config.Envs.ORDER_SERVICE_PORT, orderService, service.NewCouponService(orderRepository), idempotencyKeys)
 
 	if err := apiServer.Start(); err != nil {
 		log.Fatal("Failed to start server: ", err)
@@ -1,21 +1,50 @@
 package config
 
 import (
-	"log"
-	"os"
+	"time"
 
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/env"
 	"github.com/lpernett/godotenv"
 )
 
 type Config struct {
-	ORDER_SERVICE_PORT string
-	DB_HOST            string
-	DB_USER            string
-	DB_PASSWORD        string
-	DB_NAME            string
-	DB_PORT            string
-	DB_SSL             string
-	KAFKA_BROKERS      string
+	ORDER_SERVICE_PORT          string
+	DB_HOST                     string
+	DB_USER                     string
+	DB_PASSWORD                 string
+	DB_NAME                     string
+	DB_PORT                     string
+	DB_SSL                      string
+	KAFKA_BROKERS               []string
+	KAFKA_GROUP_ID              string
+	KAFKA_PAYMENT_TOPIC         string
+	KAFKA_RETRY_ATTEMPTS        int
+	KAFKA_RETRY_BACKOFF         time.Duration
+	KAFKA_RETRY_TOPIC_COUNT     int
+	KAFKA_RETRY_TOPIC_DELAY     time.Duration
+	PROCESSED_EVENT_LEASE       time.Duration
+	PROCESSED_EVENT_TTL         time.Duration
+	IDEMPOTENCY_KEY_LEASE       time.Duration //a checkout still running after this long may be retried, well past the slowest checkout
+	IDEMPOTENCY_KEY_TTL         time.Duration
+	ORDER_PAYMENT_WINDOW        time.Duration
+	ORDER_EXPIRY_INTERVAL       time.Duration
+	ORDER_EXPIRY_BATCH_SIZE     int
+	ORDER_EXPIRY_DRY_RUN        bool
+	ORDER_COMPLETION_WINDOW     time.Duration
+	ORDER_COMPLETION_INTERVAL   time.Duration
+	ORDER_COMPLETION_BATCH_SIZE int
+	ORDER_RETURN_WINDOW         time.Duration
+	GROUP_SESSION_INTERVAL      time.Duration
+	GROUP_SESSION_BATCH_SIZE    int
+	SERVICE_SECRET              string
+	SERVICE_TIMEOUT             time.Duration
+	PRODUCT_SERVICE_URL         string //product-service listens on 3002, order-service on 3006 like the architecture plan
+	WAREHOUSE_SERVICE_URL       string
+	RESERVATION_INTERVAL        time.Duration
+	RESERVATION_CLAIM_LEASE     time.Duration //a sweep still calling the warehouse after this long is taken to have died, well past a batch of timeouts
+	RESERVATION_BATCH_SIZE      int
+	LOGISTIC_SERVICE_URL        string
+	SHIPPING_ORIGIN_POSTAL_CODE string //where parcels are quoted from
 }
 
 var Envs = initConfig()
@@ -24,23 +53,46 @@ func initConfig() *Config {
 	godotenv.Load("../.env")
 
 	return &Config{
-		ORDER_SERVICE_PORT: getEnv("ORDER_SERVICE_PORT", "3002"),
-		DB_HOST:            getEnv("DB_HOST", "localhost"),
-		DB_USER:            getEnv("DB_USER", "postgres"),
-		DB_PASSWORD:        getEnv("DB_PASSWORD", "password"),
-		DB_NAME:            getEnv("DB_NAME", "orderdb"),
-		DB_PORT:            getEnv("DB_PORT", "5432"),
-		DB_SSL:             getEnv("DB_SSL", "DISABLED"),
-		KAFKA_BROKERS:      getEnv("KAFKA_BROKERS", "localhost:9092"),
+		ORDER_SERVICE_PORT:          getEnv("ORDER_SERVICE_PORT", "3006"),
+		DB_HOST:                     getEnv("DB_HOST", "localhost"),
+		DB_USER:                     getEnv("DB_USER", "postgres"),
+		DB_PASSWORD:                 getEnv("DB_PASSWORD", "password"),
+		DB_NAME:                     getEnv("DB_NAME", "orderdb"),
+		DB_PORT:                     getEnv("DB_PORT", "5432"),
+		DB_SSL:                      getEnv("DB_SSL", "DISABLED"),
+		KAFKA_BROKERS:               env.GetEnvAsSlice("KAFKA_BROKERS", []string{"localhost:9092"}, ","),
+		KAFKA_GROUP_ID:              getEnv("KAFKA_GROUP_ID", "order-service"),
+		KAFKA_PAYMENT_TOPIC:         getEnv("KAFKA_PAYMENT_TOPIC", "payment_event"),
+		KAFKA_RETRY_ATTEMPTS:        env.GetEnvAsInt("KAFKA_RETRY_ATTEMPTS", 3),
+		KAFKA_RETRY_BACKOFF:         env.GetEnvAsDuration("KAFKA_RETRY_BACKOFF", 500*time.Millisecond),
+		KAFKA_RETRY_TOPIC_COUNT:     env.GetEnvAsInt("KAFKA_RETRY_TOPIC_COUNT", 3),
+		KAFKA_RETRY_TOPIC_DELAY:     env.GetEnvAsDuration("KAFKA_RETRY_TOPIC_DELAY", 30*time.Second),
+		PROCESSED_EVENT_LEASE:       env.GetEnvAsDuration("PROCESSED_EVENT_LEASE", 5*time.Minute),
+		PROCESSED_EVENT_TTL:         env.GetEnvAsDuration("PROCESSED_EVENT_TTL", 14*24*time.Hour),
+		IDEMPOTENCY_KEY_LEASE:       env.GetEnvAsDuration("IDEMPOTENCY_KEY_LEASE", 5*time.Minute),
+		IDEMPOTENCY_KEY_TTL:         env.GetEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		ORDER_PAYMENT_WINDOW:        env.GetEnvAsDuration("ORDER_PAYMENT_WINDOW", 24*time.Hour),
+		ORDER_EXPIRY_INTERVAL:       env.GetEnvAsDuration("ORDER_EXPIRY_INTERVAL", time.Minute),
+		ORDER_EXPIRY_BATCH_SIZE:     env.GetEnvAsInt("ORDER_EXPIRY_BATCH_SIZE", 100),
+		ORDER_EXPIRY_DRY_RUN:        env.GetEnvAsBool("ORDER_EXPIRY_DRY_RUN", false),
+		ORDER_COMPLETION_WINDOW:     env.GetEnvAsDuration("ORDER_COMPLETION_WINDOW", 7*24*time.Hour),
+		ORDER_COMPLETION_INTERVAL:   env.GetEnvAsDuration("ORDER_COMPLETION_INTERVAL", 10*time.Minute),
+		ORDER_COMPLETION_BATCH_SIZE: env.GetEnvAsInt("ORDER_COMPLETION_BATCH_SIZE", 100),
+		ORDER_RETURN_WINDOW:         env.GetEnvAsDuration("ORDER_RETURN_WINDOW", 7*24*time.Hour),
+		GROUP_SESSION_INTERVAL:      env.GetEnvAsDuration("GROUP_SESSION_INTERVAL", time.Minute),
+		GROUP_SESSION_BATCH_SIZE:    env.GetEnvAsInt("GROUP_SESSION_BATCH_SIZE", 100),
+		SERVICE_SECRET:              getEnv("SERVICE_SECRET", ""),
+		SERVICE_TIMEOUT:             env.GetEnvAsDuration("SERVICE_TIMEOUT", 5*time.Second),
+		PRODUCT_SERVICE_URL:         getEnv("PRODUCT_SERVICE_URL", "http://localhost:3002"),
+		WAREHOUSE_SERVICE_URL:       getEnv("WAREHOUSE_SERVICE_URL", "http://localhost:3012"),
+		RESERVATION_INTERVAL:        env.GetEnvAsDuration("RESERVATION_INTERVAL", 15*time.Second),
+		RESERVATION_CLAIM_LEASE:     env.GetEnvAsDuration("RESERVATION_CLAIM_LEASE", 10*time.Minute),
+		RESERVATION_BATCH_SIZE:      env.GetEnvAsInt("RESERVATION_BATCH_SIZE", 50),
+		LOGISTIC_SERVICE_URL:        getEnv("LOGISTIC_SERVICE_URL", "http://localhost:3009"),
+		SHIPPING_ORIGIN_POSTAL_CODE: getEnv("SHIPPING_ORIGIN_POSTAL_CODE", ""),
 	}
 }
 
 func getEnv(key string, fallback string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		log.Println("Value: ", value)
-		return value
-	}
-
-	log.Println("Returning feedback")
-	return fallback
+	return env.GetEnv(key, fallback)
 }
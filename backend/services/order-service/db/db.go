@@ -22,7 +22,8 @@ func NewPostgresStore() (*gorm.DB, error) {
 	// 	PrepareStmt: false,
 	// 	Logger:      logger.Default.LogMode(logger.Info),
 	// }
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError lets callers match unique violations with gorm.ErrDuplicatedKey
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	// db, err := gorm.Open(postgres.Open(dsn), config)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,85 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/auth"
+)
+
+// how order-service identifies itself to other services, the secret is shared by every service
+type ServiceAuth struct {
+	ServiceName string
+	Secret      string
+}
+
+// a non 2xx answer from another service
+type StatusError struct {
+	Service    string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s responded %d: %s", e.Service, e.StatusCode, e.Body)
+}
+
+// the plumbing every http client here shares, requests are signed the way serviceAuthMiddleware verifies them
+type serviceClient struct {
+	service    string
+	baseURL    string
+	auth       ServiceAuth
+	httpClient *http.Client
+}
+
+func newServiceClient(service string, baseURL string, auth ServiceAuth, timeout time.Duration) serviceClient {
+	return serviceClient{
+		service:    service,
+		baseURL:    baseURL,
+		auth:       auth,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// sends body as json when it isn't nil and decodes a 2xx response into out when out isn't nil
+func (c serviceClient) do(ctx context.Context, method string, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(auth.ServiceNameHeader, c.auth.ServiceName)
+	req.Header.Set(auth.ServiceAuthHeader, auth.GenerateServiceToken(c.auth.ServiceName, c.auth.Secret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &StatusError{Service: c.service, StatusCode: resp.StatusCode, Body: string(message)}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
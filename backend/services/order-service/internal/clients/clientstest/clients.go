@@ -0,0 +1,65 @@
+package clientstest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+)
+
+var (
+	_ clients.ProductClient = (*Products)(nil)
+	_ clients.RateClient    = (*Rates)(nil)
+)
+
+// in-memory product-service, unknown ids are answered with clients.ErrProductNotFound like the real service
+type Products struct {
+	mu       sync.Mutex
+	products map[string]clients.Product
+}
+
+func NewProducts(products ...clients.Product) *Products {
+	byId := make(map[string]clients.Product, len(products))
+	for _, product := range products {
+		byId[product.ID] = product
+	}
+
+	return &Products{products: byId}
+}
+
+func (p *Products) GetProduct(ctx context.Context, productId string) (clients.Product, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	product, ok := p.products[productId]
+	if !ok {
+		return clients.Product{}, clients.ErrProductNotFound
+	}
+	return product, nil
+}
+
+// in-memory logistic-service quoting the same rates for every route, Couriers narrows them like the real one
+type Rates struct {
+	Rates []clients.Rate
+	Err   error
+}
+
+func (r *Rates) GetRates(ctx context.Context, request clients.RateRequest) ([]clients.Rate, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	if len(request.Couriers) == 0 {
+		return r.Rates, nil
+	}
+
+	var rates []clients.Rate
+	for _, rate := range r.Rates {
+		for _, courier := range request.Couriers {
+			if rate.Courier == courier {
+				rates = append(rates, rate)
+			}
+		}
+	}
+	return rates, nil
+}
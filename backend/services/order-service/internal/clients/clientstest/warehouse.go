@@ -0,0 +1,130 @@
+package clientstest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+)
+
+var _ clients.WarehouseClient = (*Warehouse)(nil)
+
+// in-memory warehouse-service, stock is per product and products without stock are answered like the real service
+type Warehouse struct {
+	mu           sync.Mutex
+	stock        map[string]int
+	reservations map[string]*fakeReservation
+	next         int
+	settleErr    error
+}
+
+type fakeReservation struct {
+	productId string
+	quantity  int
+	status    string
+}
+
+func NewWarehouse(stock map[string]int) *Warehouse {
+	available := make(map[string]int, len(stock))
+	for productId, quantity := range stock {
+		available[productId] = quantity
+	}
+
+	return &Warehouse{
+		stock:        available,
+		reservations: make(map[string]*fakeReservation),
+	}
+}
+
+func (f *Warehouse) Reserve(ctx context.Context, request clients.ReserveRequest) (clients.Reservation, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	available, ok := f.stock[request.ProductID]
+	if !ok {
+		return clients.Reservation{Message: "Inventory not configured for this product/variant"}, nil
+	}
+	if available < request.Quantity {
+		return clients.Reservation{
+			Message:  fmt.Sprintf("Insufficient stock (need %d, have %d)", request.Quantity, available),
+			Shortage: request.Quantity - available,
+		}, nil
+	}
+
+	f.next++
+	reservationId := fmt.Sprintf("00000000-0000-4000-8000-%012d", f.next)
+	f.stock[request.ProductID] = available - request.Quantity
+	f.reservations[reservationId] = &fakeReservation{productId: request.ProductID, quantity: request.Quantity, status: "reserved"}
+
+	return clients.Reservation{
+		Reserved:       true,
+		ReservationID:  reservationId,
+		AvailableAfter: f.stock[request.ProductID],
+		ExpiresAt:      time.Now().Add(24 * time.Hour),
+	}, nil
+}
+
+// makes every Confirm and Release fail with err until it is called again with nil
+func (f *Warehouse) FailSettling(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.settleErr = err
+}
+
+func (f *Warehouse) Confirm(ctx context.Context, reservationId string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.settleErr != nil {
+		return f.settleErr
+	}
+
+	reservation, ok := f.reservations[reservationId]
+	if !ok || reservation.status != "reserved" {
+		return clients.ErrReservationNotReserved
+	}
+
+	reservation.status = "confirmed"
+	return nil
+}
+
+func (f *Warehouse) Release(ctx context.Context, reservationId string, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.settleErr != nil {
+		return f.settleErr
+	}
+
+	reservation, ok := f.reservations[reservationId]
+	if !ok || reservation.status != "reserved" {
+		return clients.ErrReservationNotReserved
+	}
+
+	reservation.status = "released"
+	f.stock[reservation.productId] += reservation.quantity
+	return nil
+}
+
+// units of a product that can still be reserved
+func (f *Warehouse) Available(productId string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.stock[productId]
+}
+
+// reserved, confirmed or released, empty for a reservation the warehouse never handed out
+func (f *Warehouse) Status(reservationId string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reservation, ok := f.reservations[reservationId]
+	if !ok {
+		return ""
+	}
+	return reservation.status
+}
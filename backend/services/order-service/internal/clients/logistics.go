@@ -0,0 +1,51 @@
+package clients
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// one parcel, Couriers narrows the answer to those courier codes
+type RateRequest struct {
+	OriginPostalCode string   `json:"originPostalCode"`
+	DestPostalCode   string   `json:"destPostalCode"`
+	WeightGrams      int      `json:"weightGrams"`
+	ItemValue        int64    `json:"itemValue,omitempty"`
+	Couriers         []string `json:"couriers,omitempty"`
+}
+
+type Rate struct {
+	Courier       string          `json:"courier"`
+	CourierName   string          `json:"courierName"`
+	ServiceCode   string          `json:"serviceCode"`
+	ServiceName   string          `json:"serviceName"`
+	Rate          decimal.Decimal `json:"rate"`
+	EstimatedDays *string         `json:"estimatedDays"`
+}
+
+// where checkout gets shipping costs from
+type RateClient interface {
+	GetRates(ctx context.Context, request RateRequest) ([]Rate, error)
+}
+
+type HTTPRateClient struct {
+	client serviceClient
+}
+
+func NewHTTPRateClient(baseURL string, auth ServiceAuth, timeout time.Duration) *HTTPRateClient {
+	return &HTTPRateClient{client: newServiceClient("logistic-service", baseURL, auth, timeout)}
+}
+
+// an empty list means no active courier serves the route
+func (c *HTTPRateClient) GetRates(ctx context.Context, request RateRequest) ([]Rate, error) {
+	var response struct {
+		Data []Rate `json:"data"`
+	}
+	if err := c.client.do(ctx, http.MethodPost, "/api/internal/rates", request, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrProductNotFound = errors.New("product not found in product service")
+
+// statuses product-service gives a product, only approved products are live
+const (
+	ProductStatusApproved   = "approved"
+	ProductStatusOutOfStock = "out_of_stock"
+)
+
+// the fields of product-service's product that an order needs, prices and sizes come as decimal strings
+type Product struct {
+	ID              string           `json:"id"`
+	SellerID        *string          `json:"sellerId"`
+	CategoryID      string           `json:"categoryId"`
+	ProductCode     string           `json:"productCode"` //the sku of the product, variants carry their own
+	Name            string           `json:"name"`
+	Description     *string          `json:"description"`
+	BaseSellPrice   decimal.Decimal  `json:"baseSellPrice"`
+	WeightGrams     *int             `json:"weightGrams"`
+	LengthCm        *decimal.Decimal `json:"lengthCm"`
+	WidthCm         *decimal.Decimal `json:"widthCm"`
+	HeightCm        *decimal.Decimal `json:"heightCm"`
+	PrimaryImageURL *string          `json:"primaryImageUrl"`
+	Status          string           `json:"status"`
+	DeletedAt       *time.Time       `json:"deletedAt"`
+	Category        *ProductCategory `json:"category"`
+}
+
+type ProductCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// where order creation gets authoritative prices and availability from
+type ProductClient interface {
+	GetProduct(ctx context.Context, productId string) (Product, error)
+}
+
+type HTTPProductClient struct {
+	client serviceClient
+}
+
+func NewHTTPProductClient(baseURL string, auth ServiceAuth, timeout time.Duration) *HTTPProductClient {
+	return &HTTPProductClient{client: newServiceClient("product-service", baseURL, auth, timeout)}
+}
+
+// product-service answers 404 for unknown ids and returns the product itself, not wrapped in data
+func (c *HTTPProductClient) GetProduct(ctx context.Context, productId string) (Product, error) {
+	var product Product
+	err := c.client.do(ctx, http.MethodGet, "/api/products/id/"+url.PathEscape(productId), nil, &product)
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return Product{}, ErrProductNotFound
+	}
+	return product, err
+}
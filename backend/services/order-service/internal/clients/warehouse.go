@@ -0,0 +1,76 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// release and confirm only act on a reservation that is still reserved, anything else is answered with this
+var ErrReservationNotReserved = errors.New("reservation is no longer reserved")
+
+// one line of an order, the warehouse holds stock for house brand products only
+type ReserveRequest struct {
+	ProductID   string  `json:"productId"`
+	VariantID   *string `json:"variantId"`
+	Quantity    int     `json:"quantity"`
+	OrderID     string  `json:"orderId"`
+	OrderItemID string  `json:"orderItemId"`
+}
+
+// Reserved is false when the warehouse couldn't hold the quantity, Shortage is how many units were missing.
+// a product without inventory comes back unreserved with no shortage
+type Reservation struct {
+	Reserved       bool      `json:"reserved"`
+	ReservationID  string    `json:"reservationId"`
+	Message        string    `json:"message"`
+	Shortage       int       `json:"shortage"`
+	AvailableAfter int       `json:"availableAfter"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// reserve holds stock for an order that isn't paid yet, confirm deducts it for good and release gives it back
+type WarehouseClient interface {
+	Reserve(ctx context.Context, request ReserveRequest) (Reservation, error)
+	Confirm(ctx context.Context, reservationId string) error
+	Release(ctx context.Context, reservationId string, reason string) error
+}
+
+type HTTPWarehouseClient struct {
+	client serviceClient
+}
+
+func NewHTTPWarehouseClient(baseURL string, auth ServiceAuth, timeout time.Duration) *HTTPWarehouseClient {
+	return &HTTPWarehouseClient{client: newServiceClient("warehouse-service", baseURL, auth, timeout)}
+}
+
+func (c *HTTPWarehouseClient) Reserve(ctx context.Context, request ReserveRequest) (Reservation, error) {
+	var reservation Reservation
+	err := c.client.do(ctx, http.MethodPost, "/api/warehouse/reserve-inventory", request, &reservation)
+	return reservation, err
+}
+
+func (c *HTTPWarehouseClient) Confirm(ctx context.Context, reservationId string) error {
+	return c.settle(ctx, "/api/warehouse/confirm-reservation", map[string]string{"reservationId": reservationId})
+}
+
+func (c *HTTPWarehouseClient) Release(ctx context.Context, reservationId string, reason string) error {
+	return c.settle(ctx, "/api/warehouse/release-reservation", map[string]string{"reservationId": reservationId, "reason": reason})
+}
+
+// the warehouse answers 200 with success false when the reservation is unknown or was already settled
+func (c *HTTPWarehouseClient) settle(ctx context.Context, path string, body map[string]string) error {
+	var result struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if err := c.client.do(ctx, http.MethodPost, path, body, &result); err != nil {
+		return err
+	}
+
+	if !result.Success {
+		return errors.Join(ErrReservationNotReserved, errors.New(result.Message))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
+	"github.com/gorilla/mux"
+)
+
+type CouponHandler struct {
+	couponService *service.CouponService
+	orderService  *service.OrderService
+}
+
+func NewCouponHandler(couponService *service.CouponService, orderService *service.OrderService) *CouponHandler {
+	return &CouponHandler{
+		couponService: couponService,
+		orderService:  orderService,
+	}
+}
+
+// management is admin only, validate is what checkout calls before the order is placed
+func (h *CouponHandler) RegisterRoutes(couponRouter *mux.Router) {
+	couponRouter.HandleFunc("/validate", h.validateCoupon).Methods("POST")
+	couponRouter.HandleFunc("", h.getCoupons).Methods("GET")
+	couponRouter.HandleFunc("", h.createCoupon).Methods("POST")
+	couponRouter.HandleFunc("/{couponId}", h.getCoupon).Methods("GET")
+	couponRouter.HandleFunc("/{couponId}", h.updateCoupon).Methods("PUT")
+	couponRouter.HandleFunc("/{couponId}", h.deleteCoupon).Methods("DELETE")
+}
+
+func (h *CouponHandler) validateCoupon(w http.ResponseWriter, r *http.Request) {
+	var payload types.ValidateCouponPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	result, err := h.orderService.ValidateCoupon(r.Context(), payload)
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, result); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (h *CouponHandler) getCoupons(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminOrSystem(w, r); !ok {
+		return
+	}
+
+	var couponFilterPayload types.CouponFilterPayload
+	if err := utils.DecodeQueryParamsWithValidation(&couponFilterPayload, r); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	coupons, err := h.couponService.ListCoupons(r.Context(), couponFilterPayload)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, coupons); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (h *CouponHandler) getCoupon(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminOrSystem(w, r); !ok {
+		return
+	}
+
+	found, err := h.couponService.GetCoupon(r.Context(), mux.Vars(r)["couponId"])
+	writeCoupon(w, http.StatusOK, found, err)
+}
+
+func (h *CouponHandler) createCoupon(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.CouponPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	created, err := h.couponService.CreateCoupon(r.Context(), payload, actorId)
+	writeCoupon(w, http.StatusCreated, created, err)
+}
+
+func (h *CouponHandler) updateCoupon(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminOrSystem(w, r); !ok {
+		return
+	}
+
+	var payload types.CouponPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	updated, err := h.couponService.UpdateCoupon(r.Context(), mux.Vars(r)["couponId"], payload)
+	writeCoupon(w, http.StatusOK, updated, err)
+}
+
+func (h *CouponHandler) deleteCoupon(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminOrSystem(w, r); !ok {
+		return
+	}
+
+	if err := h.couponService.DeleteCoupon(r.Context(), mux.Vars(r)["couponId"]); err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCoupon(w http.ResponseWriter, status int, coupon models.Coupon, err error) {
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, status, coupon); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/middleware"
+	"github.com/gorilla/mux"
+)
+
+type GroupSessionHandler struct {
+	orderService *service.OrderService
+	idempotent   func(http.Handler) http.Handler
+}
+
+func NewGroupSessionHandler(orderService *service.OrderService, idempotent func(http.Handler) http.Handler) *GroupSessionHandler {
+	return &GroupSessionHandler{
+		orderService: orderService,
+		idempotent:   idempotent,
+	}
+}
+
+// sessions are opened by admins, joining is a checkout and takes an Idempotency-Key like POST /api/orders
+func (h *GroupSessionHandler) RegisterRoutes(sessionRouter *mux.Router) {
+	sessionRouter.HandleFunc("", h.createGroupSession).Methods("POST")
+	sessionRouter.HandleFunc("/{sessionId}", h.getGroupSession).Methods("GET")
+	sessionRouter.Handle("/{sessionId}/join", middleware.UserIDMiddleware(h.idempotent(http.HandlerFunc(h.joinGroupSession)))).Methods("POST")
+}
+
+func (h *GroupSessionHandler) createGroupSession(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.CreateGroupSessionPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	session, err := h.orderService.CreateGroupSession(r.Context(), payload, actorId)
+	writeGroupSession(w, http.StatusCreated, session, err)
+}
+
+func (h *GroupSessionHandler) getGroupSession(w http.ResponseWriter, r *http.Request) {
+	session, err := h.orderService.GetGroupSession(r.Context(), mux.Vars(r)["sessionId"])
+	writeGroupSession(w, http.StatusOK, session, err)
+}
+
+func (h *GroupSessionHandler) joinGroupSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var payload types.JoinGroupSessionPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	viewerId, viewerType := viewerFromRequest(r)
+	if viewerType == service.ActorCustomer && payload.UserID != viewerId {
+		utils.WriteError(w, statusCodeFromError(service.ErrNotOrderOwner), service.ErrNotOrderOwner)
+		return
+	}
+
+	payload.IdempotencyKey = middleware.GetIdempotencyKeyFromContext(ctx)
+
+	checkout, err := h.orderService.JoinGroupSession(ctx, mux.Vars(r)["sessionId"], payload)
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusCreated, checkout); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func writeGroupSession(w http.ResponseWriter, status int, session models.GroupSession, err error) {
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, status, session); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
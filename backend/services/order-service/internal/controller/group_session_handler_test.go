@@ -0,0 +1,54 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/controller"
+	"github.com/gorilla/mux"
+)
+
+const testSessionID = "5a4b3c2d-1e0f-4a9b-8c7d-6e5f4a3b2c1d"
+
+func joinBody(userId string) string {
+	return `{
+		"userId": "` + userId + `",
+		"quantity": 1,
+		"shippingAddress": {"name": "Siti", "phone": "081234567890", "address": "Jl. Merdeka 1", "city": "Bandung", "province": "Jawa Barat", "district": "Sumur Bandung"}
+	}`
+}
+
+// joining checks the caller the same way POST /api/orders does, before the service is reached
+func TestJoinGroupSessionRejectsForeignUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{name: "no caller", status: http.StatusUnauthorized},
+		{name: "customer joining for someone else", headers: map[string]string{"x-user-id": otherUserID}, status: http.StatusForbidden},
+		{name: "customer role spelled out", headers: map[string]string{"x-user-id": otherUserID, "x-user-role": "customer"}, status: http.StatusForbidden},
+	}
+
+	router := mux.NewRouter()
+	passthrough := func(next http.Handler) http.Handler { return next }
+	controller.NewGroupSessionHandler(nil, passthrough).RegisterRoutes(router.PathPrefix("/api/group-sessions").Subrouter())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/group-sessions/"+testSessionID+"/join", strings.NewReader(joinBody(testUserID)))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
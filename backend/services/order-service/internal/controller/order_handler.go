@@ -1,39 +1,53 @@
 package controller
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/coupon"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/middleware"
 	"github.com/gorilla/mux"
 )
 
+var errAdminOrSystemOnly = errors.New("admin or internal caller required")
+
 type OrderHandler struct {
 	orderService *service.OrderService
+	idempotent   func(http.Handler) http.Handler
 }
 
-func NewHandler(orderService *service.OrderService) *OrderHandler {
+func NewHandler(orderService *service.OrderService, idempotent func(http.Handler) http.Handler) *OrderHandler {
 	return &OrderHandler{
 		orderService: orderService,
+		idempotent:   idempotent,
 	}
 }
 
 func (h *OrderHandler) RegisterRoutes(orderRouter *mux.Router) {
 
-	orderRouter.HandleFunc("", h.getOrders).Methods("GET")
-	orderRouter.HandleFunc("", h.createOrder).Methods("POST")
+	orderRouter.Handle("", middleware.UserIDMiddleware(http.HandlerFunc(h.getOrders))).Methods("GET")
+	//the caller is known before the idempotency key is claimed, keys are scoped to it
+	orderRouter.Handle("", middleware.UserIDMiddleware(h.idempotent(http.HandlerFunc(h.createOrder)))).Methods("POST")
 	// orderRouter.HandleFunc("/bulk", h.orderService.createBulkOrders).Methods("POST")
-	// orderRouter.HandleFunc("/{orderId}/cancel", h.orderService.cancelOrder).Methods("POST")
-	// orderRouter.HandleFunc("/stats", h.orderService.getOrderStats).Methods("GET")
-	// orderRouter.HandleFunc("/user/{userId}", h.orderService.getUserOrders).Methods("GET")
-	// orderRouter.HandleFunc("/factory/{factoryId}", h.orderService.getFactoryOrders).Methods("GET")
-	// orderRouter.HandleFunc("number/{orderNumber}", h.orderService.getOrderByNumber).Methods("GET")
-	// orderRouter.HandleFunc("/{orderId}", h.orderService.getOrderById).Methods("GET")
-	// orderRouter.HandleFunc("/{orderId}/status", h.orderService.updateOrderStatus).Methods("PUT")
-	// orderRouter.HandleFunc("/{orderId}/shipping-cost", h.orderService.updateShippingCost).Methods("PUT")
+	orderRouter.Handle("/{orderId}/cancel", middleware.UserIDMiddleware(http.HandlerFunc(h.cancelOrder))).Methods("POST")
+	orderRouter.Handle("/{orderId}/confirm-received", middleware.UserIDMiddleware(http.HandlerFunc(h.confirmReceived))).Methods("POST")
+	orderRouter.HandleFunc("/stats", h.getOrderStats).Methods("GET")
+	orderRouter.Handle("/user/{userId}", middleware.UserIDMiddleware(http.HandlerFunc(h.getUserOrders))).Methods("GET")
+	orderRouter.Handle("/factory/{factoryId}", middleware.UserIDMiddleware(http.HandlerFunc(h.getFactoryOrders))).Methods("GET")
+	orderRouter.Handle("/number/{orderNumber}", middleware.UserIDMiddleware(http.HandlerFunc(h.getOrderByNumber))).Methods("GET")
+	//the pattern keeps words like stats from being read as an order id
+	orderRouter.Handle("/{orderId:[0-9a-fA-F-]{36}}", middleware.UserIDMiddleware(http.HandlerFunc(h.getOrderById))).Methods("GET")
+	orderRouter.HandleFunc("/{orderId}/status", h.updateOrderStatus).Methods("PUT")
+	orderRouter.HandleFunc("/{orderId}/shipping-cost", h.updateShippingCost).Methods("PUT")
+
+	h.registerReturnRoutes(orderRouter)
 }
 
+// customers only ever list their own orders, listing across users is left to admins and internal callers
 func (h *OrderHandler) getOrders(w http.ResponseWriter, r *http.Request) {
 	var orderFilterPayload types.OrderFilterPayload
 	if err := utils.DecodeQueryParamsWithValidation(&orderFilterPayload, r); err != nil {
@@ -41,11 +55,43 @@ func (h *OrderHandler) getOrders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orders, err := h.orderService.GetOrders(orderFilterPayload)
+	viewerId, viewerType := viewerFromRequest(r)
+	if viewerType == service.ActorCustomer {
+		if orderFilterPayload.UserID != "" && orderFilterPayload.UserID != viewerId {
+			utils.WriteError(w, statusCodeFromError(service.ErrNotOrderOwner), service.ErrNotOrderOwner)
+			return
+		}
+		orderFilterPayload.UserID = viewerId
+	}
+
+	h.listOrders(w, r, orderFilterPayload)
+}
+
+// page/limit by default, keyset pagination once a cursor is passed
+func (h *OrderHandler) listOrders(w http.ResponseWriter, r *http.Request, orderFilterPayload types.OrderFilterPayload) {
+	if orderFilterPayload.Cursor != nil {
+		h.getOrdersByCursor(w, r, orderFilterPayload)
+		return
+	}
+
+	orders, err := h.orderService.GetOrders(r.Context(), orderFilterPayload)
 	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, orders); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
 		return
 	}
+}
+
+func (h *OrderHandler) getOrdersByCursor(w http.ResponseWriter, r *http.Request, orderFilterPayload types.OrderFilterPayload) {
+	orders, err := h.orderService.GetOrdersByCursor(r.Context(), orderFilterPayload)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
 
 	if err := utils.WriteJSONResponse(w, http.StatusOK, orders); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
@@ -53,15 +99,321 @@ func (h *OrderHandler) getOrders(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// dashboards of the support console and, through the internal api, the seller dashboard
+func (h *OrderHandler) getOrderStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminOrSystem(w, r); !ok {
+		return
+	}
+
+	var orderStatsPayload types.OrderStatsPayload
+	if err := utils.DecodeQueryParamsWithValidation(&orderStatsPayload, r); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := h.orderService.GetOrderStats(r.Context(), orderStatsPayload)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, stats); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (h *OrderHandler) getUserOrders(w http.ResponseWriter, r *http.Request) {
+	userId := mux.Vars(r)["userId"]
+
+	viewerId, viewerType := viewerFromRequest(r)
+	if viewerType == service.ActorCustomer && userId != viewerId {
+		utils.WriteError(w, statusCodeFromError(service.ErrNotOrderOwner), service.ErrNotOrderOwner)
+		return
+	}
+
+	var orderFilterPayload types.OrderFilterPayload
+	if err := utils.DecodeQueryParamsWithValidation(&orderFilterPayload, r); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orderFilterPayload.UserID = userId
+	h.listOrders(w, r, orderFilterPayload)
+}
+
+// orders containing at least one item from the factory, for the support console
+func (h *OrderHandler) getFactoryOrders(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminOrSystem(w, r); !ok {
+		return
+	}
+
+	var orderFilterPayload types.OrderFilterPayload
+	if err := utils.DecodeQueryParamsWithValidation(&orderFilterPayload, r); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	orderFilterPayload.FactoryID = mux.Vars(r)["factoryId"]
+	h.listOrders(w, r, orderFilterPayload)
+}
+
+func (h *OrderHandler) getOrderById(w http.ResponseWriter, r *http.Request) {
+	viewerId, viewerType := viewerFromRequest(r)
+
+	order, err := h.orderService.GetOrderDetail(r.Context(), mux.Vars(r)["orderId"], viewerId, viewerType)
+	writeOrderDetail(w, order, err)
+}
+
+func (h *OrderHandler) getOrderByNumber(w http.ResponseWriter, r *http.Request) {
+	viewerId, viewerType := viewerFromRequest(r)
+
+	order, err := h.orderService.GetOrderDetailByNumber(r.Context(), mux.Vars(r)["orderNumber"], viewerId, viewerType)
+	writeOrderDetail(w, order, err)
+}
+
+func writeOrderDetail(w http.ResponseWriter, order types.OrderDetailResponse, err error) {
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, order); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// customers only order for themselves, admins and internal callers may place an order for any user
 func (h *OrderHandler) createOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var createOrderPayload types.CreateOrderPayload
 	if err := utils.ParseJSONBody(r.Body, &createOrderPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.ValidatePayload(createOrderPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	viewerId, viewerType := viewerFromRequest(r)
+	if viewerType == service.ActorCustomer && createOrderPayload.UserID != viewerId {
+		utils.WriteError(w, statusCodeFromError(service.ErrNotOrderOwner), service.ErrNotOrderOwner)
+		return
+	}
+
+	createOrderPayload.IdempotencyKey = middleware.GetIdempotencyKeyFromContext(ctx)
+
+	order, err := h.orderService.CreateOrder(createOrderPayload, ctx)
+	if err != nil {
+		writeCheckoutError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusCreated, order); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
 	}
+}
+
+// admins and internal callers only, a customer could otherwise mark their own order paid
+func (h *OrderHandler) updateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orderId := mux.Vars(r)["orderId"]
+
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+	_, actorType := actorFromRequest(r)
+
+	var updateOrderStatusPayload types.UpdateOrderStatusPayload
+	if err := utils.ParseJSONBody(r.Body, &updateOrderStatusPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.ValidatePayload(updateOrderStatusPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := h.orderService.UpdateOrderStatus(ctx, orderId, updateOrderStatusPayload, actorId, actorType)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, order); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// admins and internal callers only, the order is priced again and the new breakdown returned
+func (h *OrderHandler) updateShippingCost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orderId := mux.Vars(r)["orderId"]
+
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+
+	var updateShippingCostPayload types.UpdateShippingCostPayload
+	if err := utils.ParseJSONBody(r.Body, &updateShippingCostPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.ValidatePayload(updateShippingCostPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	order, err := h.orderService.UpdateShippingCost(ctx, orderId, updateShippingCostPayload, actorId)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, order); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (h *OrderHandler) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orderId := mux.Vars(r)["orderId"]
+
+	var cancelOrderPayload types.CancelOrderPayload
+	if err := utils.ParseJSONBody(r.Body, &cancelOrderPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := utils.ValidatePayload(cancelOrderPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	actorId, actorType := viewerFromRequest(r)
+	order, err := h.orderService.CancelOrder(ctx, orderId, cancelOrderPayload, actorId, actorType)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, order); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (h *OrderHandler) confirmReceived(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	orderId := mux.Vars(r)["orderId"]
 
-	if err := h.orderService.CreateOrder(createOrderPayload, ctx); err != nil {
+	actorId, _ := viewerFromRequest(r)
+	order, err := h.orderService.ConfirmReceived(ctx, orderId, actorId)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, order); err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+// x-user-id and x-user-role are set by the gateway after it validated the token, internal callers carry role internal
+func actorFromRequest(r *http.Request) (string, string) {
+	actorId := r.Header.Get("x-user-id")
+
+	switch r.Header.Get("x-user-role") {
+	case "admin":
+		return actorId, service.ActorAdmin
+	case "internal", "system":
+		return actorId, service.ActorSystem
+	default:
+		return actorId, service.ActorCustomer
+	}
+}
+
+// the caller on routes behind middleware.UserIDMiddleware, the role decides whether ownership is checked
+func viewerFromRequest(r *http.Request) (string, string) {
+	userId, _ := middleware.GetUserIdFromContext(r.Context())
+	_, actorType := actorFromRequest(r)
+	return userId, actorType
+}
+
+// writes a 403 and returns false for customers, admins and internal callers pass
+func requireAdminOrSystem(w http.ResponseWriter, r *http.Request) (string, bool) {
+	actorId, actorType := actorFromRequest(r)
+	if actorType == service.ActorCustomer {
+		utils.WriteError(w, http.StatusForbidden, errAdminOrSystemOnly)
+		return "", false
 	}
 
+	return actorId, true
+}
+
+// like utils.WriteError, items that can't be ordered are listed line by line so the whole cart can be fixed at once
+func writeCheckoutError(w http.ResponseWriter, err error) {
+	var unavailable *service.UnavailableItemsError
+	if !errors.As(err, &unavailable) {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	utils.WriteJSONResponse(w, http.StatusUnprocessableEntity, map[string]any{
+		"error": err.Error(),
+		"items": unavailable.Items,
+	})
+}
+
+func statusCodeFromError(err error) int {
+	switch {
+	case errors.Is(err, service.ErrOrderNotFound),
+		errors.Is(err, service.ErrReturnNotFound),
+		errors.Is(err, service.ErrCouponNotFound),
+		errors.Is(err, service.ErrGroupSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidCursor),
+		errors.Is(err, service.ErrOrderItemNotFound),
+		errors.Is(err, service.ErrReturnQuantityExceeded),
+		errors.Is(err, service.ErrInvalidCoupon),
+		errors.Is(err, service.ErrInvalidStatsRange),
+		errors.Is(err, service.ErrInvalidGroupSession),
+		errors.Is(err, service.ErrInvalidShippingCost),
+		errors.Is(err, statemachine.ErrUnknownStatus):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrNotOrderOwner):
+		return http.StatusForbidden
+	case errors.Is(err, statemachine.ErrInvalidTransition),
+		errors.Is(err, statemachine.ErrInvalidReturnTransition),
+		errors.Is(err, service.ErrOrderNotReturnable),
+		errors.Is(err, service.ErrReturnWindowClosed),
+		errors.Is(err, service.ErrCouponCodeTaken),
+		errors.Is(err, service.ErrIdempotencyKeyInUse),
+		errors.Is(err, service.ErrGroupSessionClosed),
+		errors.Is(err, service.ErrGroupSessionFull),
+		errors.Is(err, service.ErrGroupSessionNotFilled),
+		errors.Is(err, service.ErrShippingCostLocked):
+		return http.StatusConflict
+	case errors.Is(err, coupon.ErrNotApplicable),
+		errors.Is(err, service.ErrItemsUnavailable),
+		errors.Is(err, service.ErrNoShippingRate):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrProductServiceUnavailable),
+		errors.Is(err, service.ErrWarehouseUnavailable),
+		errors.Is(err, service.ErrShippingUnavailable):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
 }
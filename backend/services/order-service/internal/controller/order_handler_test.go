@@ -0,0 +1,89 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/controller"
+	"github.com/gorilla/mux"
+)
+
+const (
+	testUserID  = "6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"
+	otherUserID = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+	testOrderID = "8e7d6c5b-4a39-4b28-9c17-0f6e5d4c3b2a"
+)
+
+// the requests below are all turned away before the service is reached, so the handler runs without one
+func newRouter() *mux.Router {
+	router := mux.NewRouter()
+	passthrough := func(next http.Handler) http.Handler { return next }
+	controller.NewHandler(nil, passthrough).RegisterRoutes(router.PathPrefix("/api/orders").Subrouter())
+	return router
+}
+
+func orderBody(userId string) string {
+	return `{
+		"userId": "` + userId + `",
+		"items": [{"productId": "2b7e4c1a-5d3f-4a8e-9b6c-7d8e9f0a1b2c", "quantity": 1}],
+		"shippingAddress": {"name": "Siti", "phone": "081234567890", "address": "Jl. Merdeka 1", "city": "Bandung", "province": "Jawa Barat", "district": "Sumur Bandung"}
+	}`
+}
+
+func TestCreateOrderRejectsForeignUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{name: "no caller", status: http.StatusUnauthorized},
+		{name: "customer ordering for someone else", headers: map[string]string{"x-user-id": otherUserID}, status: http.StatusForbidden},
+		{name: "customer role spelled out", headers: map[string]string{"x-user-id": otherUserID, "x-user-role": "customer"}, status: http.StatusForbidden},
+	}
+
+	router := newRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(orderBody(testUserID)))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+// order changes on behalf of a user go through UserIDMiddleware, the service never sees a request without a caller
+func TestOrderChangesRequireCaller(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "cancel", path: "/api/orders/" + testOrderID + "/cancel", body: `{"reason": "changed my mind"}`},
+		{name: "confirm received", path: "/api/orders/" + testOrderID + "/confirm-received"},
+	}
+
+	router := newRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("x-user-role", "admin")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnauthorized, rec.Body)
+			}
+		})
+	}
+}
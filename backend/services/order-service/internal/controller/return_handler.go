@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
+	"github.com/gorilla/mux"
+)
+
+// registered on the order router, customers drive requested -> in_transit and admins the rest
+func (h *OrderHandler) registerReturnRoutes(orderRouter *mux.Router) {
+	orderRouter.HandleFunc("/{orderId}/returns", h.requestReturn).Methods("POST")
+	orderRouter.HandleFunc("/{orderId}/returns", h.getOrderReturns).Methods("GET")
+	orderRouter.HandleFunc("/returns/{returnId}/approve", h.approveReturn).Methods("POST")
+	orderRouter.HandleFunc("/returns/{returnId}/reject", h.rejectReturn).Methods("POST")
+	orderRouter.HandleFunc("/returns/{returnId}/ship", h.shipReturn).Methods("POST")
+	orderRouter.HandleFunc("/returns/{returnId}/cancel", h.cancelReturn).Methods("POST")
+	orderRouter.HandleFunc("/returns/{returnId}/receive", h.receiveReturn).Methods("POST")
+	orderRouter.HandleFunc("/returns/{returnId}/inspection", h.inspectReturn).Methods("POST")
+	orderRouter.HandleFunc("/returns/{returnId}/complete", h.completeReturn).Methods("POST")
+}
+
+func (h *OrderHandler) requestReturn(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateReturnPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	actorId, _ := actorFromRequest(r)
+	if actorId == "" {
+		utils.WriteError(w, http.StatusUnauthorized, errors.New("userID not found in request"))
+		return
+	}
+
+	ret, err := h.orderService.RequestReturn(r.Context(), mux.Vars(r)["orderId"], payload, actorId)
+	writeReturn(w, http.StatusCreated, ret, err)
+}
+
+func (h *OrderHandler) getOrderReturns(w http.ResponseWriter, r *http.Request) {
+	actorId, actorType := actorFromRequest(r)
+
+	returns, err := h.orderService.GetOrderReturns(r.Context(), mux.Vars(r)["orderId"], actorId, actorType)
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, http.StatusOK, returns); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
+
+func (h *OrderHandler) approveReturn(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+
+	ret, err := h.orderService.ApproveReturn(r.Context(), mux.Vars(r)["returnId"], actorId)
+	writeReturn(w, http.StatusOK, ret, err)
+}
+
+func (h *OrderHandler) rejectReturn(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.RejectReturnPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	ret, err := h.orderService.RejectReturn(r.Context(), mux.Vars(r)["returnId"], payload, actorId)
+	writeReturn(w, http.StatusOK, ret, err)
+}
+
+func (h *OrderHandler) shipReturn(w http.ResponseWriter, r *http.Request) {
+	var payload types.ShipReturnPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	actorId, _ := actorFromRequest(r)
+	ret, err := h.orderService.ShipReturn(r.Context(), mux.Vars(r)["returnId"], payload, actorId)
+	writeReturn(w, http.StatusOK, ret, err)
+}
+
+func (h *OrderHandler) cancelReturn(w http.ResponseWriter, r *http.Request) {
+	actorId, _ := actorFromRequest(r)
+
+	ret, err := h.orderService.CancelReturn(r.Context(), mux.Vars(r)["returnId"], actorId)
+	writeReturn(w, http.StatusOK, ret, err)
+}
+
+func (h *OrderHandler) receiveReturn(w http.ResponseWriter, r *http.Request) {
+	if _, ok := requireAdminOrSystem(w, r); !ok {
+		return
+	}
+
+	ret, err := h.orderService.ReceiveReturn(r.Context(), mux.Vars(r)["returnId"])
+	writeReturn(w, http.StatusOK, ret, err)
+}
+
+func (h *OrderHandler) inspectReturn(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.InspectReturnPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	ret, err := h.orderService.InspectReturn(r.Context(), mux.Vars(r)["returnId"], payload, actorId)
+	writeReturn(w, http.StatusOK, ret, err)
+}
+
+func (h *OrderHandler) completeReturn(w http.ResponseWriter, r *http.Request) {
+	actorId, ok := requireAdminOrSystem(w, r)
+	if !ok {
+		return
+	}
+
+	var payload types.CompleteReturnPayload
+	if !decodeAndValidate(w, r, &payload) {
+		return
+	}
+
+	ret, err := h.orderService.CompleteReturn(r.Context(), mux.Vars(r)["returnId"], payload, actorId)
+	writeReturn(w, http.StatusOK, ret, err)
+}
+
+// writes the 400 itself, callers only return on false
+func decodeAndValidate(w http.ResponseWriter, r *http.Request, payload any) bool {
+	if err := utils.ParseJSONBody(r.Body, payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+
+	if err := utils.ValidatePayload(payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err)
+		return false
+	}
+
+	return true
+}
+
+func writeReturn(w http.ResponseWriter, status int, ret models.Return, err error) {
+	if err != nil {
+		utils.WriteError(w, statusCodeFromError(err), err)
+		return
+	}
+
+	if err := utils.WriteJSONResponse(w, status, ret); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+}
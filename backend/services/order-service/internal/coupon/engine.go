@@ -0,0 +1,195 @@
+package coupon
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	DiscountPercentage   = "percentage"
+	DiscountFixedAmount  = "fixed_amount"
+	DiscountFreeShipping = "free_shipping"
+)
+
+// every rule violation wraps ErrNotApplicable, so callers can map them all to one response
+var (
+	ErrNotApplicable       = errors.New("coupon not applicable")
+	ErrUnknownCode         = fmt.Errorf("%w: coupon code does not exist", ErrNotApplicable)
+	ErrInactive            = fmt.Errorf("%w: coupon is not active", ErrNotApplicable)
+	ErrNotStarted          = fmt.Errorf("%w: coupon is not valid yet", ErrNotApplicable)
+	ErrExpired             = fmt.Errorf("%w: coupon has expired", ErrNotApplicable)
+	ErrUsageLimitReached   = fmt.Errorf("%w: coupon has been fully redeemed", ErrNotApplicable)
+	ErrUserLimitReached    = fmt.Errorf("%w: coupon already used the maximum number of times", ErrNotApplicable)
+	ErrNewCustomersOnly    = fmt.Errorf("%w: coupon is for new customers only", ErrNotApplicable)
+	ErrMinOrderNotMet      = fmt.Errorf("%w: order does not reach the minimum amount", ErrNotApplicable)
+	ErrNoEligibleItems     = fmt.Errorf("%w: no item in the order qualifies", ErrNotApplicable)
+	ErrUnknownDiscountType = errors.New("unknown discount type")
+)
+
+type Line struct {
+	ID         string // caller's key for the line, echoed back in the breakdown
+	ProductID  string
+	BrandID    string
+	CategoryID string
+	Quantity   int
+	Subtotal   decimal.Decimal
+}
+
+type Cart struct {
+	Lines        []Line
+	ShippingCost decimal.Decimal
+	UserUsages   int  // times this user already redeemed the coupon
+	NewCustomer  bool // the user has no earlier order that went through
+}
+
+type LineDiscount struct {
+	LineID    string          `json:"lineId"`
+	ProductID string          `json:"productId"`
+	Subtotal  decimal.Decimal `json:"subtotal"`
+	Discount  decimal.Decimal `json:"discount"`
+}
+
+type Result struct {
+	CouponID         string          `json:"couponId"`
+	Code             string          `json:"code"`
+	DiscountType     string          `json:"discountType"`
+	EligibleSubtotal decimal.Decimal `json:"eligibleSubtotal"`
+	ItemDiscount     decimal.Decimal `json:"itemDiscount"`
+	ShippingDiscount decimal.Decimal `json:"shippingDiscount"`
+	TotalDiscount    decimal.Decimal `json:"totalDiscount"`
+	Lines            []LineDiscount  `json:"lines"`
+}
+
+// checks every rule of the coupon against the cart and prices the discount in whole rupiah, rounded down.
+// the usage counters are read from coupon and cart, callers that redeem must hold the coupon row lock
+func Evaluate(coupon models.Coupon, cart Cart, now time.Time) (Result, error) {
+	if err := checkAvailability(coupon, cart, now); err != nil {
+		return Result{}, err
+	}
+
+	cartSubtotal := decimal.Zero
+	for _, line := range cart.Lines {
+		cartSubtotal = cartSubtotal.Add(line.Subtotal)
+	}
+
+	if coupon.MinOrderAmount != nil && cartSubtotal.LessThan(*coupon.MinOrderAmount) {
+		return Result{}, ErrMinOrderNotMet
+	}
+
+	eligible := make([]Line, 0, len(cart.Lines))
+	eligibleSubtotal := decimal.Zero
+	for _, line := range cart.Lines {
+		if isEligible(coupon, line) {
+			eligible = append(eligible, line)
+			eligibleSubtotal = eligibleSubtotal.Add(line.Subtotal)
+		}
+	}
+
+	if len(eligible) == 0 || !eligibleSubtotal.IsPositive() {
+		return Result{}, ErrNoEligibleItems
+	}
+
+	result := Result{
+		CouponID:         coupon.ID,
+		Code:             coupon.Code,
+		DiscountType:     coupon.DiscountType,
+		EligibleSubtotal: eligibleSubtotal,
+		ItemDiscount:     decimal.Zero,
+		ShippingDiscount: decimal.Zero,
+	}
+
+	switch coupon.DiscountType {
+	case DiscountPercentage:
+		result.ItemDiscount = capDiscount(eligibleSubtotal.Mul(coupon.DiscountValue).Div(decimal.NewFromInt(100)), coupon.MaxDiscountAmount)
+	case DiscountFixedAmount:
+		result.ItemDiscount = capDiscount(coupon.DiscountValue, nil)
+	case DiscountFreeShipping:
+		result.ShippingDiscount = capDiscount(cart.ShippingCost, coupon.MaxDiscountAmount)
+	default:
+		return Result{}, fmt.Errorf("%w: %s", ErrUnknownDiscountType, coupon.DiscountType)
+	}
+
+	//a discount never makes the eligible items cheaper than free
+	if result.ItemDiscount.GreaterThan(eligibleSubtotal) {
+		result.ItemDiscount = eligibleSubtotal
+	}
+
+	result.Lines = allocate(eligible, eligibleSubtotal, result.ItemDiscount)
+	result.TotalDiscount = result.ItemDiscount.Add(result.ShippingDiscount)
+	return result, nil
+}
+
+func checkAvailability(coupon models.Coupon, cart Cart, now time.Time) error {
+	switch {
+	case !coupon.IsActive || coupon.DeletedAt != nil:
+		return ErrInactive
+	case now.Before(coupon.StartsAt):
+		return ErrNotStarted
+	case !now.Before(coupon.ExpiresAt):
+		return ErrExpired
+	case coupon.TotalUsageLimit != nil && coupon.UsedCount >= *coupon.TotalUsageLimit:
+		return ErrUsageLimitReached
+	case coupon.PerUserLimit > 0 && cart.UserUsages >= coupon.PerUserLimit:
+		return ErrUserLimitReached
+	case coupon.NewCustomersOnly && !cart.NewCustomer:
+		return ErrNewCustomersOnly
+	default:
+		return nil
+	}
+}
+
+// empty allow lists admit everything, the exclude list always wins
+func isEligible(coupon models.Coupon, line Line) bool {
+	if coupon.ExcludedProductIDs.Contains(line.ProductID) {
+		return false
+	}
+	if len(coupon.ApplicableProductIDs) > 0 && !coupon.ApplicableProductIDs.Contains(line.ProductID) {
+		return false
+	}
+	if len(coupon.ApplicableBrandIDs) > 0 && !coupon.ApplicableBrandIDs.Contains(line.BrandID) {
+		return false
+	}
+	if len(coupon.ApplicableCategoryIDs) > 0 && !coupon.ApplicableCategoryIDs.Contains(line.CategoryID) {
+		return false
+	}
+	return true
+}
+
+func capDiscount(discount decimal.Decimal, max *decimal.Decimal) decimal.Decimal {
+	if max != nil && discount.GreaterThan(*max) {
+		discount = *max
+	}
+	if discount.IsNegative() {
+		return decimal.Zero
+	}
+	return discount.Floor()
+}
+
+// splits the discount over the lines by their share of the subtotal, the rounding remainder lands on the largest line
+func allocate(lines []Line, subtotal decimal.Decimal, discount decimal.Decimal) []LineDiscount {
+	allocations := make([]LineDiscount, len(lines))
+	allocated := decimal.Zero
+	largest := 0
+
+	for i, line := range lines {
+		share := discount.Mul(line.Subtotal).Div(subtotal).Floor()
+		allocations[i] = LineDiscount{
+			LineID:    line.ID,
+			ProductID: line.ProductID,
+			Subtotal:  line.Subtotal,
+			Discount:  share,
+		}
+		allocated = allocated.Add(share)
+
+		if line.Subtotal.GreaterThan(lines[largest].Subtotal) {
+			largest = i
+		}
+	}
+
+	allocations[largest].Discount = allocations[largest].Discount.Add(discount.Sub(allocated))
+	return allocations
+}
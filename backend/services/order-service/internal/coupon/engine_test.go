@@ -0,0 +1,241 @@
+package coupon_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/coupon"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/shopspring/decimal"
+)
+
+var now = time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func rupiah(amount int64) decimal.Decimal {
+	return decimal.NewFromInt(amount)
+}
+
+func rupiahPtr(amount int64) *decimal.Decimal {
+	value := rupiah(amount)
+	return &value
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+// an active coupon valid around now, tests override what they exercise
+func baseCoupon(discountType string, value int64) models.Coupon {
+	return models.Coupon{
+		ID:            "coupon-1",
+		Code:          "HEMAT",
+		DiscountType:  discountType,
+		DiscountValue: rupiah(value),
+		PerUserLimit:  1,
+		IsActive:      true,
+		StartsAt:      now.Add(-time.Hour),
+		ExpiresAt:     now.Add(time.Hour),
+	}
+}
+
+func line(id string, productId string, subtotal int64) coupon.Line {
+	return coupon.Line{ID: id, ProductID: productId, BrandID: "brand-" + productId, Quantity: 1, Subtotal: rupiah(subtotal)}
+}
+
+func TestEvaluateDiscounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		coupon   func(c *models.Coupon)
+		kind     string
+		value    int64
+		cart     coupon.Cart
+		item     int64
+		shipping int64
+		lines    []int64
+	}{
+		{
+			name:  "percentage",
+			kind:  coupon.DiscountPercentage,
+			value: 10,
+			cart:  coupon.Cart{Lines: []coupon.Line{line("0", "a", 150000)}},
+			item:  15000,
+			lines: []int64{15000},
+		},
+		{
+			name:   "percentage capped at max discount",
+			kind:   coupon.DiscountPercentage,
+			value:  50,
+			coupon: func(c *models.Coupon) { c.MaxDiscountAmount = rupiahPtr(25000) },
+			cart:   coupon.Cart{Lines: []coupon.Line{line("0", "a", 150000)}},
+			item:   25000,
+			lines:  []int64{25000},
+		},
+		{
+			name:  "percentage rounded down to whole rupiah",
+			kind:  coupon.DiscountPercentage,
+			value: 15,
+			cart:  coupon.Cart{Lines: []coupon.Line{line("0", "a", 33333)}},
+			item:  4999,
+			lines: []int64{4999},
+		},
+		{
+			name:  "fixed amount never more than the eligible items",
+			kind:  coupon.DiscountFixedAmount,
+			value: 100000,
+			cart:  coupon.Cart{Lines: []coupon.Line{line("0", "a", 40000)}},
+			item:  40000,
+			lines: []int64{40000},
+		},
+		{
+			name:  "rounding remainder lands on the largest line",
+			kind:  coupon.DiscountFixedAmount,
+			value: 10000,
+			cart:  coupon.Cart{Lines: []coupon.Line{line("0", "a", 20000), line("1", "b", 50000), line("2", "c", 20000)}},
+			item:  10000,
+			lines: []int64{2222, 5556, 2222},
+		},
+		{
+			name:  "equal lines give the remainder to the first",
+			kind:  coupon.DiscountFixedAmount,
+			value: 10000,
+			cart:  coupon.Cart{Lines: []coupon.Line{line("0", "a", 30000), line("1", "b", 30000), line("2", "c", 30000)}},
+			item:  10000,
+			lines: []int64{3334, 3333, 3333},
+		},
+		{
+			name:   "excluded product gets no share",
+			kind:   coupon.DiscountPercentage,
+			value:  10,
+			coupon: func(c *models.Coupon) { c.ExcludedProductIDs = models.UUIDArray{"b"} },
+			cart:   coupon.Cart{Lines: []coupon.Line{line("0", "a", 100000), line("1", "b", 200000)}},
+			item:   10000,
+			lines:  []int64{10000},
+		},
+		{
+			name:  "exclude list wins over the allow list",
+			kind:  coupon.DiscountPercentage,
+			value: 10,
+			coupon: func(c *models.Coupon) {
+				c.ApplicableProductIDs = models.UUIDArray{"a", "b"}
+				c.ExcludedProductIDs = models.UUIDArray{"a"}
+			},
+			cart:  coupon.Cart{Lines: []coupon.Line{line("0", "a", 100000), line("1", "b", 200000)}},
+			item:  20000,
+			lines: []int64{20000},
+		},
+		{
+			name:   "brand restriction",
+			kind:   coupon.DiscountPercentage,
+			value:  10,
+			coupon: func(c *models.Coupon) { c.ApplicableBrandIDs = models.UUIDArray{"brand-b"} },
+			cart:   coupon.Cart{Lines: []coupon.Line{line("0", "a", 100000), line("1", "b", 200000)}},
+			item:   20000,
+			lines:  []int64{20000},
+		},
+		{
+			name:   "min order reached exactly",
+			kind:   coupon.DiscountFixedAmount,
+			value:  5000,
+			coupon: func(c *models.Coupon) { c.MinOrderAmount = rupiahPtr(100000) },
+			cart:   coupon.Cart{Lines: []coupon.Line{line("0", "a", 60000), line("1", "b", 40000)}},
+			item:   5000,
+			lines:  []int64{3000, 2000},
+		},
+		{
+			name:     "free shipping",
+			kind:     coupon.DiscountFreeShipping,
+			cart:     coupon.Cart{Lines: []coupon.Line{line("0", "a", 100000)}, ShippingCost: rupiah(18000)},
+			shipping: 18000,
+			lines:    []int64{0},
+		},
+		{
+			name:     "free shipping capped at max discount",
+			kind:     coupon.DiscountFreeShipping,
+			coupon:   func(c *models.Coupon) { c.MaxDiscountAmount = rupiahPtr(10000) },
+			cart:     coupon.Cart{Lines: []coupon.Line{line("0", "a", 100000)}, ShippingCost: rupiah(18000)},
+			shipping: 10000,
+			lines:    []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := baseCoupon(tt.kind, tt.value)
+			if tt.coupon != nil {
+				tt.coupon(&c)
+			}
+
+			result, err := coupon.Evaluate(c, tt.cart, now)
+			if err != nil {
+				t.Fatalf("Evaluate: %v", err)
+			}
+
+			if !result.ItemDiscount.Equal(rupiah(tt.item)) || !result.ShippingDiscount.Equal(rupiah(tt.shipping)) {
+				t.Fatalf("discount = %s items + %s shipping, want %d + %d", result.ItemDiscount, result.ShippingDiscount, tt.item, tt.shipping)
+			}
+			if !result.TotalDiscount.Equal(rupiah(tt.item + tt.shipping)) {
+				t.Fatalf("total discount = %s, want %d", result.TotalDiscount, tt.item+tt.shipping)
+			}
+
+			if len(result.Lines) != len(tt.lines) {
+				t.Fatalf("got %d discounted lines, want %d", len(result.Lines), len(tt.lines))
+			}
+			allocated := decimal.Zero
+			for i, want := range tt.lines {
+				if !result.Lines[i].Discount.Equal(rupiah(want)) {
+					t.Errorf("line %s discount = %s, want %d", result.Lines[i].LineID, result.Lines[i].Discount, want)
+				}
+				allocated = allocated.Add(result.Lines[i].Discount)
+			}
+			if !allocated.Equal(result.ItemDiscount) {
+				t.Fatalf("lines add up to %s, item discount is %s", allocated, result.ItemDiscount)
+			}
+		})
+	}
+}
+
+func TestEvaluateRejects(t *testing.T) {
+	cart := coupon.Cart{Lines: []coupon.Line{line("0", "a", 100000)}, NewCustomer: true}
+
+	tests := []struct {
+		name   string
+		coupon func(c *models.Coupon)
+		cart   func(cart *coupon.Cart)
+		err    error
+	}{
+		{name: "inactive", coupon: func(c *models.Coupon) { c.IsActive = false }, err: coupon.ErrInactive},
+		{name: "deleted", coupon: func(c *models.Coupon) { c.DeletedAt = &now }, err: coupon.ErrInactive},
+		{name: "not started", coupon: func(c *models.Coupon) { c.StartsAt = now.Add(time.Minute) }, err: coupon.ErrNotStarted},
+		{name: "expires now", coupon: func(c *models.Coupon) { c.ExpiresAt = now }, err: coupon.ErrExpired},
+		{name: "fully redeemed", coupon: func(c *models.Coupon) { c.TotalUsageLimit = intPtr(5); c.UsedCount = 5 }, err: coupon.ErrUsageLimitReached},
+		{name: "used by this customer", cart: func(cart *coupon.Cart) { cart.UserUsages = 1 }, err: coupon.ErrUserLimitReached},
+		{
+			name:   "returning customer",
+			coupon: func(c *models.Coupon) { c.NewCustomersOnly = true },
+			cart:   func(cart *coupon.Cart) { cart.NewCustomer = false },
+			err:    coupon.ErrNewCustomersOnly,
+		},
+		{name: "below min order", coupon: func(c *models.Coupon) { c.MinOrderAmount = rupiahPtr(100001) }, err: coupon.ErrMinOrderNotMet},
+		{name: "every item excluded", coupon: func(c *models.Coupon) { c.ExcludedProductIDs = models.UUIDArray{"a"} }, err: coupon.ErrNoEligibleItems},
+		{name: "no item in the allow list", coupon: func(c *models.Coupon) { c.ApplicableCategoryIDs = models.UUIDArray{"shoes"} }, err: coupon.ErrNoEligibleItems},
+		{name: "unknown discount type", coupon: func(c *models.Coupon) { c.DiscountType = "buy_one_get_one" }, err: coupon.ErrUnknownDiscountType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := baseCoupon(coupon.DiscountPercentage, 10)
+			if tt.coupon != nil {
+				tt.coupon(&c)
+			}
+			cart := cart
+			if tt.cart != nil {
+				tt.cart(&cart)
+			}
+
+			if _, err := coupon.Evaluate(c, cart, now); !errors.Is(err, tt.err) {
+				t.Fatalf("Evaluate error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+const fetchBackoff = 500 * time.Millisecond
+
+// opens a group member on topic, swapped for kafkatest.Broker.NewConsumer in tests
+type ConsumerFactory func(topic string) kafka.Consumer
+
+// a handler that keeps failing is moved down SourceTopic's retry chain instead of holding up its partition,
+// messages that can't be decoded go straight to the dead-letter topic
+type RetryChain struct {
+	SourceTopic string
+	Policy      kafka.RetryPolicy
+	Producer    kafka.Producer // writes to the retry and dead-letter topics
+}
+
+// starts one consumer for the source topic and one per retry topic, each returns once ctx is cancelled
+func (c RetryChain) Run(ctx context.Context, newConsumer ConsumerFactory, handler Handler) {
+	go c.Consume(ctx, newConsumer(c.SourceTopic), handler, 0)
+
+	for level := 1; level <= c.Policy.RetryTopics; level++ {
+		go c.Consume(ctx, newConsumer(kafka.RetryTopic(c.SourceTopic, level)), handler, level)
+	}
+}
+
+// fetch, handle, commit. level is the consumer's position in the retry chain, 0 for the source topic.
+// a message is only committed once it was handled or moved on, so nothing is lost on restart
+func (c RetryChain) Consume(ctx context.Context, consumer kafka.Consumer, handler Handler, level int) {
+	defer consumer.Close()
+
+	for {
+		msg, err := consumer.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Printf("Failed to fetch message: %v", err)
+			if !sleep(ctx, fetchBackoff) {
+				return
+			}
+			continue
+		}
+
+		if level > 0 && !kafka.WaitUntilDue(ctx, msg) {
+			return
+		}
+
+		if !c.handle(ctx, handler, level, msg) {
+			return
+		}
+
+		if err := consumer.Commit(ctx, msg); err != nil {
+			log.Printf("Failed to commit %s[%d]@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+		}
+	}
+}
+
+// returns true once the message is finished, either handled or moved to a retry or dead-letter topic
+func (c RetryChain) handle(ctx context.Context, handler Handler, level int, msg kafka.Message) bool {
+	var err error
+	for attempt := 0; attempt < max(c.Policy.Attempts, 1); attempt++ {
+		if attempt > 0 && !sleep(ctx, c.Policy.AttemptBackoff(attempt-1)) {
+			return false
+		}
+
+		if err = handler.Handle(ctx, msg.Key, msg.Value); err == nil {
+			return true
+		}
+
+		log.Printf("Failed to handle %s[%d]@%d (attempt %d): %v", msg.Topic, msg.Partition, msg.Offset, attempt+1, err)
+		if kafka.IsNonRetryable(err) {
+			break
+		}
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return c.escalate(ctx, level, msg, err)
+}
+
+// keeps trying to publish until it succeeds, committing past a message that went nowhere would lose it
+func (c RetryChain) escalate(ctx context.Context, level int, msg kafka.Message, handleErr error) bool {
+	next := kafka.NextRetryMessage(msg, c.SourceTopic, level, c.Policy, handleErr)
+
+	for attempt := 0; ; attempt++ {
+		err := c.Producer.Publish(ctx, next)
+		if err == nil {
+			break
+		}
+
+		log.Printf("Failed to move %s[%d]@%d to %s (attempt %d): %v", msg.Topic, msg.Partition, msg.Offset, next.Topic, attempt+1, err)
+		if !sleep(ctx, c.Policy.AttemptBackoff(attempt)) {
+			return false
+		}
+	}
+
+	log.Printf("Moved %s[%d]@%d to %s: %v", msg.Topic, msg.Partition, msg.Offset, next.Topic, handleErr)
+	return true
+}
+
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,137 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/events"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka/kafkatest"
+)
+
+const (
+	topic   = "payment_event"
+	groupId = "order-service"
+)
+
+// counts calls and fails every one of them with err when it is set
+type countingHandler struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (h *countingHandler) Handle(ctx context.Context, key []byte, message []byte) error {
+	h.calls.Add(1)
+	if _, err := kafka.DecodeEvent(message); err != nil {
+		return err
+	}
+	return h.err
+}
+
+func startChain(t *testing.T, handler events.Handler) *kafkatest.Broker {
+	t.Helper()
+
+	broker := kafkatest.NewBroker(1)
+	chain := events.RetryChain{
+		SourceTopic: topic,
+		Policy: kafka.RetryPolicy{
+			Attempts:    2,
+			Backoff:     time.Millisecond,
+			MaxBackoff:  time.Millisecond,
+			RetryTopics: 2,
+			TopicDelay:  time.Millisecond,
+		},
+		Producer: broker.NewProducer(""),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	chain.Run(ctx, func(topic string) kafka.Consumer {
+		return broker.NewConsumer(topic, groupId)
+	}, handler)
+
+	return broker
+}
+
+func publishPayment(t *testing.T, broker *kafkatest.Broker) {
+	t.Helper()
+
+	envelope, err := kafka.NewEnvelope(kafka.EventPaymentSucceeded, 1, "order-1", "payment-service", kafka.PaymentSucceeded{OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	if err := broker.NewProducer(topic).PublishEvent(context.Background(), envelope); err != nil {
+		t.Fatalf("PublishEvent: %v", err)
+	}
+}
+
+func waitForCommit(t *testing.T, broker *kafkatest.Broker, topic string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := broker.WaitForCommit(ctx, groupId, topic); err != nil {
+		t.Fatalf("%s was not committed: %v", topic, err)
+	}
+}
+
+func TestRetryChainCommitsHandledPayment(t *testing.T) {
+	handler := &countingHandler{}
+	broker := startChain(t, handler)
+
+	publishPayment(t, broker)
+	waitForCommit(t, broker, topic)
+
+	if calls := handler.calls.Load(); calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestRetryChainMovesFailingPaymentToDeadLetter(t *testing.T) {
+	handler := &countingHandler{err: errors.New("database unavailable")}
+	broker := startChain(t, handler)
+
+	publishPayment(t, broker)
+	for _, subscribed := range []string{topic, kafka.RetryTopic(topic, 1), kafka.RetryTopic(topic, 2)} {
+		waitForCommit(t, broker, subscribed)
+	}
+
+	deadLettered := broker.Messages(kafka.DeadLetterTopic(topic))
+	if len(deadLettered) != 1 {
+		t.Fatalf("dead-letter topic has %d messages, want 1", len(deadLettered))
+	}
+	if headers := deadLettered[0].Headers; headers[kafka.HeaderOriginalTopic] != topic || headers[kafka.HeaderRetryAttempt] != "3" {
+		t.Fatalf("dead-letter headers = %v", headers)
+	}
+
+	//two in process attempts on the source topic and on each retry topic
+	if calls := handler.calls.Load(); calls != 6 {
+		t.Fatalf("handler called %d times, want 6", calls)
+	}
+}
+
+// an undecodable message would fail the same way forever, it must not hold up the payments behind it
+func TestRetryChainDeadLettersUndecodablePaymentAndMovesOn(t *testing.T) {
+	handler := &countingHandler{}
+	broker := startChain(t, handler)
+
+	if err := broker.NewProducer(topic).PublishMessage(context.Background(), []byte("order-1"), []byte("not json")); err != nil {
+		t.Fatalf("PublishMessage: %v", err)
+	}
+	publishPayment(t, broker)
+	waitForCommit(t, broker, topic)
+
+	if messages := broker.Messages(kafka.RetryTopic(topic, 1)); len(messages) != 0 {
+		t.Fatalf("undecodable message was retried: %+v", messages)
+	}
+	if messages := broker.Messages(kafka.DeadLetterTopic(topic)); len(messages) != 1 {
+		t.Fatalf("dead-letter topic has %d messages, want 1", len(messages))
+	}
+	if calls := handler.calls.Load(); calls != 2 {
+		t.Fatalf("handler called %d times, want 2", calls)
+	}
+}
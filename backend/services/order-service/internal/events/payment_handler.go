@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+type PaymentHandler struct {
+	orderService *service.OrderService
+}
+
+func NewPaymentHandler(orderService *service.OrderService) *PaymentHandler {
+	return &PaymentHandler{
+		orderService: orderService,
+	}
+}
+
+func (h *PaymentHandler) Handle(ctx context.Context, key []byte, message []byte) error {
+	envelope, err := kafka.DecodeEvent(message)
+	if err != nil {
+		return err
+	}
+
+	switch envelope.Type {
+	case kafka.EventPaymentSucceeded:
+		var payment kafka.PaymentSucceeded
+		if err := envelope.DecodePayload(&payment); err != nil {
+			return err
+		}
+
+		err := h.orderService.MarkOrderPaid(ctx, payment.OrderID, payment.PaymentID)
+		if errors.Is(err, service.ErrOrderNotFound) {
+			//payments for another service's orders share the topic, nothing to do here
+			log.Printf("payment %s references unknown order %s", payment.PaymentID, payment.OrderID)
+			return nil
+		}
+		return err
+
+	//the payment link expired, the order can't be paid anymore so stock and coupons are given back
+	case kafka.EventPaymentExpired:
+		var payment kafka.PaymentExpired
+		if err := envelope.DecodePayload(&payment); err != nil {
+			return err
+		}
+
+		err := h.orderService.CancelUnpaidOrder(ctx, payment.OrderID, "payment "+payment.PaymentID+" expired")
+		if errors.Is(err, service.ErrOrderNotFound) {
+			log.Printf("expired payment %s references unknown order %s", payment.PaymentID, payment.OrderID)
+			return nil
+		}
+		return err
+
+	default:
+		return nil
+	}
+}
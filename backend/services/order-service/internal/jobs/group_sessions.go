@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+)
+
+type GroupSessionsConfig struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// settles group sessions: fills or expires them, then confirms or cancels the orders they held.
+// safe to run on every replica since each batch is claimed with SKIP LOCKED
+type GroupSessions struct {
+	orderService *service.OrderService
+	config       GroupSessionsConfig
+}
+
+func NewGroupSessions(orderService *service.OrderService, config GroupSessionsConfig) *GroupSessions {
+	return &GroupSessions{
+		orderService: orderService,
+		config:       config,
+	}
+}
+
+// blocks until ctx is cancelled
+func (j *GroupSessions) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.sweep(ctx)
+		}
+	}
+}
+
+// filling runs before expiring so a session that reached its minimum in time is never expired
+func (j *GroupSessions) sweep(ctx context.Context) {
+	now := time.Now()
+	j.drain(ctx, "filled sessions", func() (int, error) {
+		return j.orderService.FillGroupSessions(ctx, now, j.config.BatchSize)
+	})
+	j.drain(ctx, "expired sessions", func() (int, error) {
+		return j.orderService.ExpireGroupSessions(ctx, now, j.config.BatchSize)
+	})
+	j.drain(ctx, "confirmed orders", func() (int, error) {
+		return j.orderService.ConfirmFilledGroupOrders(ctx, j.config.BatchSize)
+	})
+	j.drain(ctx, "cancelled orders", func() (int, error) {
+		return j.orderService.CancelExpiredGroupOrders(ctx, j.config.BatchSize)
+	})
+}
+
+func (j *GroupSessions) drain(ctx context.Context, what string, batch func() (int, error)) {
+	total := 0
+	for ctx.Err() == nil {
+		processed, err := batch()
+		if err != nil {
+			log.Printf("group sessions: %v", err)
+			break
+		}
+
+		total += processed
+		if processed < j.config.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("group sessions: %d %s", total, what)
+	}
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+)
+
+type OrderCompletionConfig struct {
+	Interval  time.Duration
+	Window    time.Duration // how long after delivery an order completes without the customer confirming
+	BatchSize int
+}
+
+// completes delivered orders once the window passes, returns stay open until their own window from delivery closes.
+// safe to run on every replica since each batch is claimed with SKIP LOCKED
+type OrderCompletion struct {
+	orderService *service.OrderService
+	config       OrderCompletionConfig
+}
+
+func NewOrderCompletion(orderService *service.OrderService, config OrderCompletionConfig) *OrderCompletion {
+	return &OrderCompletion{
+		orderService: orderService,
+		config:       config,
+	}
+}
+
+// blocks until ctx is cancelled
+func (j *OrderCompletion) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.sweep(ctx)
+		}
+	}
+}
+
+func (j *OrderCompletion) sweep(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		completed, err := j.orderService.CompleteDeliveredOrders(ctx, time.Now(), j.config.Window, j.config.BatchSize)
+		if err != nil {
+			log.Printf("order completion: %v", err)
+			break
+		}
+
+		total += completed
+		if completed < j.config.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("order completion: completed %d delivered orders", total)
+	}
+}
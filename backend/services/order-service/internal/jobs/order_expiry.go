@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+)
+
+type OrderExpiryConfig struct {
+	Interval  time.Duration
+	BatchSize int
+	DryRun    bool // logs the orders that are past their deadline without cancelling them
+}
+
+// cancels orders that stayed unpaid past the payment window, so their stock is released.
+// safe to run on every replica since each batch is claimed with SKIP LOCKED
+type OrderExpiry struct {
+	orderService *service.OrderService
+	config       OrderExpiryConfig
+}
+
+func NewOrderExpiry(orderService *service.OrderService, config OrderExpiryConfig) *OrderExpiry {
+	return &OrderExpiry{
+		orderService: orderService,
+		config:       config,
+	}
+}
+
+// blocks until ctx is cancelled
+func (j *OrderExpiry) Run(ctx context.Context) {
+	if j.config.DryRun {
+		log.Println("order expiry: running in dry run mode, no order will be cancelled")
+	}
+
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.sweep(ctx)
+		}
+	}
+}
+
+// keeps cancelling full batches so a backlog is cleared within one tick
+func (j *OrderExpiry) sweep(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		expired, err := j.orderService.ExpireOverdueOrders(ctx, time.Now(), j.config.BatchSize, j.config.DryRun)
+		if err != nil {
+			log.Printf("order expiry: %v", err)
+			break
+		}
+
+		total += expired
+		//a dry run never changes the rows, the next batch would be the same one
+		if expired < j.config.BatchSize || j.config.DryRun {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("order expiry: %d unpaid orders past the payment window (dry run: %t)", total, j.config.DryRun)
+	}
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+)
+
+type StockReservationsConfig struct {
+	Interval  time.Duration
+	Lease     time.Duration // how long a sweep may take to settle its batch before another one takes the items over
+	BatchSize int
+}
+
+// confirms warehouse reservations of paid orders and releases those of cancelled or refunded ones.
+// safe to run on every replica since each batch is claimed with SKIP LOCKED and stamped before the warehouse is called
+type StockReservations struct {
+	orderService *service.OrderService
+	config       StockReservationsConfig
+}
+
+func NewStockReservations(orderService *service.OrderService, config StockReservationsConfig) *StockReservations {
+	return &StockReservations{
+		orderService: orderService,
+		config:       config,
+	}
+}
+
+// blocks until ctx is cancelled
+func (j *StockReservations) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.sweep(ctx)
+		}
+	}
+}
+
+func (j *StockReservations) sweep(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		settled, err := j.orderService.SettleStockReservations(ctx, time.Now(), j.config.Lease, j.config.BatchSize)
+		if err != nil {
+			log.Printf("stock reservations: %v", err)
+			break
+		}
+
+		total += settled
+		if settled < j.config.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("stock reservations: settled %d reservations", total)
+	}
+}
@@ -0,0 +1,92 @@
+package pricing
+
+import (
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/shopspring/decimal"
+)
+
+// names of the steps as they appear in an order's breakdown
+const (
+	StepSubtotal         = "subtotal"
+	StepItemDiscount     = "item_discount"
+	StepCoupon           = "coupon"
+	StepShipping         = "shipping"
+	StepShippingDiscount = "shipping_discount"
+	StepTax              = "tax"
+	StepTotal            = "total"
+)
+
+// what an order is priced from, every amount in whole rupiah
+type Input struct {
+	Subtotal               decimal.Decimal //sum of the line subtotals
+	ItemDiscount           decimal.Decimal //line level promotions, coupons not included
+	CouponCode             string
+	CouponItemDiscount     decimal.Decimal
+	CouponShippingDiscount decimal.Decimal
+	ShippingCost           decimal.Decimal
+	ShippingService        string //courier and service the cost was quoted for
+}
+
+// the amounts an order stores, DiscountAmount covers item, coupon and shipping discounts together
+type Result struct {
+	ShippingCost   decimal.Decimal
+	DiscountAmount decimal.Decimal
+	TaxAmount      decimal.Decimal
+	TotalAmount    decimal.Decimal
+	Breakdown      models.PriceBreakdown
+}
+
+// the state steps read and write, Total is the running total
+type Quote struct {
+	Input
+	Discount  decimal.Decimal
+	TaxBase   decimal.Decimal //what PPN is charged on
+	Tax       decimal.Decimal
+	Total     decimal.Decimal
+	Breakdown models.PriceBreakdown
+}
+
+// moves the running total by amount and records it
+func (q *Quote) Record(step string, description string, amount decimal.Decimal) {
+	q.Total = q.Total.Add(amount)
+	q.Breakdown = append(q.Breakdown, models.PriceStep{Step: step, Description: description, Amount: amount, Total: q.Total})
+}
+
+type Step interface {
+	Apply(q *Quote)
+}
+
+// runs its steps in order, swapping or adding a step doesn't touch the callers
+type Pipeline struct {
+	steps []Step
+}
+
+func NewPipeline(steps ...Step) *Pipeline {
+	return &Pipeline{steps: steps}
+}
+
+// subtotal, item discounts, coupon, shipping, PPN, total
+func DefaultPipeline(tax PPN) *Pipeline {
+	return NewPipeline(Subtotal{}, ItemDiscount{}, Coupon{}, Shipping{}, tax, Total{})
+}
+
+func (p *Pipeline) Price(input Input) Result {
+	q := &Quote{
+		Input:    input,
+		Discount: decimal.Zero,
+		TaxBase:  decimal.Zero,
+		Tax:      decimal.Zero,
+		Total:    decimal.Zero,
+	}
+	for _, step := range p.steps {
+		step.Apply(q)
+	}
+
+	return Result{
+		ShippingCost:   q.ShippingCost,
+		DiscountAmount: q.Discount,
+		TaxAmount:      q.Tax,
+		TotalAmount:    q.Total,
+		Breakdown:      q.Breakdown,
+	}
+}
@@ -0,0 +1,127 @@
+package pricing_test
+
+import (
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/pricing"
+	"github.com/shopspring/decimal"
+)
+
+func rupiah(amount int64) decimal.Decimal {
+	return decimal.NewFromInt(amount)
+}
+
+func TestDefaultPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    pricing.Input
+		discount int64
+		tax      int64
+		total    int64
+		steps    []string
+	}{
+		{
+			name:  "PPN 12% on 11/12 of the goods, shipping outside the base",
+			input: pricing.Input{Subtotal: rupiah(150000), ShippingCost: rupiah(18000)},
+			tax:   16500,
+			total: 184500,
+			steps: []string{pricing.StepSubtotal, pricing.StepShipping, pricing.StepTax, pricing.StepTotal},
+		},
+		{
+			name:  "tax base and tax each rounded down",
+			input: pricing.Input{Subtotal: rupiah(100001)},
+			//100001 * 11/12 = 91667.58, 12% of 91667 = 11000.04
+			tax:   11000,
+			total: 111001,
+			steps: []string{pricing.StepSubtotal, pricing.StepShipping, pricing.StepTax, pricing.StepTotal},
+		},
+		{
+			name: "discounts come off before PPN",
+			input: pricing.Input{
+				Subtotal:           rupiah(200000),
+				ItemDiscount:       rupiah(20000),
+				CouponCode:         "HEMAT",
+				CouponItemDiscount: rupiah(30000),
+				ShippingCost:       rupiah(20000),
+			},
+			discount: 50000,
+			tax:      16500,
+			total:    186500,
+			steps:    []string{pricing.StepSubtotal, pricing.StepItemDiscount, pricing.StepCoupon, pricing.StepShipping, pricing.StepTax, pricing.StepTotal},
+		},
+		{
+			name: "shipping discount capped at the shipping cost",
+			input: pricing.Input{
+				Subtotal:               rupiah(120000),
+				CouponCode:             "ONGKIR",
+				CouponShippingDiscount: rupiah(25000),
+				ShippingCost:           rupiah(18000),
+			},
+			discount: 18000,
+			tax:      13200,
+			total:    133200,
+			steps:    []string{pricing.StepSubtotal, pricing.StepShipping, pricing.StepShippingDiscount, pricing.StepTax, pricing.StepTotal},
+		},
+		{
+			name:     "no PPN once discounts cover the goods",
+			input:    pricing.Input{Subtotal: rupiah(50000), ItemDiscount: rupiah(20000), CouponCode: "HEMAT", CouponItemDiscount: rupiah(30000), ShippingCost: rupiah(9000)},
+			discount: 50000,
+			tax:      0,
+			total:    9000,
+			steps:    []string{pricing.StepSubtotal, pricing.StepItemDiscount, pricing.StepCoupon, pricing.StepShipping, pricing.StepTax, pricing.StepTotal},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := pricing.DefaultPipeline(pricing.DefaultPPN()).Price(tt.input)
+
+			if !result.DiscountAmount.Equal(rupiah(tt.discount)) {
+				t.Errorf("discount = %s, want %d", result.DiscountAmount, tt.discount)
+			}
+			if !result.TaxAmount.Equal(rupiah(tt.tax)) {
+				t.Errorf("tax = %s, want %d", result.TaxAmount, tt.tax)
+			}
+			if !result.TotalAmount.Equal(rupiah(tt.total)) {
+				t.Errorf("total = %s, want %d", result.TotalAmount, tt.total)
+			}
+
+			if len(result.Breakdown) != len(tt.steps) {
+				t.Fatalf("breakdown = %+v, want steps %v", result.Breakdown, tt.steps)
+			}
+			for i, step := range tt.steps {
+				if result.Breakdown[i].Step != step {
+					t.Fatalf("step %d = %s, want %s", i, result.Breakdown[i].Step, step)
+				}
+			}
+			//the breakdown ends on what the order stores as its total
+			if last := result.Breakdown[len(result.Breakdown)-1]; !last.Total.Equal(result.TotalAmount) {
+				t.Fatalf("breakdown ends on %s, total is %s", last.Total, result.TotalAmount)
+			}
+		})
+	}
+}
+
+func TestPPNRate(t *testing.T) {
+	tests := []struct {
+		name string
+		ppn  pricing.PPN
+		base int64
+		tax  int64
+	}{
+		{name: "default", ppn: pricing.DefaultPPN(), base: 91665, tax: 10999},
+		{name: "full base for luxury goods", ppn: pricing.PPN{RatePercent: 12, BaseNumerator: 1, BaseDenominator: 1}, base: 99999, tax: 11999},
+		{name: "the old 11% rate", ppn: pricing.PPN{RatePercent: 11, BaseNumerator: 1, BaseDenominator: 1}, base: 99999, tax: 10999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &pricing.Quote{Input: pricing.Input{Subtotal: rupiah(99999)}}
+			tt.ppn.Apply(q)
+
+			if !q.TaxBase.Equal(rupiah(tt.base)) || !q.Tax.Equal(rupiah(tt.tax)) {
+				t.Fatalf("PPN = %s on %s, want %d on %d", q.Tax, q.TaxBase, tt.tax, tt.base)
+			}
+		})
+	}
+}
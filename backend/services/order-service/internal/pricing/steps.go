@@ -0,0 +1,79 @@
+package pricing
+
+import (
+	"fmt"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/shopspring/decimal"
+)
+
+type Subtotal struct{}
+
+func (Subtotal) Apply(q *Quote) {
+	q.Record(StepSubtotal, "items", q.Subtotal)
+}
+
+type ItemDiscount struct{}
+
+func (ItemDiscount) Apply(q *Quote) {
+	if q.ItemDiscount.IsZero() {
+		return
+	}
+	q.Discount = q.Discount.Add(q.ItemDiscount)
+	q.Record(StepItemDiscount, "item promotions", q.ItemDiscount.Neg())
+}
+
+// the part of a coupon that comes off the items, a free shipping coupon is applied with the shipping
+type Coupon struct{}
+
+func (Coupon) Apply(q *Quote) {
+	if q.CouponCode == "" || q.CouponItemDiscount.IsZero() {
+		return
+	}
+	q.Discount = q.Discount.Add(q.CouponItemDiscount)
+	q.Record(StepCoupon, "coupon "+q.CouponCode, q.CouponItemDiscount.Neg())
+}
+
+// a shipping discount never exceeds the shipping cost
+type Shipping struct{}
+
+func (Shipping) Apply(q *Quote) {
+	q.Record(StepShipping, q.ShippingService, q.ShippingCost)
+
+	discount := decimal.Min(q.CouponShippingDiscount, q.ShippingCost)
+	if discount.IsPositive() {
+		q.Discount = q.Discount.Add(discount)
+		q.Record(StepShippingDiscount, "coupon "+q.CouponCode, discount.Neg())
+	}
+}
+
+// PPN on the goods after discounts, prices are quoted without it. since 2025 the 12% rate is charged on a tax
+// base (DPP nilai lain) of 11/12 of the price for everything but luxury goods, 11% in effect. shipping is billed
+// by the courier with its own PPN and isn't part of the base. rounded down to whole rupiah
+type PPN struct {
+	RatePercent     int64
+	BaseNumerator   int64
+	BaseDenominator int64
+}
+
+func DefaultPPN() PPN {
+	return PPN{RatePercent: 12, BaseNumerator: 11, BaseDenominator: 12}
+}
+
+func (t PPN) Apply(q *Quote) {
+	base := q.Subtotal.Sub(q.ItemDiscount).Sub(q.CouponItemDiscount)
+	if base.IsNegative() {
+		base = decimal.Zero
+	}
+
+	q.TaxBase = base.Mul(decimal.NewFromInt(t.BaseNumerator)).Div(decimal.NewFromInt(t.BaseDenominator)).Floor()
+	q.Tax = q.TaxBase.Mul(decimal.NewFromInt(t.RatePercent)).Div(decimal.NewFromInt(100)).Floor()
+	q.Record(StepTax, fmt.Sprintf("PPN %d%% of %s", t.RatePercent, q.TaxBase.String()), q.Tax)
+}
+
+// records the total so the breakdown ends with what the customer pays
+type Total struct{}
+
+func (Total) Apply(q *Quote) {
+	q.Breakdown = append(q.Breakdown, models.PriceStep{Step: StepTotal, Description: "amount due", Amount: q.Total, Total: q.Total})
+}
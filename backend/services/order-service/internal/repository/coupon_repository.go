@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CouponFilter struct {
+	Search   string
+	IsActive *bool
+	Offset   int
+	Limit    int
+}
+
+func (r *OrderRepository) GetCoupons(ctx context.Context, filter CouponFilter) ([]models.Coupon, int64, error) {
+	var coupons []models.Coupon
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Coupon{}).Where(`"deletedAt" IS NULL`)
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where("(code ILIKE ? OR name ILIKE ?)", pattern, pattern)
+	}
+	if filter.IsActive != nil {
+		query = query.Where(`"isActive" = ?`, *filter.IsActive)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	results := query.
+		Order(`"createdAt" DESC`).
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&coupons)
+	return coupons, total, results.Error
+}
+
+func (r *OrderRepository) GetCouponByID(ctx context.Context, couponId string) (models.Coupon, error) {
+	var coupon models.Coupon
+
+	results := r.db.WithContext(ctx).
+		Where(`id = ? AND "deletedAt" IS NULL`, couponId).
+		First(&coupon)
+	return coupon, results.Error
+}
+
+func (r *OrderRepository) GetCouponByCode(ctx context.Context, code string) (models.Coupon, error) {
+	var coupon models.Coupon
+
+	results := r.db.WithContext(ctx).
+		Where(`code = ? AND "deletedAt" IS NULL`, code).
+		First(&coupon)
+	return coupon, results.Error
+}
+
+// the lock serialises redemptions of one coupon, usage limits are checked against counts nobody else can move
+func (r *OrderRepository) GetCouponByCodeForUpdate(ctx context.Context, code string) (models.Coupon, error) {
+	var coupon models.Coupon
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(`code = ? AND "deletedAt" IS NULL`, code).
+		First(&coupon)
+	return coupon, results.Error
+}
+
+func (r *OrderRepository) CreateCoupon(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).Create(coupon).Error
+}
+
+// usedCount is owned by redemption and never overwritten from here
+func (r *OrderRepository) UpdateCoupon(ctx context.Context, coupon *models.Coupon) error {
+	return r.db.WithContext(ctx).
+		Model(coupon).
+		Select("*").
+		Omit("id", "usedCount", "createdAt", "createdBy", "deletedAt").
+		Updates(coupon).Error
+}
+
+func (r *OrderRepository) DeleteCoupon(ctx context.Context, couponId string) (bool, error) {
+	results := r.db.WithContext(ctx).
+		Model(&models.Coupon{}).
+		Where(`id = ? AND "deletedAt" IS NULL`, couponId).
+		Updates(map[string]any{"deletedAt": time.Now(), "isActive": false})
+	return results.RowsAffected == 1, results.Error
+}
+
+func (r *OrderRepository) CountCouponUsages(ctx context.Context, couponId string, userId string) (int64, error) {
+	var count int64
+
+	results := r.db.WithContext(ctx).
+		Model(&models.CouponUsage{}).
+		Where(`"couponId" = ? AND "userId" = ?`, couponId, userId).
+		Count(&count)
+	return count, results.Error
+}
+
+// orders that were not cancelled, a customer with none of them still counts as new
+func (r *OrderRepository) CountUserOrders(ctx context.Context, userId string) (int64, error) {
+	var count int64
+
+	results := r.db.WithContext(ctx).
+		Model(&models.Order{}).
+		Where(`"userId" = ? AND status <> ?`, userId, "cancelled").
+		Count(&count)
+	return count, results.Error
+}
+
+// the guard keeps usedCount within totalUsageLimit even without the row lock, false means the limit was hit
+func (r *OrderRepository) IncrementCouponUsage(ctx context.Context, couponId string) (bool, error) {
+	results := r.db.WithContext(ctx).
+		Model(&models.Coupon{}).
+		Where(`id = ? AND ("totalUsageLimit" IS NULL OR "usedCount" < "totalUsageLimit")`, couponId).
+		Update("usedCount", gorm.Expr(`"usedCount" + 1`))
+	return results.RowsAffected == 1, results.Error
+}
+
+func (r *OrderRepository) CreateCouponUsage(ctx context.Context, usage *models.CouponUsage) error {
+	return r.db.WithContext(ctx).Create(usage).Error
+}
+
+// gives a cancelled order's redemption back, returns false when the order never redeemed one
+func (r *OrderRepository) ReleaseCouponUsage(ctx context.Context, orderId string) (bool, error) {
+	return r.releaseCouponUsage(ctx, r.db.WithContext(ctx).Where(`"orderId" = ?`, orderId))
+}
+
+// a split checkout redeems once, the redemption goes back when the last of its orders is cancelled.
+// the usage row is locked before counting, so of two orders cancelled at the same time the later one sees the
+// earlier committed and releases
+func (r *OrderRepository) ReleaseCheckoutCouponUsage(ctx context.Context, checkoutId string) (bool, error) {
+	checkoutOrders := r.db.Model(&models.Order{}).Select("id").Where(`"checkoutId" = ?`, checkoutId)
+
+	var usages []models.CouponUsage
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(`"orderId" IN (?)`, checkoutOrders).
+		Find(&usages).Error; err != nil || len(usages) == 0 {
+		return false, err
+	}
+
+	var open int64
+	if err := r.db.WithContext(ctx).
+		Model(&models.Order{}).
+		Where(`"checkoutId" = ? AND status <> ?`, checkoutId, "cancelled").
+		Count(&open).Error; err != nil || open > 0 {
+		return false, err
+	}
+
+	return r.releaseCouponUsage(ctx, r.db.WithContext(ctx).Where(`"orderId" IN (?)`, checkoutOrders))
+}
+
+func (r *OrderRepository) releaseCouponUsage(ctx context.Context, query *gorm.DB) (bool, error) {
+	var usage models.CouponUsage
+	results := query.
+		Clauses(clause.Returning{}).
+		Delete(&usage)
+	if results.Error != nil || results.RowsAffected == 0 {
+		return false, results.Error
+	}
+
+	return true, r.db.WithContext(ctx).
+		Model(&models.Coupon{}).
+		Where(`id = ? AND "usedCount" > 0`, usage.CouponID).
+		Update("usedCount", gorm.Expr(`"usedCount" - 1`)).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *OrderRepository) CreateGroupSession(ctx context.Context, session *models.GroupSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *OrderRepository) GetGroupSessionByID(ctx context.Context, sessionId string) (models.GroupSession, error) {
+	var session models.GroupSession
+
+	results := r.db.WithContext(ctx).
+		Where("id = ?", sessionId).
+		First(&session)
+	return session, results.Error
+}
+
+// joins of one session queue up behind the lock, so MaxQuantity holds under concurrent checkouts
+func (r *OrderRepository) GetGroupSessionByIDForUpdate(ctx context.Context, sessionId string) (models.GroupSession, error) {
+	var session models.GroupSession
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", sessionId).
+		First(&session)
+	return session, results.Error
+}
+
+// relative update, callers holding an order lock never have to lock the session row first
+func (r *OrderRepository) AddGroupSessionQuantity(ctx context.Context, sessionId string, joined int, paid int) error {
+	return r.db.WithContext(ctx).
+		Model(&models.GroupSession{}).
+		Where("id = ?", sessionId).
+		Updates(map[string]any{
+			"joinedQuantity": gorm.Expr(`"joinedQuantity" + ?`, joined),
+			"paidQuantity":   gorm.Expr(`"paidQuantity" + ?`, paid),
+			"updatedAt":      time.Now(),
+		}).Error
+}
+
+// open sessions whose paid quantity reached the minimum, locked with SKIP LOCKED like the order jobs
+func (r *OrderRepository) GetFillableGroupSessions(ctx context.Context, limit int) ([]models.GroupSession, error) {
+	var sessions []models.GroupSession
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where(`status = ? AND "paidQuantity" >= "minQuantity"`, "open").
+		Order(`"createdAt"`).
+		Limit(limit).
+		Find(&sessions)
+	return sessions, results.Error
+}
+
+// open sessions past their deadline that never reached the minimum
+func (r *OrderRepository) GetExpiredGroupSessions(ctx context.Context, now time.Time, limit int) ([]models.GroupSession, error) {
+	var sessions []models.GroupSession
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where(`status = ? AND "expiresAt" <= ? AND "paidQuantity" < "minQuantity"`, "open", now).
+		Order(`"expiresAt"`).
+		Limit(limit).
+		Find(&sessions)
+	return sessions, results.Error
+}
+
+func (r *OrderRepository) UpdateGroupSessionStatus(ctx context.Context, session *models.GroupSession) error {
+	return r.db.WithContext(ctx).
+		Model(session).
+		Select("status", "filledAt", "expiredAt", "updatedAt").
+		Updates(session).Error
+}
+
+// orders in one of statuses that belong to a session in sessionStatus, only the order rows are locked
+func (r *OrderRepository) GetGroupOrders(ctx context.Context, sessionStatus string, statuses []string, limit int) ([]models.Order, error) {
+	var orders []models.Order
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "order"}, Options: "SKIP LOCKED"}).
+		Joins(`JOIN group_session ON group_session.id = "order"."groupSessionId"`).
+		Where("group_session.status = ?", sessionStatus).
+		Where(`"order".status IN ?`, statuses).
+		Order(`"order"."createdAt"`).
+		Limit(limit).
+		Find(&orders)
+	return orders, results.Error
+}
@@ -1,8 +1,14 @@
 package repository
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/outbox"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository struct {
@@ -16,20 +22,299 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-func (r *OrderRepository) GetOrders(jsonPayload map[string]interface{}) ([]models.Order, error) {
+type OrderFilter struct {
+	UserID        string
+	FactoryID     string
+	Statuses      []string
+	IsGroupBuying *bool
+	Search        string
+	From          *time.Time
+	To            *time.Time //exclusive
+	SortColumn    string     //must be a column of order, the service whitelists it
+	SortDesc      bool
+	Offset        int
+	Limit         int
+}
+
+// returns one page of orders together with the number of orders matching the filter
+func (r *OrderRepository) GetOrders(ctx context.Context, filter OrderFilter) ([]models.Order, int64, error) {
 	var orders []models.Order
+	var total int64
 
-	results := r.db.Model(&models.Order{}).
+	query := r.filterOrders(r.db.WithContext(ctx).Model(&models.Order{}), filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return orders, 0, nil
+	}
+
+	//id breaks ties so pages don't overlap when the sort column repeats
+	results := r.filterOrders(r.db.WithContext(ctx).Model(&models.Order{}), filter).
 		Joins("User").
-		Joins("LEFT JOIN order_items ON orders.id = order_items.order_id").
-		Joins("LEFT JOIN factories ON order_items.factory_id = factories.id").
-		Joins("LEFT JOIN products ON order_items.product_id = products.id").
-		Where(jsonPayload).
-		Distinct("orders.*").
+		Preload("OrderItems").
+		Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+			{Column: clause.Column{Table: "order", Name: filter.SortColumn}, Desc: filter.SortDesc},
+			{Column: clause.Column{Table: "order", Name: "id"}, Desc: filter.SortDesc},
+		}}).
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&orders)
+	return orders, total, results.Error
+}
+
+// position of the last order on the previous page
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// keyset page sorted by createdAt then id, cost stays flat however deep the caller pages since nothing is counted or skipped
+func (r *OrderRepository) GetOrdersAfter(ctx context.Context, filter OrderFilter, after *OrderCursor) ([]models.Order, error) {
+	var orders []models.Order
+
+	query := r.filterOrders(r.db.WithContext(ctx).Model(&models.Order{}), filter)
+	if after != nil {
+		comparison := ">"
+		if filter.SortDesc {
+			comparison = "<"
+		}
+		query = query.Where(`("order"."createdAt", "order".id) `+comparison+" (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	results := query.
+		Joins("User").
+		Preload("OrderItems").
+		Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+			{Column: clause.Column{Table: "order", Name: "createdAt"}, Desc: filter.SortDesc},
+			{Column: clause.Column{Table: "order", Name: "id"}, Desc: filter.SortDesc},
+		}}).
+		Limit(filter.Limit).
 		Find(&orders)
 	return orders, results.Error
 }
 
-func (r *OrderRepository) CreateOrder(jsonPayload map[string]interface{}) error {
-	return nil
+func (r *OrderRepository) filterOrders(query *gorm.DB, filter OrderFilter) *gorm.DB {
+	if filter.UserID != "" {
+		query = query.Where(`"order"."userId" = ?`, filter.UserID)
+	}
+
+	//exists keeps one row per order, a join would repeat orders with several items from the factory
+	if filter.FactoryID != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM order_item WHERE order_item."orderId" = "order".id AND order_item."factoryId" = ?)`, filter.FactoryID)
+	}
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where(`"order".status IN ?`, filter.Statuses)
+	}
+
+	if filter.IsGroupBuying != nil {
+		if *filter.IsGroupBuying {
+			query = query.Where(`"order"."groupSessionId" IS NOT NULL`)
+		} else {
+			query = query.Where(`"order"."groupSessionId" IS NULL`)
+		}
+	}
+
+	if filter.From != nil {
+		query = query.Where(`"order"."createdAt" >= ?`, *filter.From)
+	}
+
+	if filter.To != nil {
+		query = query.Where(`"order"."createdAt" < ?`, *filter.To)
+	}
+
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where(`("order"."orderNumber" ILIKE ? OR "order"."shippingRecipient" ILIKE ? OR "order"."shippingPhone" ILIKE ?)`, pattern, pattern, pattern)
+	}
+
+	return query
+}
+
+// user input is matched literally, % and _ would otherwise act as wildcards
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// with the factory each product ships from, checkout snapshots its name and city
+func (r *OrderRepository) GetProductsByIDs(ctx context.Context, productIds []string) ([]models.Product, error) {
+	var products []models.Product
+
+	results := r.db.WithContext(ctx).
+		Preload("Factory").
+		Where("id IN ?", productIds).
+		Find(&products)
+	return products, results.Error
+}
+
+// order and its items are written in one transaction, either both exist or neither does
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(order).Error; err != nil {
+			return err
+		}
+
+		for i := range order.OrderItems {
+			order.OrderItems[i].OrderID = order.ID
+		}
+
+		if err := tx.Omit(clause.Associations).Create(&order.OrderItems).Error; err != nil {
+			return err
+		}
+
+		//the first history row has no fromStatus, it records where the order started
+		return tx.Create(&models.OrderStatusHistory{
+			OrderID:  order.ID,
+			ToStatus: order.Status,
+		}).Error
+	})
+}
+
+// runs fn inside a transaction, the repository handed to fn is bound to that transaction
+func (r *OrderRepository) Transaction(ctx context.Context, fn func(txRepository *OrderRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&OrderRepository{db: tx})
+	})
+}
+
+func (r *OrderRepository) GetOrderByID(ctx context.Context, orderId string) (models.Order, error) {
+	var order models.Order
+
+	results := r.db.WithContext(ctx).
+		Where("id = ?", orderId).
+		First(&order)
+	return order, results.Error
+}
+
+// order with its user and items for the detail view
+func (r *OrderRepository) GetOrderDetailByID(ctx context.Context, orderId string) (models.Order, error) {
+	return r.getOrderDetail(ctx, "id", orderId)
+}
+
+func (r *OrderRepository) GetOrderDetailByNumber(ctx context.Context, orderNumber string) (models.Order, error) {
+	return r.getOrderDetail(ctx, "orderNumber", orderNumber)
+}
+
+func (r *OrderRepository) getOrderDetail(ctx context.Context, column string, value string) (models.Order, error) {
+	var order models.Order
+
+	results := r.db.WithContext(ctx).
+		Joins("User").
+		Preload("OrderItems", func(db *gorm.DB) *gorm.DB {
+			return db.Order(`"createdAt", id`)
+		}).
+		Where(clause.Eq{Column: clause.Column{Table: "order", Name: column}, Value: value}).
+		First(&order)
+	return order, results.Error
+}
+
+// oldest first, the first row records the status the order was created in
+func (r *OrderRepository) GetStatusHistory(ctx context.Context, orderId string) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+
+	results := r.db.WithContext(ctx).
+		Where(`"orderId" = ?`, orderId).
+		Order(`"createdAt", id`).
+		Find(&history)
+	return history, results.Error
+}
+
+// every order of a split checkout, in the order they were created
+func (r *OrderRepository) GetOrdersByCheckoutID(ctx context.Context, checkoutId string) ([]models.Order, error) {
+	var orders []models.Order
+
+	results := r.db.WithContext(ctx).
+		Preload("OrderItems").
+		Where(`"checkoutId" = ?`, checkoutId).
+		Order(`"createdAt", "orderNumber"`).
+		Find(&orders)
+	return orders, results.Error
+}
+
+func (r *OrderRepository) GetOrderByIdempotencyKey(ctx context.Context, idempotencyKey string) (models.Order, error) {
+	var order models.Order
+
+	results := r.db.WithContext(ctx).
+		Preload("OrderItems").
+		Where(`"idempotencyKey" = ?`, idempotencyKey).
+		First(&order)
+	return order, results.Error
+}
+
+// row is locked until the surrounding transaction ends, so concurrent status changes queue up
+func (r *OrderRepository) GetOrderByIDForUpdate(ctx context.Context, orderId string) (models.Order, error) {
+	var order models.Order
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", orderId).
+		First(&order)
+	return order, results.Error
+}
+
+// unpaid orders created before cutoff, oldest first. with lock set the rows are locked and rows another replica
+// already holds are skipped, so concurrent sweeps split the work instead of waiting on each other
+func (r *OrderRepository) GetUnpaidOrdersCreatedBefore(ctx context.Context, statuses []string, cutoff time.Time, limit int, lock bool) ([]models.Order, error) {
+	var orders []models.Order
+
+	query := r.db.WithContext(ctx)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+
+	results := query.
+		Where("status IN ?", statuses).
+		Where(`"createdAt" < ?`, cutoff).
+		Order(`"createdAt"`).
+		Limit(limit).
+		Find(&orders)
+	return orders, results.Error
+}
+
+// delivered orders whose delivery is older than cutoff, locked with SKIP LOCKED like GetUnpaidOrdersCreatedBefore
+func (r *OrderRepository) GetOrdersDeliveredBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Order, error) {
+	var orders []models.Order
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ?", "delivered").
+		Where(`"deliveredAt" < ?`, cutoff).
+		Order(`"deliveredAt"`).
+		Limit(limit).
+		Find(&orders)
+	return orders, results.Error
+}
+
+func (r *OrderRepository) UpdateOrderStatus(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).
+		Model(order).
+		Select("status", "paidAt", "shippedAt", "deliveredAt", "completedAt", "cancelledAt", "cancelReason", "cancelledBy", "updatedAt").
+		Updates(order).Error
+}
+
+func (r *OrderRepository) UpdateOrderPricing(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).
+		Model(order).
+		Select("shippingCost", "shippingCourier", "shippingMethod", "discountAmount", "taxAmount", "totalAmount", "priceBreakdown", "updatedAt").
+		Updates(order).Error
+}
+
+func (r *OrderRepository) GetOrderItems(ctx context.Context, orderId string) ([]models.OrderItem, error) {
+	var orderItems []models.OrderItem
+
+	results := r.db.WithContext(ctx).
+		Where(`"orderId" = ?`, orderId).
+		Find(&orderItems)
+	return orderItems, results.Error
+}
+
+func (r *OrderRepository) CreateStatusHistory(ctx context.Context, history *models.OrderStatusHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+// only meaningful on a repository returned by Transaction, the event must commit together with the change it describes
+func (r *OrderRepository) CreateOutboxEvent(ctx context.Context, event outbox.Event) error {
+	return outbox.Write(r.db.WithContext(ctx), event)
 }
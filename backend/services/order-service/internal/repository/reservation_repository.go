@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"gorm.io/gorm/clause"
+)
+
+// an item whose warehouse reservation still has to be confirmed or released, with the state of its order
+type ReservationToSettle struct {
+	models.OrderItem
+	OrderStatus    string
+	GroupSessionID *string
+}
+
+// reserved items of orders in one of statuses that no sweep claimed after claimedBefore, a group order still held
+// in paid is left alone since its session may yet expire. the items are stamped with now so the warehouse can be
+// called once the transaction committed, a claim older than claimedBefore is taken to be from a sweep that died
+func (r *OrderRepository) ClaimReservationsToSettle(ctx context.Context, statuses []string, claimedBefore time.Time, now time.Time, limit int) ([]ReservationToSettle, error) {
+	var items []ReservationToSettle
+
+	results := r.db.WithContext(ctx).
+		Model(&models.OrderItem{}).
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "order_item"}, Options: "SKIP LOCKED"}).
+		Select(`order_item.*, "order".status AS order_status, "order"."groupSessionId" AS group_session_id`).
+		Joins(`JOIN "order" ON "order".id = order_item."orderId"`).
+		Where(`order_item."reservationStatus" = ?`, "reserved").
+		Where(`(order_item."reservationClaimedAt" IS NULL OR order_item."reservationClaimedAt" < ?)`, claimedBefore).
+		Where(`"order".status IN ?`, statuses).
+		Where(`NOT ("order".status = ? AND "order"."groupSessionId" IS NOT NULL)`, "paid").
+		Order(`order_item."createdAt"`).
+		Limit(limit).
+		Find(&items)
+	if results.Error != nil || len(items) == 0 {
+		return items, results.Error
+	}
+
+	ids := make([]string, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+		items[i].ReservationClaimedAt = &now
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&models.OrderItem{}).
+		Where("id IN ?", ids).
+		Update("reservationClaimedAt", now).Error
+	return items, err
+}
+
+// records what the warehouse did with a claimed reservation, an item settled in the meantime is left as it is
+func (r *OrderRepository) SettleReservation(ctx context.Context, orderItemId string, status string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.OrderItem{}).
+		Where(`id = ? AND "reservationStatus" = ?`, orderItemId, "reserved").
+		Updates(map[string]any{
+			"reservationStatus":    status,
+			"reservationClaimedAt": nil,
+		}).Error
+}
+
+// gives a claimed reservation back so the next sweep tries the warehouse again
+func (r *OrderRepository) UnclaimReservation(ctx context.Context, orderItemId string) error {
+	return r.db.WithContext(ctx).
+		Model(&models.OrderItem{}).
+		Where(`id = ? AND "reservationStatus" = ?`, orderItemId, "reserved").
+		Update("reservationClaimedAt", nil).Error
+}
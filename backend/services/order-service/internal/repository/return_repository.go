@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *OrderRepository) CreateReturn(ctx context.Context, ret *models.Return) error {
+	return r.db.WithContext(ctx).Create(ret).Error
+}
+
+func (r *OrderRepository) GetReturnByID(ctx context.Context, returnId string) (models.Return, error) {
+	var ret models.Return
+
+	results := r.db.WithContext(ctx).
+		Where("id = ?", returnId).
+		First(&ret)
+	return ret, results.Error
+}
+
+func (r *OrderRepository) GetReturnByIDForUpdate(ctx context.Context, returnId string) (models.Return, error) {
+	var ret models.Return
+
+	results := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", returnId).
+		First(&ret)
+	return ret, results.Error
+}
+
+func (r *OrderRepository) GetReturnsByOrderID(ctx context.Context, orderId string) ([]models.Return, error) {
+	var returns []models.Return
+
+	results := r.db.WithContext(ctx).
+		Where(`"orderId" = ?`, orderId).
+		Order(`"createdAt" DESC`).
+		Find(&returns)
+	return returns, results.Error
+}
+
+func (r *OrderRepository) UpdateReturn(ctx context.Context, ret *models.Return) error {
+	return r.db.WithContext(ctx).Save(ret).Error
+}
+
+// the guard in the where clause keeps returnedQuantity from passing quantity, false means nothing was updated
+func (r *OrderRepository) AddReturnedQuantity(ctx context.Context, orderItemId string, quantity int) (bool, error) {
+	results := r.db.WithContext(ctx).
+		Model(&models.OrderItem{}).
+		Where(`id = ? AND "returnedQuantity" + ? <= quantity`, orderItemId, quantity).
+		Update("returnedQuantity", gorm.Expr(`"returnedQuantity" + ?`, quantity))
+	return results.RowsAffected == 1, results.Error
+}
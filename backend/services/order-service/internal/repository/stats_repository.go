@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type StatsFilter struct {
+	From        time.Time
+	To          time.Time //exclusive
+	SellerID    string
+	BrandID     string
+	OrderSource string
+	Interval    string //day, week or month, passed to date_trunc
+	TimeZone    string //buckets start at midnight in this zone
+	TopLimit    int
+}
+
+type StatusCountRow struct {
+	Status    string
+	Orders    int64
+	Amount    decimal.Decimal
+	GMVOrders int64
+	GMV       decimal.Decimal
+}
+
+type StatsBucketRow struct {
+	Bucket    time.Time
+	Orders    int64
+	Cancelled int64
+	GMVOrders int64
+	GMV       decimal.Decimal
+}
+
+type TopProductRow struct {
+	ProductID   string
+	ProductName string
+	Quantity    int64
+	Orders      int64
+	Revenue     decimal.Decimal
+}
+
+type TopMerchantRow struct {
+	ID      string
+	Orders  int64
+	Revenue decimal.Decimal
+}
+
+// orders that were paid for make up GMV and revenue, pending, cancelled and refunded ones are only counted
+var gmvStatuses = []string{
+	"paid", "confirmed", "processing", "ready_to_ship", "shipped", "in_transit", "out_for_delivery", "delivered",
+	"completed", "partially_refunded",
+}
+
+// a partially refunded order counts with what its completed refund returns didn't pay back
+const gmvAmount = `"order"."totalAmount" - CASE WHEN "order".status = 'partially_refunded' THEN ` +
+	`COALESCE((SELECT SUM(r."totalAmount") FROM "return" r WHERE r."orderId" = "order".id AND r.status = 'completed' AND r."returnType" = 'refund'), 0) ` +
+	`ELSE 0 END`
+
+// an item's revenue without the units that came back
+const itemRevenue = `(order_item.subtotal - order_item."discountAmount") * (order_item.quantity - order_item."returnedQuantity") / order_item.quantity`
+
+func (r *OrderRepository) statsOrders(ctx context.Context, filter StatsFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Table("order").
+		Where(`"order"."createdAt" >= ? AND "order"."createdAt" < ?`, filter.From, filter.To)
+
+	if filter.SellerID != "" {
+		query = query.Where(`"order"."sellerId" = ?`, filter.SellerID)
+	}
+	if filter.BrandID != "" {
+		query = query.Where(`"order"."brandId" = ?`, filter.BrandID)
+	}
+	if filter.OrderSource != "" {
+		query = query.Where(`"order"."orderSource" = ?`, filter.OrderSource)
+	}
+
+	return query
+}
+
+func (r *OrderRepository) GetStatusCounts(ctx context.Context, filter StatsFilter) ([]StatusCountRow, error) {
+	var rows []StatusCountRow
+
+	results := r.statsOrders(ctx, filter).
+		Select(`"order".status AS status, COUNT(*) AS orders, COALESCE(SUM("order"."totalAmount"), 0) AS amount, `+
+			`COUNT(*) FILTER (WHERE "order".status IN ?) AS gmv_orders, `+
+			`COALESCE(SUM(`+gmvAmount+`) FILTER (WHERE "order".status IN ?), 0) AS gmv`, gmvStatuses, gmvStatuses).
+		Group(`"order".status`).
+		Order(`"order".status`).
+		Scan(&rows)
+	return rows, results.Error
+}
+
+// only buckets that have orders are returned, the service fills the gaps
+func (r *OrderRepository) GetStatsBuckets(ctx context.Context, filter StatsFilter) ([]StatsBucketRow, error) {
+	var rows []StatsBucketRow
+
+	bucket := gorm.Expr(`date_trunc(?, "order"."createdAt", ?)`, filter.Interval, filter.TimeZone)
+	results := r.statsOrders(ctx, filter).
+		Select(`? AS bucket, COUNT(*) AS orders, `+
+			`COUNT(*) FILTER (WHERE "order".status = 'cancelled') AS cancelled, `+
+			`COUNT(*) FILTER (WHERE "order".status IN ?) AS gmv_orders, `+
+			`COALESCE(SUM(`+gmvAmount+`) FILTER (WHERE "order".status IN ?), 0) AS gmv`, bucket, gmvStatuses, gmvStatuses).
+		Group("bucket").
+		Order("bucket").
+		Scan(&rows)
+	return rows, results.Error
+}
+
+// revenue is what the customer paid for the item after coupon discounts, less the units that were returned
+func (r *OrderRepository) GetTopProducts(ctx context.Context, filter StatsFilter) ([]TopProductRow, error) {
+	var rows []TopProductRow
+
+	results := r.statsOrders(ctx, filter).
+		Joins(`JOIN order_item ON order_item."orderId" = "order".id`).
+		Where(`"order".status IN ?`, gmvStatuses).
+		Select(`order_item."productId" AS product_id, MAX(order_item."snapshotProductName") AS product_name, ` +
+			`SUM(order_item.quantity) AS quantity, COUNT(DISTINCT "order".id) AS orders, ` +
+			`SUM(` + itemRevenue + `) AS revenue`).
+		Group(`order_item."productId"`).
+		Order("revenue DESC, product_id").
+		Limit(filter.TopLimit).
+		Scan(&rows)
+	return rows, results.Error
+}
+
+func (r *OrderRepository) GetTopFactories(ctx context.Context, filter StatsFilter) ([]TopMerchantRow, error) {
+	var rows []TopMerchantRow
+
+	results := r.statsOrders(ctx, filter).
+		Joins(`JOIN order_item ON order_item."orderId" = "order".id`).
+		Where(`"order".status IN ?`, gmvStatuses).
+		Select(`order_item."factoryId" AS id, COUNT(DISTINCT "order".id) AS orders, ` +
+			`SUM(` + itemRevenue + `) AS revenue`).
+		Group(`order_item."factoryId"`).
+		Order("revenue DESC, id").
+		Limit(filter.TopLimit).
+		Scan(&rows)
+	return rows, results.Error
+}
+
+func (r *OrderRepository) GetTopSellers(ctx context.Context, filter StatsFilter) ([]TopMerchantRow, error) {
+	var rows []TopMerchantRow
+
+	results := r.statsOrders(ctx, filter).
+		Where(`"order".status IN ?`, gmvStatuses).
+		Where(`"order"."sellerId" IS NOT NULL`).
+		Select(`"order"."sellerId" AS id, COUNT(*) AS orders, SUM(` + gmvAmount + `) AS revenue`).
+		Group(`"order"."sellerId"`).
+		Order("revenue DESC, id").
+		Limit(filter.TopLimit).
+		Scan(&rows)
+	return rows, results.Error
+}
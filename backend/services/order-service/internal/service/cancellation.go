@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"gorm.io/gorm"
+)
+
+const reasonPaymentDeadline = "payment deadline exceeded"
+
+// actorType decides what may be cancelled: customers only their own orders and only before processing starts,
+// admins and the system anything the state machine allows, which rules out everything from shipped on
+func (service *OrderService) CancelOrder(ctx context.Context, orderId string, payload types.CancelOrderPayload, actorId string, actorType string) (types.OrderResponse, error) {
+	var order models.Order
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		var err error
+		order, err = lockOrder(ctx, txRepository, orderId)
+		if err != nil {
+			return err
+		}
+
+		if actorType == ActorCustomer {
+			if order.UserID != actorId {
+				return ErrNotOrderOwner
+			}
+			if !statemachine.CanCustomerCancel(order.Status) {
+				return fmt.Errorf("%w: customers cannot cancel an order in status %s", statemachine.ErrInvalidTransition, order.Status)
+			}
+		}
+
+		return service.transitionOrder(ctx, txRepository, &order, statusChange{
+			to:            statemachine.StatusCancelled,
+			reason:        payload.Reason,
+			notes:         payload.Notes,
+			changedBy:     actorId,
+			changedByType: actorType,
+		})
+	})
+	if err != nil {
+		return types.OrderResponse{}, err
+	}
+
+	return service.parseToOrderResponse([]models.Order{order})[0], nil
+}
+
+// for payment-service giving up on the payment, safe to call again once the order is cancelled or paid
+func (service *OrderService) CancelUnpaidOrder(ctx context.Context, orderId string, reason string) error {
+	return service.cancelUnpaid(ctx, orderId, reason, func(order models.Order) bool {
+		return true
+	})
+}
+
+// cancels the order only once its payment deadline has passed at now
+func (service *OrderService) ExpireUnpaidOrder(ctx context.Context, orderId string, now time.Time) error {
+	return service.cancelUnpaid(ctx, orderId, reasonPaymentDeadline, func(order models.Order) bool {
+		return !now.Before(service.PaymentDeadline(order))
+	})
+}
+
+// cancels one batch of orders past their payment deadline and returns how many were found.
+// with dryRun nothing is locked or written, the orders that would be cancelled are only logged
+func (service *OrderService) ExpireOverdueOrders(ctx context.Context, now time.Time, batchSize int, dryRun bool) (int, error) {
+	cutoff := now.Add(-service.paymentWindow)
+	unpaid := []string{statemachine.StatusPending, statemachine.StatusAwaitingPayment}
+
+	if dryRun {
+		orders, err := service.orderRepository.GetUnpaidOrdersCreatedBefore(ctx, unpaid, cutoff, batchSize, false)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, order := range orders {
+			log.Printf("[dry run] would cancel order %s (%s), unpaid since %s", order.OrderNumber, order.Status, order.CreatedAt.Format(time.RFC3339))
+		}
+		return len(orders), nil
+	}
+
+	var expired int
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		orders, err := txRepository.GetUnpaidOrdersCreatedBefore(ctx, unpaid, cutoff, batchSize, true)
+		if err != nil {
+			return err
+		}
+
+		for i := range orders {
+			if err := service.transitionOrder(ctx, txRepository, &orders[i], statusChange{
+				to:            statemachine.StatusCancelled,
+				reason:        reasonPaymentDeadline,
+				changedByType: ActorSystem,
+			}); err != nil {
+				return err
+			}
+		}
+
+		expired = len(orders)
+		return nil
+	})
+
+	return expired, err
+}
+
+func (service *OrderService) PaymentDeadline(order models.Order) time.Time {
+	return order.CreatedAt.Add(service.paymentWindow)
+}
+
+func (service *OrderService) cancelUnpaid(ctx context.Context, orderId string, reason string, due func(order models.Order) bool) error {
+	return service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		order, err := lockOrder(ctx, txRepository, orderId)
+		if err != nil {
+			return err
+		}
+
+		//the payment may have landed while the row lock was awaited
+		if !statemachine.IsUnpaid(order.Status) || !due(order) {
+			log.Printf("Not cancelling order %s in status %s: %s", order.ID, order.Status, reason)
+			return nil
+		}
+
+		return service.transitionOrder(ctx, txRepository, &order, statusChange{
+			to:            statemachine.StatusCancelled,
+			reason:        reason,
+			changedByType: ActorSystem,
+		})
+	})
+}
+
+func lockOrder(ctx context.Context, txRepository *repository.OrderRepository, orderId string) (models.Order, error) {
+	order, err := txRepository.GetOrderByIDForUpdate(ctx, orderId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Order{}, ErrOrderNotFound
+	}
+	return order, err
+}
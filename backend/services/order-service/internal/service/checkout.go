@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
+	"github.com/shopspring/decimal"
+)
+
+// the items of one seller, or of one brand when the product has no seller, ship and settle as an order of their own
+type checkoutGroup struct {
+	source   string
+	brandId  *string
+	sellerId *string
+	items    []models.OrderItem
+	subtotal decimal.Decimal
+}
+
+// prices are taken from product-service and shipping from logistic-service, never from the client payload. the
+// checkout gets one shipping quote that is split over its orders, then each order is priced through the pricing
+// pipeline. the orders come back in the order their first item appeared in the cart, all sharing one checkout id,
+// with warehouse stock already held for them. callers release that stock when the orders end up not being written
+func (service *OrderService) buildCheckout(ctx context.Context, payload types.CreateOrderPayload) ([]*models.Order, error) {
+	orderItems, _, err := service.priceItems(ctx, payload.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	checkoutId, err := utils.GenerateID()
+	if err != nil {
+		return nil, err
+	}
+
+	//ids are known before anything is inserted, the warehouse reservations are made against them
+	lineIndex := make(map[string]int, len(orderItems))
+	for i := range orderItems {
+		if orderItems[i].ID, err = utils.GenerateID(); err != nil {
+			return nil, err
+		}
+		lineIndex[orderItems[i].ID] = i
+	}
+
+	groups := splitCheckout(orderItems)
+
+	address := payload.ShippingAddress
+	orders := make([]*models.Order, len(groups))
+	for i, group := range groups {
+		orderId, err := utils.GenerateID()
+		if err != nil {
+			return nil, err
+		}
+
+		orders[i] = &models.Order{
+			ID:                 orderId,
+			UserID:             payload.UserID,
+			CheckoutID:         &checkoutId,
+			OrderSource:        group.source,
+			BrandID:            group.brandId,
+			SellerID:           group.sellerId,
+			Status:             statemachine.StatusPending,
+			Subtotal:           group.subtotal,
+			ShippingCost:       decimal.Zero,
+			TaxAmount:          decimal.Zero,
+			DiscountAmount:     decimal.Zero,
+			TotalAmount:        group.subtotal,
+			ShippingName:       address.Name,
+			ShippingPhone:      address.Phone,
+			ShippingProvince:   address.Province,
+			ShippingCity:       address.City,
+			ShippingDistrict:   address.District,
+			ShippingPostalCode: address.PostalCode,
+			ShippingAddress:    address.Address,
+			CustomerName:       address.Name, //checkout asks for no contact of its own, the recipient is who ordered
+			CustomerPhone:      address.Phone,
+			OrderItems:         group.items,
+		}
+	}
+
+	if err := service.quoteShipping(ctx, orders, shippingChoice{courier: payload.Courier, service: payload.ShippingService}); err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		service.priceOrder(order, appliedCoupon{})
+	}
+
+	//the key is unique per order, the first order carries it for the whole checkout
+	orders[0].IdempotencyKey = optionalString(payload.IdempotencyKey)
+
+	if err := service.reserveStock(ctx, orders, lineIndex); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func splitCheckout(orderItems []models.OrderItem) []checkoutGroup {
+	var groups []checkoutGroup
+	groupIndex := make(map[string]int)
+
+	for _, item := range orderItems {
+		key, group := OrderSourceBrand+":"+derefString(item.BrandID), checkoutGroup{source: OrderSourceBrand, brandId: item.BrandID}
+		if item.SellerID != nil {
+			key, group = OrderSourceSeller+":"+*item.SellerID, checkoutGroup{source: OrderSourceSeller, sellerId: item.SellerID}
+		}
+
+		index, ok := groupIndex[key]
+		if !ok {
+			index = len(groups)
+			groupIndex[key] = index
+			group.subtotal = decimal.Zero
+			groups = append(groups, group)
+		}
+
+		groups[index].items = append(groups[index].items, item)
+		groups[index].subtotal = groups[index].subtotal.Add(item.Subtotal)
+	}
+
+	return groups
+}
+
+// splits total over the weights in whole rupiah, rounded down with the remainder going to the largest weight so
+// the shares always add up to total. without any weight everything goes to the first share
+func allocateProportionally(total decimal.Decimal, weights []decimal.Decimal) []decimal.Decimal {
+	shares := make([]decimal.Decimal, len(weights))
+	weightSum := decimal.Zero
+	largest := 0
+	for i, weight := range weights {
+		shares[i] = decimal.Zero
+		weightSum = weightSum.Add(weight)
+		if weight.GreaterThan(weights[largest]) {
+			largest = i
+		}
+	}
+
+	if len(weights) == 0 || total.IsZero() {
+		return shares
+	}
+
+	if !weightSum.IsPositive() {
+		shares[0] = total
+		return shares
+	}
+
+	allocated := decimal.Zero
+	for i, weight := range weights {
+		shares[i] = total.Mul(weight).Div(weightSum).Floor()
+		allocated = allocated.Add(shares[i])
+	}
+	shares[largest] = shares[largest].Add(total.Sub(allocated))
+
+	return shares
+}
+
+// the orders a checkout was split into, older orders without a checkout id are a group of their own
+func (service *OrderService) getCheckout(ctx context.Context, order models.Order) (types.CheckoutResponse, error) {
+	if order.CheckoutID == nil {
+		return service.parseToCheckoutResponse(order.ID, []models.Order{order}), nil
+	}
+
+	orders, err := service.orderRepository.GetOrdersByCheckoutID(ctx, *order.CheckoutID)
+	if err != nil {
+		return types.CheckoutResponse{}, err
+	}
+
+	return service.parseToCheckoutResponse(*order.CheckoutID, orders), nil
+}
+
+func (service *OrderService) parseToCheckoutResponse(checkoutId string, orders []models.Order) types.CheckoutResponse {
+	checkout := types.CheckoutResponse{
+		CheckoutID:     checkoutId,
+		Subtotal:       decimal.Zero,
+		ShippingCost:   decimal.Zero,
+		TaxAmount:      decimal.Zero,
+		DiscountAmount: decimal.Zero,
+		TotalAmount:    decimal.Zero,
+		Orders:         service.parseToOrderResponse(orders),
+	}
+
+	for _, order := range orders {
+		checkout.Subtotal = checkout.Subtotal.Add(order.Subtotal)
+		checkout.ShippingCost = checkout.ShippingCost.Add(order.ShippingCost)
+		checkout.TaxAmount = checkout.TaxAmount.Add(order.TaxAmount)
+		checkout.DiscountAmount = checkout.DiscountAmount.Add(order.DiscountAmount)
+		checkout.TotalAmount = checkout.TotalAmount.Add(order.TotalAmount)
+	}
+
+	return checkout
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients/clientstest"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/shared/types"
+	"github.com/shopspring/decimal"
+)
+
+func rupiahs(amounts ...int64) []decimal.Decimal {
+	values := make([]decimal.Decimal, len(amounts))
+	for i, amount := range amounts {
+		values[i] = decimal.NewFromInt(amount)
+	}
+	return values
+}
+
+func TestAllocateProportionally(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []decimal.Decimal
+		want    []decimal.Decimal
+	}{
+		{name: "even split", total: 30000, weights: rupiahs(100, 100, 100), want: rupiahs(10000, 10000, 10000)},
+		{name: "by weight", total: 20000, weights: rupiahs(18000, 9000, 9000), want: rupiahs(10000, 5000, 5000)},
+		{name: "remainder to the largest weight", total: 10000, weights: rupiahs(20000, 50000, 20000), want: rupiahs(2222, 5556, 2222)},
+		{name: "remainder to the first of equal weights", total: 100, weights: rupiahs(1, 1, 1), want: rupiahs(34, 33, 33)},
+		{name: "zero weight gets nothing", total: 9000, weights: rupiahs(0, 15000, 0), want: rupiahs(0, 9000, 0)},
+		{name: "no weight at all goes to the first", total: 9000, weights: rupiahs(0, 0), want: rupiahs(9000, 0)},
+		{name: "nothing to split", total: 0, weights: rupiahs(15000, 20000), want: rupiahs(0, 0)},
+		{name: "no shares", total: 9000, weights: nil, want: rupiahs()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := allocateProportionally(decimal.NewFromInt(tt.total), tt.weights)
+			if len(shares) != len(tt.want) {
+				t.Fatalf("got %d shares, want %d", len(shares), len(tt.want))
+			}
+
+			sum := decimal.Zero
+			for i, share := range shares {
+				if !share.Equal(tt.want[i]) {
+					t.Errorf("share %d = %s, want %s", i, share, tt.want[i])
+				}
+				sum = sum.Add(share)
+			}
+			if len(shares) > 0 && !sum.Equal(decimal.NewFromInt(tt.total)) {
+				t.Fatalf("shares add up to %s, want %d", sum, tt.total)
+			}
+		})
+	}
+}
+
+// one quote for the whole checkout, shared by what each order's items weigh
+func TestQuoteShippingSplitsOneQuoteByWeight(t *testing.T) {
+	service := &OrderService{rates: &clientstest.Rates{Rates: []clients.Rate{
+		{Courier: "jne", ServiceCode: "REG", Rate: decimal.RequireFromString("20000.40")},
+		{Courier: "sicepat", ServiceCode: "BEST", Rate: decimal.NewFromInt(25000)},
+	}}}
+
+	heavy := &models.Order{ShippingPostalCode: "40111", Subtotal: decimal.NewFromInt(150000), OrderItems: []models.OrderItem{
+		{Quantity: 1, ProductSnapshot: types.JSONB{"product": map[string]interface{}{"weight_grams": float64(3000)}}},
+	}}
+	//no weight in the snapshot counts as a kilogram
+	light := &models.Order{ShippingPostalCode: "40111", Subtotal: decimal.NewFromInt(50000), OrderItems: []models.OrderItem{
+		{Quantity: 1},
+	}}
+
+	if err := service.quoteShipping(context.Background(), []*models.Order{heavy, light}, shippingChoice{}); err != nil {
+		t.Fatalf("quoteShipping: %v", err)
+	}
+
+	//20000.40 is charged as 20001, three quarters of it plus the rounding remainder go to the heavier order
+	if !heavy.ShippingCost.Equal(decimal.NewFromInt(15001)) || !light.ShippingCost.Equal(decimal.NewFromInt(5000)) {
+		t.Fatalf("shipping costs = %s and %s, want 15001 and 5000", heavy.ShippingCost, light.ShippingCost)
+	}
+	for _, order := range []*models.Order{heavy, light} {
+		if order.ShippingCourier == nil || *order.ShippingCourier != "jne" || order.ShippingMethod == nil || *order.ShippingMethod != "REG" {
+			t.Fatalf("order ships with %v %v, want the cheapest jne REG", order.ShippingCourier, order.ShippingMethod)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+)
+
+// the customer confirms the parcel arrived, which completes a delivered order before the window runs out
+func (service *OrderService) ConfirmReceived(ctx context.Context, orderId string, actorId string) (types.OrderResponse, error) {
+	var order models.Order
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		var err error
+		order, err = lockOrder(ctx, txRepository, orderId)
+		if err != nil {
+			return err
+		}
+
+		if order.UserID != actorId {
+			return ErrNotOrderOwner
+		}
+
+		return service.transitionOrder(ctx, txRepository, &order, statusChange{
+			to:            statemachine.StatusCompleted,
+			reason:        "received confirmed by customer",
+			changedBy:     actorId,
+			changedByType: ActorCustomer,
+		})
+	})
+	if err != nil {
+		return types.OrderResponse{}, err
+	}
+
+	return service.parseToOrderResponse([]models.Order{order})[0], nil
+}
+
+// completes one batch of orders delivered more than window ago and returns how many were completed
+func (service *OrderService) CompleteDeliveredOrders(ctx context.Context, now time.Time, window time.Duration, batchSize int) (int, error) {
+	var completed int
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		orders, err := txRepository.GetOrdersDeliveredBefore(ctx, now.Add(-window), batchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range orders {
+			if err := service.transitionOrder(ctx, txRepository, &orders[i], statusChange{
+				to:            statemachine.StatusCompleted,
+				reason:        "completion window passed",
+				changedByType: ActorSystem,
+			}); err != nil {
+				return err
+			}
+		}
+
+		completed = len(orders)
+		return nil
+	})
+
+	return completed, err
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/coupon"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCouponNotFound  = errors.New("coupon not found")
+	ErrInvalidCoupon   = errors.New("invalid coupon")
+	ErrCouponCodeTaken = errors.New("coupon code already exists")
+)
+
+// admin management of coupons, redemption happens in OrderService.CreateOrder
+type CouponService struct {
+	orderRepository *repository.OrderRepository
+}
+
+func NewCouponService(orderRepository *repository.OrderRepository) *CouponService {
+	return &CouponService{orderRepository: orderRepository}
+}
+
+func (service *CouponService) ListCoupons(ctx context.Context, payload types.CouponFilterPayload) (types.PaginatedCouponsResponse, error) {
+	filter := repository.CouponFilter{
+		Search:   strings.TrimSpace(payload.Search),
+		IsActive: payload.IsActive,
+		Limit:    defaultPageLimit,
+	}
+	if payload.Limit > 0 {
+		filter.Limit = payload.Limit
+	}
+	if payload.Page > 1 {
+		filter.Offset = (payload.Page - 1) * filter.Limit
+	}
+
+	coupons, total, err := service.orderRepository.GetCoupons(ctx, filter)
+	if err != nil {
+		return types.PaginatedCouponsResponse{}, err
+	}
+
+	if coupons == nil {
+		coupons = []models.Coupon{}
+	}
+
+	return types.PaginatedCouponsResponse{
+		Data: coupons,
+		Pagination: types.Pagination{
+			Page:       filter.Offset/filter.Limit + 1,
+			Limit:      filter.Limit,
+			Total:      int(total),
+			TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+		},
+	}, nil
+}
+
+func (service *CouponService) GetCoupon(ctx context.Context, couponId string) (models.Coupon, error) {
+	found, err := service.orderRepository.GetCouponByID(ctx, couponId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.Coupon{}, ErrCouponNotFound
+		}
+		return models.Coupon{}, err
+	}
+
+	return found, nil
+}
+
+func (service *CouponService) CreateCoupon(ctx context.Context, payload types.CouponPayload, actorId string) (models.Coupon, error) {
+	if err := validateCoupon(payload); err != nil {
+		return models.Coupon{}, err
+	}
+
+	created := models.Coupon{CreatedBy: optionalString(actorId)}
+	applyCouponPayload(&created, payload)
+
+	if err := service.orderRepository.CreateCoupon(ctx, &created); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.Coupon{}, fmt.Errorf("%w: %s", ErrCouponCodeTaken, created.Code)
+		}
+		return models.Coupon{}, err
+	}
+
+	return created, nil
+}
+
+// replaces every editable field, redemptions made so far are kept
+func (service *CouponService) UpdateCoupon(ctx context.Context, couponId string, payload types.CouponPayload) (models.Coupon, error) {
+	if err := validateCoupon(payload); err != nil {
+		return models.Coupon{}, err
+	}
+
+	updated, err := service.GetCoupon(ctx, couponId)
+	if err != nil {
+		return models.Coupon{}, err
+	}
+
+	applyCouponPayload(&updated, payload)
+
+	if err := service.orderRepository.UpdateCoupon(ctx, &updated); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return models.Coupon{}, fmt.Errorf("%w: %s", ErrCouponCodeTaken, updated.Code)
+		}
+		return models.Coupon{}, err
+	}
+
+	return updated, nil
+}
+
+// soft delete, orders keep pointing at the coupon they redeemed
+func (service *CouponService) DeleteCoupon(ctx context.Context, couponId string) error {
+	deleted, err := service.orderRepository.DeleteCoupon(ctx, couponId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrCouponNotFound
+	}
+
+	return nil
+}
+
+func validateCoupon(payload types.CouponPayload) error {
+	if !payload.DiscountValue.IsPositive() && payload.DiscountType != coupon.DiscountFreeShipping {
+		return fmt.Errorf("%w: discountValue must be greater than zero", ErrInvalidCoupon)
+	}
+
+	if payload.DiscountType == coupon.DiscountPercentage && payload.DiscountValue.GreaterThan(decimal.NewFromInt(100)) {
+		return fmt.Errorf("%w: a percentage discount can't exceed 100", ErrInvalidCoupon)
+	}
+
+	if payload.MinOrderAmount != nil && payload.MinOrderAmount.IsNegative() {
+		return fmt.Errorf("%w: minOrderAmount can't be negative", ErrInvalidCoupon)
+	}
+
+	if payload.MaxDiscountAmount != nil && !payload.MaxDiscountAmount.IsPositive() {
+		return fmt.Errorf("%w: maxDiscountAmount must be greater than zero", ErrInvalidCoupon)
+	}
+
+	if !payload.ExpiresAt.After(payload.StartsAt) {
+		return fmt.Errorf("%w: expiresAt must be after startsAt", ErrInvalidCoupon)
+	}
+
+	return nil
+}
+
+func applyCouponPayload(target *models.Coupon, payload types.CouponPayload) {
+	target.Code = normalizeCouponCode(payload.Code)
+	target.Name = payload.Name
+	target.Description = payload.Description
+	target.DiscountType = payload.DiscountType
+	target.DiscountValue = payload.DiscountValue
+	target.MinOrderAmount = payload.MinOrderAmount
+	target.MaxDiscountAmount = payload.MaxDiscountAmount
+	target.TotalUsageLimit = payload.TotalUsageLimit
+	target.ApplicableBrandIDs = payload.ApplicableBrandIDs
+	target.ApplicableCategoryIDs = payload.ApplicableCategoryIDs
+	target.ApplicableProductIDs = payload.ApplicableProductIDs
+	target.ExcludedProductIDs = payload.ExcludedProductIDs
+	target.NewCustomersOnly = payload.NewCustomersOnly
+	target.StartsAt = payload.StartsAt
+	target.ExpiresAt = payload.ExpiresAt
+	target.LiveSessionID = payload.LiveSessionID
+	target.CampaignID = payload.CampaignID
+
+	target.PerUserLimit = 1
+	if payload.PerUserLimit > 0 {
+		target.PerUserLimit = payload.PerUserLimit
+	}
+
+	target.IsActive = true
+	if payload.IsActive != nil {
+		target.IsActive = *payload.IsActive
+	}
+
+	target.Source = "manual"
+	if payload.Source != "" {
+		target.Source = payload.Source
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// clients treat the cursor as opaque, the json inside may change without notice
+type cursorToken struct {
+	CreatedAt string `json:"c"`
+	ID        string `json:"i"`
+}
+
+func encodeCursor(order models.Order) string {
+	token, _ := json.Marshal(cursorToken{
+		CreatedAt: order.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ID:        order.ID,
+	})
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// an empty cursor is the first page
+func decodeCursor(cursor string) (*repository.OrderCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var token cursorToken
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	//the id is compared against a uuid column, anything else would fail in postgres instead of here
+	if _, err := uuid.Parse(token.ID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, token.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return &repository.OrderCursor{CreatedAt: createdAt, ID: token.ID}, nil
+}
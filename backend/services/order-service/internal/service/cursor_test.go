@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	wib := time.FixedZone("WIB", 7*60*60)
+	order := models.Order{
+		ID:        "6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b",
+		CreatedAt: time.Date(2026, 1, 15, 17, 4, 5, 123456789, wib),
+	}
+
+	cursor, err := decodeCursor(encodeCursor(order))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	//keyset paging compares against the stored timestamp, dropping the nanoseconds would repeat or skip rows
+	if !cursor.CreatedAt.Equal(order.CreatedAt) || cursor.ID != order.ID {
+		t.Fatalf("cursor = %+v, want %s/%s", cursor, order.CreatedAt, order.ID)
+	}
+}
+
+func TestEmptyCursorIsFirstPage(t *testing.T) {
+	cursor, err := decodeCursor("")
+	if err != nil || cursor != nil {
+		t.Fatalf("decodeCursor(\"\") = %v, %v, want the first page", cursor, err)
+	}
+}
+
+func TestDecodeCursorRejectsInvalidInput(t *testing.T) {
+	token := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "!!!"},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte(`{"c":"2026-01-15T10:04:05Z","i":"6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"}`))},
+		{name: "not json", cursor: token(`created_at=2026-01-15`)},
+		{name: "missing id", cursor: token(`{"c":"2026-01-15T10:04:05Z"}`)},
+		{name: "id not a uuid", cursor: token(`{"c":"2026-01-15T10:04:05Z","i":"1 OR 1=1"}`)},
+		{name: "missing time", cursor: token(`{"i":"6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"}`)},
+		{name: "time without zone", cursor: token(`{"c":"2026-01-15T10:04:05","i":"6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"}`)},
+		{name: "time as a number", cursor: token(`{"c":1768471445,"i":"6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if cursor, err := decodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("decodeCursor = %+v, %v, want %v", cursor, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
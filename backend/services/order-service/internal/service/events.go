@@ -0,0 +1,198 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/outbox"
+	"github.com/shopspring/decimal"
+)
+
+const orderAggregateType = "order"
+
+func orderCreatedEvent(order models.Order) outbox.Event {
+	items := make([]kafka.OrderCreatedItem, len(order.OrderItems))
+	for i, item := range order.OrderItems {
+		items[i] = kafka.OrderCreatedItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			FactoryID: item.FactoryID,
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+			UnitPrice: toRupiah(item.UnitPrice),
+			Subtotal:  toRupiah(item.Subtotal),
+		}
+	}
+
+	return outbox.Event{
+		AggregateType: orderAggregateType,
+		AggregateID:   order.ID,
+		EventType:     kafka.EventOrderCreated,
+		EventVersion:  kafka.OrderCreatedVersion,
+		Payload: kafka.OrderCreated{
+			OrderID:        order.ID,
+			OrderNumber:    order.OrderNumber,
+			CheckoutID:     derefString(order.CheckoutID),
+			UserID:         order.UserID,
+			Status:         order.Status,
+			Subtotal:       toRupiah(order.Subtotal),
+			ShippingCost:   toRupiah(order.ShippingCost),
+			TaxAmount:      toRupiah(order.TaxAmount),
+			DiscountAmount: toRupiah(order.DiscountAmount),
+			TotalAmount:    toRupiah(order.TotalAmount),
+			ShippingPhone:  order.ShippingPhone,
+			Items:          items,
+			CreatedAt:      order.CreatedAt,
+		},
+	}
+}
+
+func orderStatusChangedEvent(order models.Order, payload kafka.OrderStatusChanged) outbox.Event {
+	return outbox.Event{
+		AggregateType: orderAggregateType,
+		AggregateID:   order.ID,
+		EventType:     kafka.EventOrderStatusChanged,
+		EventVersion:  kafka.OrderStatusChangedVersion,
+		Payload:       payload,
+	}
+}
+
+func orderCancelledEvent(order models.Order, previousStatus string, orderItems []models.OrderItem) outbox.Event {
+	items := make([]kafka.OrderCancelledItem, len(orderItems))
+	for i, item := range orderItems {
+		items[i] = kafka.OrderCancelledItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	payload := kafka.OrderCancelled{
+		OrderID:        order.ID,
+		OrderNumber:    order.OrderNumber,
+		UserID:         order.UserID,
+		PreviousStatus: previousStatus,
+		RefundRequired: order.PaidAt != nil,
+		TotalAmount:    toRupiah(order.TotalAmount),
+		Items:          items,
+	}
+	if order.CancelReason != nil {
+		payload.Reason = *order.CancelReason
+	}
+	if order.CancelledBy != nil {
+		payload.CancelledBy = *order.CancelledBy
+	}
+	if order.CancelledAt != nil {
+		payload.CancelledAt = *order.CancelledAt
+	}
+
+	return outbox.Event{
+		AggregateType: orderAggregateType,
+		AggregateID:   order.ID,
+		EventType:     kafka.EventOrderCancelled,
+		EventVersion:  kafka.OrderCancelledVersion,
+		Payload:       payload,
+	}
+}
+
+func orderCompletedEvent(order models.Order, completedBy string, orderItems []models.OrderItem) outbox.Event {
+	items := make([]kafka.OrderCompletedItem, len(orderItems))
+	for i, item := range orderItems {
+		items[i] = kafka.OrderCompletedItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			FactoryID: item.FactoryID,
+			Quantity:  item.Quantity,
+			Subtotal:  toRupiah(item.Subtotal),
+		}
+	}
+
+	payload := kafka.OrderCompleted{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		UserID:      order.UserID,
+		TotalAmount: toRupiah(order.TotalAmount),
+		CompletedBy: completedBy,
+		Items:       items,
+		DeliveredAt: order.DeliveredAt,
+	}
+	if order.CompletedAt != nil {
+		payload.CompletedAt = *order.CompletedAt
+	}
+
+	return outbox.Event{
+		AggregateType: orderAggregateType,
+		AggregateID:   order.ID,
+		EventType:     kafka.EventOrderCompleted,
+		EventVersion:  kafka.OrderCompletedVersion,
+		Payload:       payload,
+	}
+}
+
+func orderRepricedEvent(order models.Order, previousShippingCost decimal.Decimal, reason string, repricedBy string) outbox.Event {
+	return outbox.Event{
+		AggregateType: orderAggregateType,
+		AggregateID:   order.ID,
+		EventType:     kafka.EventOrderRepriced,
+		EventVersion:  kafka.OrderRepricedVersion,
+		Payload: kafka.OrderRepriced{
+			OrderID:              order.ID,
+			OrderNumber:          order.OrderNumber,
+			CheckoutID:           derefString(order.CheckoutID),
+			UserID:               order.UserID,
+			PreviousShippingCost: toRupiah(previousShippingCost),
+			ShippingCost:         toRupiah(order.ShippingCost),
+			TaxAmount:            toRupiah(order.TaxAmount),
+			DiscountAmount:       toRupiah(order.DiscountAmount),
+			TotalAmount:          toRupiah(order.TotalAmount),
+			Reason:               reason,
+			RepricedBy:           repricedBy,
+			RepricedAt:           order.UpdatedAt,
+		},
+	}
+}
+
+// keyed by the order so the refund is ordered after the status change it causes
+func refundRequestedEvent(order models.Order, ret models.Return, orderItems map[string]models.OrderItem, fullRefund bool, at time.Time) outbox.Event {
+	items := make([]kafka.RefundRequestedItem, len(ret.Items))
+	for i, returned := range ret.Items {
+		orderItem := orderItems[returned.OrderItemID]
+		items[i] = kafka.RefundRequestedItem{
+			OrderItemID: returned.OrderItemID,
+			ProductID:   orderItem.ProductID,
+			VariantID:   orderItem.VariantID,
+			Quantity:    returned.Quantity,
+			Amount:      toRupiah(returned.Amount),
+		}
+	}
+
+	payload := kafka.RefundRequested{
+		ReturnID:     ret.ID,
+		ReturnNumber: ret.ReturnNumber,
+		OrderID:      order.ID,
+		OrderNumber:  order.OrderNumber,
+		UserID:       order.UserID,
+		ReturnType:   ret.ReturnType,
+		Amount:       toRupiah(ret.TotalAmount),
+		FullRefund:   fullRefund,
+		Items:        items,
+		RequestedAt:  at,
+	}
+	if ret.RefundMethod != nil {
+		payload.RefundMethod = *ret.RefundMethod
+	}
+
+	return outbox.Event{
+		AggregateType: orderAggregateType,
+		AggregateID:   order.ID,
+		EventType:     kafka.EventRefundRequested,
+		EventVersion:  kafka.RefundRequestedVersion,
+		Payload:       payload,
+	}
+}
+
+// pricing keeps every amount in whole rupiah, the rounding only guards against a fractional product price
+func toRupiah(amount decimal.Decimal) kafka.Rupiah {
+	return kafka.Rupiah(amount.Round(0).IntPart())
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// a session is open until enough units are paid for (filled) or its deadline passes first (expired)
+const (
+	GroupSessionOpen    = "open"
+	GroupSessionFilled  = "filled"
+	GroupSessionExpired = "expired"
+)
+
+var (
+	ErrGroupSessionNotFound  = errors.New("group session not found")
+	ErrInvalidGroupSession   = errors.New("invalid group session")
+	ErrGroupSessionClosed    = errors.New("group session is no longer open")
+	ErrGroupSessionFull      = errors.New("group session has no quantity left")
+	ErrGroupSessionNotFilled = errors.New("group session has not reached its minimum quantity")
+)
+
+func (service *OrderService) CreateGroupSession(ctx context.Context, payload types.CreateGroupSessionPayload, actorId string) (models.GroupSession, error) {
+	products, err := service.fetchProducts(ctx, []string{payload.ProductID})
+	if err != nil {
+		return models.GroupSession{}, err
+	}
+	product, found := products[payload.ProductID]
+	if reason := unavailableReason(product, found); reason != "" {
+		return models.GroupSession{}, &UnavailableItemsError{Items: []UnavailableItem{{ProductID: payload.ProductID, Reason: reason}}}
+	}
+
+	session := models.GroupSession{
+		ProductID:   product.ID,
+		Title:       payload.Title,
+		UnitPrice:   product.BaseSellPrice,
+		MinQuantity: payload.MinQuantity,
+		MaxQuantity: payload.MaxQuantity,
+		Status:      GroupSessionOpen,
+		ExpiresAt:   payload.ExpiresAt,
+		CreatedBy:   optionalString(actorId),
+	}
+	if payload.UnitPrice != nil {
+		session.UnitPrice = *payload.UnitPrice
+	}
+
+	if !session.UnitPrice.IsPositive() {
+		return models.GroupSession{}, fmt.Errorf("%w: unitPrice must be positive", ErrInvalidGroupSession)
+	}
+	if session.MaxQuantity != nil && *session.MaxQuantity < session.MinQuantity {
+		return models.GroupSession{}, fmt.Errorf("%w: maxQuantity must not be below minQuantity", ErrInvalidGroupSession)
+	}
+	if !session.ExpiresAt.After(time.Now()) {
+		return models.GroupSession{}, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidGroupSession)
+	}
+
+	if err := service.orderRepository.CreateGroupSession(ctx, &session); err != nil {
+		return models.GroupSession{}, err
+	}
+
+	return session, nil
+}
+
+func (service *OrderService) GetGroupSession(ctx context.Context, sessionId string) (models.GroupSession, error) {
+	session, err := service.orderRepository.GetGroupSessionByID(ctx, sessionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.GroupSession{}, ErrGroupSessionNotFound
+		}
+		return models.GroupSession{}, err
+	}
+
+	return session, nil
+}
+
+// joining creates an ordinary order at the session's price. it is paid like any other order but held in paid
+// until the session fills, the session row is locked while the order is written so MaxQuantity can't be overbooked
+func (service *OrderService) JoinGroupSession(ctx context.Context, sessionId string, payload types.JoinGroupSessionPayload) (types.CheckoutResponse, error) {
+	if checkout, replayed, err := service.replayCheckout(ctx, payload.IdempotencyKey, payload.UserID); replayed || err != nil {
+		return checkout, err
+	}
+
+	session, err := service.GetGroupSession(ctx, sessionId)
+	if err != nil {
+		return types.CheckoutResponse{}, err
+	}
+
+	orders, err := service.buildCheckout(ctx, types.CreateOrderPayload{
+		UserID:          payload.UserID,
+		Items:           []types.OrderItemPayload{{ProductID: session.ProductID, Quantity: payload.Quantity}},
+		ShippingAddress: payload.ShippingAddress,
+		Courier:         payload.Courier,
+		ShippingService: payload.ShippingService,
+		IdempotencyKey:  payload.IdempotencyKey,
+	})
+	if err != nil {
+		return types.CheckoutResponse{}, err
+	}
+
+	//one product always makes one order
+	order := orders[0]
+	item := &order.OrderItems[0]
+	item.UnitPrice = session.UnitPrice
+	item.Subtotal = session.UnitPrice.Mul(decimal.NewFromInt(int64(item.Quantity)))
+	order.GroupSessionID = &session.ID
+	order.Subtotal = item.Subtotal
+	service.priceOrder(order, appliedCoupon{})
+
+	err = service.createCheckout(ctx, orders, "", func(txRepository *repository.OrderRepository) error {
+		locked, err := txRepository.GetGroupSessionByIDForUpdate(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+
+		if locked.Status != GroupSessionOpen || !time.Now().Before(locked.ExpiresAt) {
+			return ErrGroupSessionClosed
+		}
+		if locked.MaxQuantity != nil && locked.JoinedQuantity+payload.Quantity > *locked.MaxQuantity {
+			return fmt.Errorf("%w: %d of %d left", ErrGroupSessionFull, *locked.MaxQuantity-locked.JoinedQuantity, *locked.MaxQuantity)
+		}
+
+		return txRepository.AddGroupSessionQuantity(ctx, locked.ID, payload.Quantity, 0)
+	})
+	if err != nil {
+		service.releaseStock(ctx, orders, "checkout_failed")
+		return types.CheckoutResponse{}, err
+	}
+
+	return service.createdCheckoutResponse(orders), nil
+}
+
+// marks one batch of open sessions whose paid quantity reached the minimum as filled, returns how many
+func (service *OrderService) FillGroupSessions(ctx context.Context, now time.Time, batchSize int) (int, error) {
+	var filled int
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		sessions, err := txRepository.GetFillableGroupSessions(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range sessions {
+			sessions[i].Status = GroupSessionFilled
+			sessions[i].FilledAt = &now
+			sessions[i].UpdatedAt = now
+			if err := txRepository.UpdateGroupSessionStatus(ctx, &sessions[i]); err != nil {
+				return err
+			}
+		}
+
+		filled = len(sessions)
+		return nil
+	})
+
+	return filled, err
+}
+
+// marks one batch of open sessions past their deadline as expired, returns how many.
+// sessions that filled in time are taken by FillGroupSessions first
+func (service *OrderService) ExpireGroupSessions(ctx context.Context, now time.Time, batchSize int) (int, error) {
+	var expired int
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		sessions, err := txRepository.GetExpiredGroupSessions(ctx, now, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range sessions {
+			sessions[i].Status = GroupSessionExpired
+			sessions[i].ExpiredAt = &now
+			sessions[i].UpdatedAt = now
+			if err := txRepository.UpdateGroupSessionStatus(ctx, &sessions[i]); err != nil {
+				return err
+			}
+		}
+
+		expired = len(sessions)
+		return nil
+	})
+
+	return expired, err
+}
+
+// confirms one batch of paid orders held by filled sessions, returns how many were confirmed
+func (service *OrderService) ConfirmFilledGroupOrders(ctx context.Context, batchSize int) (int, error) {
+	return service.settleGroupOrders(ctx, GroupSessionFilled, []string{statemachine.StatusPaid}, batchSize, statusChange{
+		to:            statemachine.StatusConfirmed,
+		reason:        "group session filled",
+		changedByType: ActorSystem,
+	})
+}
+
+// cancels one batch of orders of expired sessions, paid ones go out with refundRequired on order.cancelled
+func (service *OrderService) CancelExpiredGroupOrders(ctx context.Context, batchSize int) (int, error) {
+	return service.settleGroupOrders(ctx, GroupSessionExpired, []string{
+		statemachine.StatusPending,
+		statemachine.StatusAwaitingPayment,
+		statemachine.StatusPaid,
+	}, batchSize, statusChange{
+		to:            statemachine.StatusCancelled,
+		reason:        "group session expired",
+		changedByType: ActorSystem,
+	})
+}
+
+func (service *OrderService) settleGroupOrders(ctx context.Context, sessionStatus string, statuses []string, batchSize int, change statusChange) (int, error) {
+	var settled int
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		orders, err := txRepository.GetGroupOrders(ctx, sessionStatus, statuses, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for i := range orders {
+			if err := service.transitionOrder(ctx, txRepository, &orders[i], change); err != nil {
+				return err
+			}
+		}
+
+		settled = len(orders)
+		return nil
+	})
+
+	return settled, err
+}
+
+// counts a paid group order towards its session, an order paid after the session filled is confirmed right away
+func (service *OrderService) groupOrderPaid(ctx context.Context, txRepository *repository.OrderRepository, order *models.Order) error {
+	quantity, err := orderQuantity(ctx, txRepository, order.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := txRepository.AddGroupSessionQuantity(ctx, *order.GroupSessionID, 0, quantity); err != nil {
+		return err
+	}
+
+	session, err := txRepository.GetGroupSessionByID(ctx, *order.GroupSessionID)
+	if err != nil {
+		return err
+	}
+	if session.Status != GroupSessionFilled {
+		return nil
+	}
+
+	return service.transitionOrder(ctx, txRepository, order, statusChange{
+		to:            statemachine.StatusConfirmed,
+		reason:        "group session filled",
+		changedByType: ActorSystem,
+	})
+}
+
+func checkGroupSessionFilled(ctx context.Context, txRepository *repository.OrderRepository, sessionId string) error {
+	session, err := txRepository.GetGroupSessionByID(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+
+	if session.Status != GroupSessionFilled {
+		return ErrGroupSessionNotFilled
+	}
+	return nil
+}
+
+// takes a cancelled order's units off its session, fromStatus tells whether they had been paid for
+func releaseGroupQuantity(ctx context.Context, txRepository *repository.OrderRepository, order *models.Order, fromStatus string) error {
+	quantity, err := orderQuantity(ctx, txRepository, order.ID)
+	if err != nil {
+		return err
+	}
+
+	paid := 0
+	if !statemachine.IsUnpaid(fromStatus) {
+		paid = quantity
+	}
+
+	return txRepository.AddGroupSessionQuantity(ctx, *order.GroupSessionID, -quantity, -paid)
+}
+
+func orderQuantity(ctx context.Context, txRepository *repository.OrderRepository, orderId string) (int, error) {
+	orderItems, err := txRepository.GetOrderItems(ctx, orderId)
+	if err != nil {
+		return 0, err
+	}
+
+	quantity := 0
+	for _, item := range orderItems {
+		quantity += item.Quantity
+	}
+	return quantity, nil
+}
@@ -0,0 +1,190 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/shopspring/decimal"
+)
+
+func openGroupSession(t *testing.T, f fixture, minQuantity int, maxQuantity int) models.GroupSession {
+	t.Helper()
+
+	unitPrice := decimal.NewFromInt(120000)
+	session, err := f.service.CreateGroupSession(context.Background(), types.CreateGroupSessionPayload{
+		ProductID:   testProductID,
+		Title:       "Kemeja Batik bareng",
+		UnitPrice:   &unitPrice,
+		MinQuantity: minQuantity,
+		MaxQuantity: &maxQuantity,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}, testAdminID)
+	if err != nil {
+		t.Fatalf("CreateGroupSession: %v", err)
+	}
+	return session
+}
+
+func joinGroupSession(t *testing.T, f fixture, sessionId string, quantity int) models.Order {
+	t.Helper()
+
+	order := checkoutPayload(quantity)
+	checkout, err := f.service.JoinGroupSession(context.Background(), sessionId, types.JoinGroupSessionPayload{
+		UserID:          order.UserID,
+		Quantity:        quantity,
+		ShippingAddress: order.ShippingAddress,
+	})
+	if err != nil {
+		t.Fatalf("JoinGroupSession: %v", err)
+	}
+	return readOrder(t, f, checkout.Orders[0].ID)
+}
+
+func readOrder(t *testing.T, f fixture, orderId string) models.Order {
+	t.Helper()
+
+	var order models.Order
+	if err := f.db.Preload("OrderItems").First(&order, "id = ?", orderId).Error; err != nil {
+		t.Fatalf("read order: %v", err)
+	}
+	return order
+}
+
+func readGroupSession(t *testing.T, f fixture, sessionId string) models.GroupSession {
+	t.Helper()
+
+	session, err := f.service.GetGroupSession(context.Background(), sessionId)
+	if err != nil {
+		t.Fatalf("GetGroupSession: %v", err)
+	}
+	return session
+}
+
+// paid orders wait in paid until the session fills, the one paid after that is confirmed right away
+func TestGroupSessionFillsAndConfirmsItsOrders(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+	session := openGroupSession(t, f, 2, 3)
+
+	first := joinGroupSession(t, f, session.ID, 1)
+	second := joinGroupSession(t, f, session.ID, 1)
+	late := joinGroupSession(t, f, session.ID, 1)
+	if !first.OrderItems[0].UnitPrice.Equal(session.UnitPrice) {
+		t.Fatalf("unit price = %s, want the session price %s", first.OrderItems[0].UnitPrice, session.UnitPrice)
+	}
+
+	if err := f.service.MarkOrderPaid(ctx, first.ID, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+	if filled, err := f.service.FillGroupSessions(ctx, time.Now(), 10); err != nil || filled != 0 {
+		t.Fatalf("FillGroupSessions with 1 of 2 paid = %d, %v, want 0", filled, err)
+	}
+
+	if err := f.service.MarkOrderPaid(ctx, second.ID, "pay-2"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+	if filled, err := f.service.FillGroupSessions(ctx, time.Now(), 10); err != nil || filled != 1 {
+		t.Fatalf("FillGroupSessions = %d, %v, want 1", filled, err)
+	}
+	if filledSession := readGroupSession(t, f, session.ID); filledSession.Status != service.GroupSessionFilled || filledSession.FilledAt == nil {
+		t.Fatalf("session = %+v, want filled", filledSession)
+	}
+
+	if confirmed, err := f.service.ConfirmFilledGroupOrders(ctx, 10); err != nil || confirmed != 2 {
+		t.Fatalf("ConfirmFilledGroupOrders = %d, %v, want 2", confirmed, err)
+	}
+	for _, order := range []models.Order{first, second} {
+		if status := readOrder(t, f, order.ID).Status; status != statemachine.StatusConfirmed {
+			t.Fatalf("order %s is %s, want %s", order.ID, status, statemachine.StatusConfirmed)
+		}
+	}
+
+	if err := f.service.MarkOrderPaid(ctx, late.ID, "pay-3"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+	if status := readOrder(t, f, late.ID).Status; status != statemachine.StatusConfirmed {
+		t.Fatalf("order paid after filling is %s, want %s", status, statemachine.StatusConfirmed)
+	}
+
+	final := readGroupSession(t, f, session.ID)
+	if final.JoinedQuantity != 3 || final.PaidQuantity != 3 {
+		t.Fatalf("session joined %d and paid %d, want 3 and 3", final.JoinedQuantity, final.PaidQuantity)
+	}
+
+	_, err := f.service.JoinGroupSession(ctx, session.ID, types.JoinGroupSessionPayload{UserID: testUserID, Quantity: 1, ShippingAddress: checkoutPayload(1).ShippingAddress})
+	if !errors.Is(err, service.ErrGroupSessionClosed) {
+		t.Fatalf("JoinGroupSession after filling error = %v, want %v", err, service.ErrGroupSessionClosed)
+	}
+}
+
+func TestJoinGroupSessionStopsAtMaxQuantity(t *testing.T) {
+	f := newFixture(t, 10)
+	session := openGroupSession(t, f, 2, 2)
+
+	joinGroupSession(t, f, session.ID, 2)
+
+	_, err := f.service.JoinGroupSession(context.Background(), session.ID, types.JoinGroupSessionPayload{UserID: testUserID, Quantity: 1, ShippingAddress: checkoutPayload(1).ShippingAddress})
+	if !errors.Is(err, service.ErrGroupSessionFull) {
+		t.Fatalf("JoinGroupSession error = %v, want %v", err, service.ErrGroupSessionFull)
+	}
+	//the rejected join gave its stock back
+	if available := f.warehouse.Available(testProductID); available != 8 {
+		t.Fatalf("warehouse has %d units left, want 8", available)
+	}
+}
+
+// a session that misses its minimum cancels its orders, the paid ones ask for a refund
+func TestExpiredGroupSessionCancelsItsOrders(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+	session := openGroupSession(t, f, 3, 5)
+
+	paid := joinGroupSession(t, f, session.ID, 1)
+	unpaid := joinGroupSession(t, f, session.ID, 1)
+	if err := f.service.MarkOrderPaid(ctx, paid.ID, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+
+	if expired, err := f.service.ExpireGroupSessions(ctx, time.Now(), 10); err != nil || expired != 0 {
+		t.Fatalf("ExpireGroupSessions before the deadline = %d, %v, want 0", expired, err)
+	}
+	if expired, err := f.service.ExpireGroupSessions(ctx, session.ExpiresAt.Add(time.Minute), 10); err != nil || expired != 1 {
+		t.Fatalf("ExpireGroupSessions = %d, %v, want 1", expired, err)
+	}
+
+	if cancelled, err := f.service.CancelExpiredGroupOrders(ctx, 10); err != nil || cancelled != 2 {
+		t.Fatalf("CancelExpiredGroupOrders = %d, %v, want 2", cancelled, err)
+	}
+
+	for _, tt := range []struct {
+		order  models.Order
+		refund bool
+	}{{order: paid, refund: true}, {order: unpaid, refund: false}} {
+		if status := readOrder(t, f, tt.order.ID).Status; status != statemachine.StatusCancelled {
+			t.Fatalf("order %s is %s, want %s", tt.order.ID, status, statemachine.StatusCancelled)
+		}
+
+		rows := outboxEvents(t, f.db, tt.order.ID)
+		last := rows[len(rows)-1]
+		envelope, err := last.Envelope("order-service")
+		if err != nil {
+			t.Fatalf("Envelope: %v", err)
+		}
+		var payload kafka.OrderCancelled
+		if envelope.Type != kafka.EventOrderCancelled || envelope.DecodePayload(&payload) != nil || payload.RefundRequired != tt.refund {
+			t.Fatalf("last outbox event = %s %s, want %s with refundRequired %v", envelope.Type, envelope.Payload, kafka.EventOrderCancelled, tt.refund)
+		}
+	}
+
+	expired := readGroupSession(t, f, session.ID)
+	if expired.Status != service.GroupSessionExpired || expired.JoinedQuantity != 0 || expired.PaidQuantity != 0 {
+		t.Fatalf("session = %+v, want expired with nothing joined or paid", expired)
+	}
+}
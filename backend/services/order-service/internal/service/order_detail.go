@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"gorm.io/gorm"
+)
+
+// customers only see their own orders, admins and internal callers see any
+func (service *OrderService) GetOrderDetail(ctx context.Context, orderId string, viewerId string, viewerType string) (types.OrderDetailResponse, error) {
+	order, err := service.orderRepository.GetOrderDetailByID(ctx, orderId)
+	return service.orderDetail(ctx, order, err, viewerId, viewerType)
+}
+
+func (service *OrderService) GetOrderDetailByNumber(ctx context.Context, orderNumber string, viewerId string, viewerType string) (types.OrderDetailResponse, error) {
+	order, err := service.orderRepository.GetOrderDetailByNumber(ctx, orderNumber)
+	return service.orderDetail(ctx, order, err, viewerId, viewerType)
+}
+
+func (service *OrderService) orderDetail(ctx context.Context, order models.Order, err error, viewerId string, viewerType string) (types.OrderDetailResponse, error) {
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.OrderDetailResponse{}, ErrOrderNotFound
+		}
+		return types.OrderDetailResponse{}, err
+	}
+
+	if viewerType == ActorCustomer && order.UserID != viewerId {
+		return types.OrderDetailResponse{}, ErrNotOrderOwner
+	}
+
+	history, err := service.orderRepository.GetStatusHistory(ctx, order.ID)
+	if err != nil {
+		return types.OrderDetailResponse{}, err
+	}
+
+	detail := types.OrderDetailResponse{
+		OrderResponse: service.parseToOrderResponse([]models.Order{order})[0],
+		StatusHistory: make([]types.OrderStatusHistoryResponse, 0, len(history)),
+	}
+	for _, entry := range history {
+		detail.StatusHistory = append(detail.StatusHistory, types.OrderStatusHistoryResponse{
+			ID:            entry.ID,
+			FromStatus:    entry.FromStatus,
+			ToStatus:      entry.ToStatus,
+			Reason:        entry.Reason,
+			Notes:         entry.Notes,
+			ChangedBy:     entry.ChangedBy,
+			ChangedByType: entry.ChangedByType,
+			CreatedAt:     entry.CreatedAt,
+		})
+	}
+
+	return detail, nil
+}
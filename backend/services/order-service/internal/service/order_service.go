@@ -2,65 +2,495 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/config"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/pricing"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
 
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
 	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
-	"github.com/Flow-Indo/LAKOO/backend/shared/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
 	sharedTypes "github.com/Flow-Indo/LAKOO/backend/shared/types"
+	"gorm.io/gorm"
 )
 
+const (
+	maxOrderNumberAttempts = 5
+	defaultPageLimit       = 20
+)
+
+var (
+	ErrOrderNotFound        = errors.New("order not found")
+	ErrOrderNumberExhausted = errors.New("could not generate a unique order number")
+	ErrNotOrderOwner        = errors.New("order belongs to another user")
+	ErrIdempotencyKeyInUse  = errors.New("idempotency key already used for another order")
+)
+
+// sort_by values the list accepts and the order column each one sorts by
+var sortColumns = map[string]string{
+	"created_at":   "createdAt",
+	"updated_at":   "updatedAt",
+	"total_amount": "totalAmount",
+	"order_number": "orderNumber",
+	"status":       "status",
+}
+
+// who changed an order, stored as changedByType on the history and cancelledBy on the order
+const (
+	ActorCustomer = "customer"
+	ActorAdmin    = "admin"
+	ActorSystem   = "system"
+)
+
+// where an order came from, seller and brand orders carry SellerID or BrandID
+const (
+	OrderSourceBrand        = "brand"
+	OrderSourceSeller       = "seller"
+	OrderSourceLiveCommerce = "live_commerce"
+)
+
+type statusChange struct {
+	to            string
+	reason        string
+	notes         string
+	changedBy     string
+	changedByType string
+}
+
+// events are never published from here, they are written to the outbox and relayed to kafka after commit
 type OrderService struct {
 	orderRepository *repository.OrderRepository
-	producer        *kafka.KafkaProducer
+	products        clients.ProductClient
+	warehouse       clients.WarehouseClient
+	rates           clients.RateClient
+	pricing         *pricing.Pipeline
+	shippingOrigin  string        //postal code parcels are quoted from
+	paymentWindow   time.Duration //how long an order may stay unpaid before the system cancels it
+	returnWindow    time.Duration //how long after delivery a return can be requested
 }
 
-func NewService(orderRepository *repository.OrderRepository) *OrderService {
+func NewService(orderRepository *repository.OrderRepository, products clients.ProductClient, warehouse clients.WarehouseClient, rates clients.RateClient) *OrderService {
 	return &OrderService{
 		orderRepository: orderRepository,
-		producer: kafka.NewProducer(
-			[]string{"localhost:9092", "localhost:9093"},
-			"order_event",
-		),
+		products:        products,
+		warehouse:       warehouse,
+		rates:           rates,
+		pricing:         pricing.DefaultPipeline(pricing.DefaultPPN()),
+		shippingOrigin:  config.Envs.SHIPPING_ORIGIN_POSTAL_CODE,
+		paymentWindow:   config.Envs.ORDER_PAYMENT_WINDOW,
+		returnWindow:    config.Envs.ORDER_RETURN_WINDOW,
+	}
+}
+
+func (service *OrderService) GetOrders(ctx context.Context, payload types.OrderFilterPayload) (types.PaginatedOrdersResponse, error) {
+	filter, err := toOrderFilter(payload)
+	if err != nil {
+		return types.PaginatedOrdersResponse{}, err
+	}
+
+	orders, total, err := service.orderRepository.GetOrders(ctx, filter)
+	if err != nil {
+		return types.PaginatedOrdersResponse{}, err
+	}
+
+	page := filter.Offset/filter.Limit + 1
+	return types.PaginatedOrdersResponse{
+		Data: service.parseToOrderResponse(orders),
+		Pagination: types.Pagination{
+			Page:       page,
+			Limit:      filter.Limit,
+			Total:      int(total),
+			TotalPages: int((total + int64(filter.Limit) - 1) / int64(filter.Limit)),
+		},
+	}, nil
+}
+
+// keyset mode for deep paging and exports, the cursor pins created_at and id so sort_by other than created_at is rejected
+func (service *OrderService) GetOrdersByCursor(ctx context.Context, payload types.OrderFilterPayload) (types.CursorOrdersResponse, error) {
+	if payload.SortBy != "" && payload.SortBy != "created_at" {
+		return types.CursorOrdersResponse{}, fmt.Errorf("%w: cursor pagination only sorts by created_at", ErrInvalidCursor)
+	}
+
+	filter, err := toOrderFilter(payload)
+	if err != nil {
+		return types.CursorOrdersResponse{}, err
+	}
+
+	after, err := decodeCursor(*payload.Cursor)
+	if err != nil {
+		return types.CursorOrdersResponse{}, err
+	}
+
+	//one extra row tells whether another page exists without counting
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	orders, err := service.orderRepository.GetOrdersAfter(ctx, filter, after)
+	if err != nil {
+		return types.CursorOrdersResponse{}, err
+	}
+
+	var nextCursor *string
+	if len(orders) > limit {
+		orders = orders[:limit]
+		cursor := encodeCursor(orders[limit-1])
+		nextCursor = &cursor
 	}
+
+	return types.CursorOrdersResponse{
+		Data: service.parseToOrderResponse(orders),
+		Pagination: types.CursorPagination{
+			Limit:      limit,
+			NextCursor: nextCursor,
+		},
+	}, nil
 }
 
-func (service *OrderService) GetOrders(filterPaylod types.OrderFilterPayload) ([]types.OrderResponse, error) {
-	jsonPayload, err := utils.PayloadToMap(filterPaylod)
+func toOrderFilter(payload types.OrderFilterPayload) (repository.OrderFilter, error) {
+	filter := repository.OrderFilter{
+		UserID:        payload.UserID,
+		FactoryID:     payload.FactoryID,
+		IsGroupBuying: payload.IsGroupBuying,
+		Search:        strings.TrimSpace(payload.Search),
+		From:          payload.StartDate,
+		SortColumn:    sortColumns["created_at"],
+		SortDesc:      true,
+		Limit:         defaultPageLimit,
+	}
+
+	for _, value := range payload.Status {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if !statemachine.IsKnownStatus(status) {
+				return repository.OrderFilter{}, fmt.Errorf("%w: %s", statemachine.ErrUnknownStatus, status)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	//a date without a time covers the whole day
+	if payload.EndDate != nil {
+		to := *payload.EndDate
+		if to.Equal(to.Truncate(24 * time.Hour)) {
+			to = to.Add(24 * time.Hour)
+		}
+		filter.To = &to
+	}
+
+	if column, ok := sortColumns[payload.SortBy]; ok {
+		filter.SortColumn = column
+	}
+	if payload.SortOrder != "" {
+		filter.SortDesc = payload.SortOrder == "desc"
+	}
+
+	if payload.Limit > 0 {
+		filter.Limit = payload.Limit
+	}
+	if payload.Page > 1 {
+		filter.Offset = (payload.Page - 1) * filter.Limit
+	}
+
+	return filter, nil
+}
+
+// a checkout becomes one order per seller or brand, the response carries the whole group so payment charges once
+func (service *OrderService) CreateOrder(createOrderPayload types.CreateOrderPayload, ctx context.Context) (types.CheckoutResponse, error) {
+	if checkout, replayed, err := service.replayCheckout(ctx, createOrderPayload.IdempotencyKey, createOrderPayload.UserID); replayed || err != nil {
+		return checkout, err
+	}
+
+	orders, err := service.buildCheckout(ctx, createOrderPayload)
 	if err != nil {
-		return []types.OrderResponse{}, err
+		return types.CheckoutResponse{}, err
+	}
+
+	if err := service.createCheckout(ctx, orders, createOrderPayload.CouponCode, nil); err != nil {
+		service.releaseStock(ctx, orders, "checkout_failed")
+		return types.CheckoutResponse{}, err
+	}
+
+	return service.createdCheckoutResponse(orders), nil
+}
+
+// the middleware replays stored responses, this covers retries that arrive after the stored response expired
+func (service *OrderService) replayCheckout(ctx context.Context, idempotencyKey string, userId string) (types.CheckoutResponse, bool, error) {
+	if idempotencyKey == "" {
+		return types.CheckoutResponse{}, false, nil
 	}
 
-	orders, err := service.orderRepository.GetOrders(jsonPayload)
+	existing, err := service.orderRepository.GetOrderByIdempotencyKey(ctx, idempotencyKey)
 	if err != nil {
-		return []types.OrderResponse{}, err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.CheckoutResponse{}, false, nil
+		}
+		return types.CheckoutResponse{}, false, err
+	}
+
+	if existing.UserID != userId {
+		return types.CheckoutResponse{}, false, ErrIdempotencyKeyInUse
 	}
 
-	return service.parseToOrderResponse(orders), nil
+	checkout, err := service.getCheckout(ctx, existing)
+	return checkout, true, err
+}
+
+func (service *OrderService) createdCheckoutResponse(orders []*models.Order) types.CheckoutResponse {
+	created := make([]models.Order, len(orders))
+	for i, order := range orders {
+		created[i] = *order
+	}
+	return service.parseToCheckoutResponse(*orders[0].CheckoutID, created)
 }
 
-func (service *OrderService) CreateOrder(createOrderPayload types.CreateOrderPayload, ctx context.Context) error {
-	jsonPayload, err := utils.PayloadToMap(createOrderPayload)
+// actorType is the authenticated caller's role, never taken from the request body
+func (service *OrderService) UpdateOrderStatus(ctx context.Context, orderId string, payload types.UpdateOrderStatusPayload, actorId string, actorType string) (types.OrderResponse, error) {
+	var order models.Order
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		var err error
+		order, err = txRepository.GetOrderByIDForUpdate(ctx, orderId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		return service.transitionOrder(ctx, txRepository, &order, statusChange{
+			to:            payload.Status,
+			reason:        payload.Reason,
+			notes:         payload.Notes,
+			changedBy:     actorId,
+			changedByType: actorType,
+		})
+	})
 	if err != nil {
+		return types.OrderResponse{}, err
+	}
+
+	return service.parseToOrderResponse([]models.Order{order})[0], nil
+}
+
+// applies a payment confirmation, safe to call again for an order that already moved past payment
+func (service *OrderService) MarkOrderPaid(ctx context.Context, orderId string, paymentId string) error {
+	return service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		order, err := txRepository.GetOrderByIDForUpdate(ctx, orderId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		if !statemachine.CanTransition(order.Status, statemachine.StatusPaid) {
+			log.Printf("Ignoring payment %s for order %s in status %s", paymentId, order.ID, order.Status)
+			return nil
+		}
+
+		if err := service.transitionOrder(ctx, txRepository, &order, statusChange{
+			to:            statemachine.StatusPaid,
+			reason:        "payment " + paymentId + " succeeded",
+			changedByType: ActorSystem,
+		}); err != nil {
+			return err
+		}
+
+		if order.GroupSessionID != nil {
+			return service.groupOrderPaid(ctx, txRepository, &order)
+		}
+		return nil
+	})
+}
+
+// must run inside a transaction that holds the order row lock, the status and its history row are written together
+func (service *OrderService) transitionOrder(ctx context.Context, txRepository *repository.OrderRepository, order *models.Order, change statusChange) error {
+	fromStatus := order.Status
+	changedAt := time.Now()
+
+	//group orders are held in paid until their session fills
+	if change.to == statemachine.StatusConfirmed && order.GroupSessionID != nil {
+		if err := checkGroupSessionFilled(ctx, txRepository, *order.GroupSessionID); err != nil {
+			return err
+		}
+	}
+
+	if err := statemachine.Transition(order, change.to, changedAt); err != nil {
 		return err
 	}
 
-	if err := service.orderRepository.CreateOrder(jsonPayload); err != nil {
+	if order.Status == statemachine.StatusCancelled {
+		order.CancelReason = optionalString(change.reason)
+		order.CancelledBy = optionalString(change.changedByType)
+	}
+
+	if err := txRepository.UpdateOrderStatus(ctx, order); err != nil {
+		return err
+	}
+
+	if err := txRepository.CreateStatusHistory(ctx, &models.OrderStatusHistory{
+		OrderID:       order.ID,
+		FromStatus:    &fromStatus,
+		ToStatus:      order.Status,
+		Reason:        optionalString(change.reason),
+		Notes:         optionalString(change.notes),
+		ChangedBy:     optionalString(change.changedBy),
+		ChangedByType: optionalString(change.changedByType),
+	}); err != nil {
+		return err
+	}
+
+	if err := txRepository.CreateOutboxEvent(ctx, orderStatusChangedEvent(*order, kafka.OrderStatusChanged{
+		OrderID:       order.ID,
+		OrderNumber:   order.OrderNumber,
+		UserID:        order.UserID,
+		FromStatus:    fromStatus,
+		ToStatus:      order.Status,
+		Reason:        change.reason,
+		ChangedByType: change.changedByType,
+		ChangedAt:     changedAt,
+	})); err != nil {
+		return err
+	}
+
+	//a cancelled order gives its coupon redemption back, a split checkout once all of its orders are cancelled
+	if order.Status == statemachine.StatusCancelled && order.CouponID != nil {
+		var err error
+		if order.CheckoutID != nil {
+			_, err = txRepository.ReleaseCheckoutCouponUsage(ctx, *order.CheckoutID)
+		} else {
+			_, err = txRepository.ReleaseCouponUsage(ctx, order.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	//a cancelled group order leaves its session, paid units stop counting towards the minimum
+	if order.Status == statemachine.StatusCancelled && order.GroupSessionID != nil {
+		if err := releaseGroupQuantity(ctx, txRepository, order, fromStatus); err != nil {
+			return err
+		}
+	}
+
+	//every way into cancelled or completed emits its own event as well, including a plain status update
+	if order.Status != statemachine.StatusCancelled && order.Status != statemachine.StatusCompleted {
+		return nil
+	}
+
+	orderItems, err := txRepository.GetOrderItems(ctx, order.ID)
+	if err != nil {
 		return err
 	}
 
-	if err := service.producer.PublishMessage(ctx, []byte("testing"), []byte("Created Order")); err != nil {
-		log.Printf("Could not publish message in Create Order, %v", err)
+	if order.Status == statemachine.StatusCompleted {
+		return txRepository.CreateOutboxEvent(ctx, orderCompletedEvent(*order, change.changedByType, orderItems))
 	}
+	return txRepository.CreateOutboxEvent(ctx, orderCancelledEvent(*order, fromStatus, orderItems))
+}
 
-	return nil
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// 31^5 suffixes per day makes a collision rare, a few retries make it practically impossible.
+// all orders of the checkout and the coupon redemption commit together, a failed insert gives the redemption back.
+// prepare runs first in the same transaction, whatever it locks stays locked until the orders are written
+func (service *OrderService) createCheckout(ctx context.Context, orders []*models.Order, couponCode string, prepare func(txRepository *repository.OrderRepository) error) error {
+	for attempt := 0; attempt < maxOrderNumberAttempts; attempt++ {
+		for _, order := range orders {
+			orderNumber, err := utils.GenerateOrderNumber(time.Now())
+			if err != nil {
+				return err
+			}
+			order.OrderNumber = orderNumber
+		}
+
+		err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+			if prepare != nil {
+				if err := prepare(txRepository); err != nil {
+					return err
+				}
+			}
+
+			var usage *models.CouponUsage
+			if couponCode != "" {
+				var err error
+				if usage, err = service.redeemCoupon(ctx, txRepository, orders, couponCode); err != nil {
+					return err
+				}
+			}
+
+			for _, order := range orders {
+				if err := txRepository.CreateOrder(ctx, order); err != nil {
+					return err
+				}
+
+				if err := txRepository.CreateOutboxEvent(ctx, orderCreatedEvent(*order)); err != nil {
+					return err
+				}
+			}
+
+			//one redemption per checkout, recorded against its first order
+			if usage != nil {
+				usage.OrderID = orders[0].ID
+				return txRepository.CreateCouponUsage(ctx, usage)
+			}
+			return nil
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return err
+		}
+
+		//the unique idempotency key lost a race against a concurrent request with the same key, retrying can't help
+		if key := orders[0].IdempotencyKey; key != nil {
+			if _, err := service.orderRepository.GetOrderByIdempotencyKey(ctx, *key); err == nil {
+				return ErrIdempotencyKeyInUse
+			}
+		}
+
+		log.Printf("Order number already taken in checkout %s, retrying", *orders[0].CheckoutID)
+	}
+
+	return ErrOrderNumberExhausted
+}
+
+func productIDs(items []types.OrderItemPayload) []string {
+	productIds := make([]string, 0, len(items))
+	for _, item := range items {
+		productIds = append(productIds, item.ProductID)
+	}
+	return productIds
+}
+
+func (service *OrderService) productsByID(ctx context.Context, txRepository *repository.OrderRepository, productIds []string) (map[string]models.Product, error) {
+	products, err := txRepository.GetProductsByIDs(ctx, productIds)
+	if err != nil {
+		return nil, err
+	}
+
+	productsById := make(map[string]models.Product, len(products))
+	for _, product := range products {
+		productsById[product.ID] = product
+	}
+	return productsById, nil
 }
 
 func (service *OrderService) parseToOrderResponse(orders []models.Order) []types.OrderResponse {
-	var orderResponses []types.OrderResponse
+	orderResponses := make([]types.OrderResponse, 0, len(orders))
 
 	for _, order := range orders {
 		orderResponses = append(orderResponses, types.OrderResponse{
@@ -68,12 +498,19 @@ func (service *OrderService) parseToOrderResponse(orders []models.Order) []types
 			OrderNumber:           order.OrderNumber,
 			UserID:                order.UserID,
 			GroupSessionID:        order.GroupSessionID,
+			CheckoutID:            order.CheckoutID,
+			OrderSource:           order.OrderSource,
+			BrandID:               order.BrandID,
+			SellerID:              order.SellerID,
 			Status:                order.Status,
 			Subtotal:              order.Subtotal,
 			ShippingCost:          order.ShippingCost,
 			TaxAmount:             order.TaxAmount,
 			DiscountAmount:        order.DiscountAmount,
+			CouponCode:            order.CouponCode,
 			TotalAmount:           order.TotalAmount,
+			ShippingCourier:       order.ShippingCourier,
+			ShippingMethod:        order.ShippingMethod,
 			ShippingName:          order.ShippingName,
 			ShippingPhone:         order.ShippingPhone,
 			ShippingProvince:      order.ShippingProvince,
@@ -86,9 +523,13 @@ func (service *OrderService) parseToOrderResponse(orders []models.Order) []types
 			PaidAt:                order.PaidAt,
 			ShippedAt:             order.ShippedAt,
 			DeliveredAt:           order.DeliveredAt,
+			CompletedAt:           order.CompletedAt,
 			CancelledAt:           order.CancelledAt,
+			CancelReason:          order.CancelReason,
+			CancelledBy:           order.CancelledBy,
 			CreatedAt:             order.CreatedAt,
 			UpdatedAt:             order.UpdatedAt,
+			PriceBreakdown:        service.toPriceStepResponses(order.PriceBreakdown),
 			OrderItems:            service.toOrderItemResponses(order.OrderItems),
 			User:                  service.toUserResponse(order.User),
 		})
@@ -97,22 +538,37 @@ func (service *OrderService) parseToOrderResponse(orders []models.Order) []types
 	return orderResponses
 }
 
+func (service *OrderService) toPriceStepResponses(breakdown models.PriceBreakdown) []types.PriceStepResponse {
+	responses := make([]types.PriceStepResponse, len(breakdown))
+	for i, step := range breakdown {
+		responses[i] = types.PriceStepResponse{
+			Step:        step.Step,
+			Description: step.Description,
+			Amount:      step.Amount,
+			Total:       step.Total,
+		}
+	}
+	return responses
+}
+
 func (service *OrderService) toOrderItemResponses(orderItems []models.OrderItem) []types.OrderItemResponse {
 	responses := make([]types.OrderItemResponse, len(orderItems))
 	for i, item := range orderItems {
 		responses[i] = types.OrderItemResponse{
-			ID:          item.ID,
-			OrderID:     item.OrderID,
-			ProductID:   item.ProductID,
-			VariantID:   item.VariantID,
-			FactoryID:   item.FactoryID,
-			SKU:         item.SKU,
-			ProductName: item.ProductName,
-			VariantName: item.VariantName,
-			Quantity:    item.Quantity,
-			UnitPrice:   item.UnitPrice,
-			Subtotal:    item.Subtotal,
-			CreatedAt:   item.CreatedAt,
+			ID:               item.ID,
+			OrderID:          item.OrderID,
+			ProductID:        item.ProductID,
+			VariantID:        item.VariantID,
+			FactoryID:        item.FactoryID,
+			SKU:              item.SKU,
+			ProductName:      item.ProductName,
+			VariantName:      item.VariantName,
+			Quantity:         item.Quantity,
+			UnitPrice:        item.UnitPrice,
+			Subtotal:         item.Subtotal,
+			DiscountAmount:   item.DiscountAmount,
+			ReturnedQuantity: item.ReturnedQuantity,
+			CreatedAt:        item.CreatedAt,
 			Product: types.ProductResponse{
 				ID:              item.Product.ID,
 				Name:            item.Product.Name,
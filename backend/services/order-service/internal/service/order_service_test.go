@@ -0,0 +1,279 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients/clientstest"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka/kafkatest"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/outbox"
+	"github.com/shopspring/decimal"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	testUserID    = "6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"
+	testProductID = "2b7e4c1a-5d3f-4a8e-9b6c-7d8e9f0a1b2c"
+	testFactoryID = "9c8b7a6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d"
+	testBrandID   = "4d3c2b1a-0f9e-4d8c-8b7a-6f5e4d3c2b1a"
+)
+
+// the tables come from the prisma schema, the same one prisma db push applies to order_db
+const prismaSchema = "../../../../../order-service-schema.prisma"
+
+// the DDL prisma would run on an empty database, generated once per test binary. migrate diff only reads the
+// schema file, the datasource url just has to be set
+var prismaSchemaSQL = sync.OnceValues(func() ([]string, error) {
+	cmd := exec.Command("npx", "--yes", "prisma@5.22.0", "migrate", "diff",
+		"--from-empty", "--to-schema-datamodel", prismaSchema, "--script")
+	cmd.Env = append(os.Environ(), "ORDER_DATABASE_URL=postgresql://localhost/order_db")
+
+	script, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("prisma migrate diff: %w: %s", err, exitErr.Stderr)
+		}
+		return nil, fmt.Errorf("prisma migrate diff: %w", err)
+	}
+
+	var statements []string
+	for _, statement := range strings.Split(string(script), ";\n") {
+		if strings.TrimSpace(statement) != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements, nil
+})
+
+// every test runs in a transaction that is rolled back at the end, the service's own transactions become savepoints.
+// needs an empty postgres database and npx, e.g. ORDER_SERVICE_TEST_DSN="host=localhost user=postgres dbname=order_test".
+// in CI a missing dsn fails the run instead of quietly skipping every database test
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("ORDER_SERVICE_TEST_DSN")
+	if dsn == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("ORDER_SERVICE_TEST_DSN must be set in CI")
+		}
+		t.Skip("ORDER_SERVICE_TEST_DSN not set")
+	}
+
+	statements, err := prismaSchemaSQL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError:                           true,
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			t.Fatalf("apply prisma schema: %v\n%s", err, statement)
+		}
+	}
+
+	//the catalog and user tables predate the prisma schema and are still read by the service
+	if err := tx.AutoMigrate(&models.Product{}, &models.Factory{}, &models.User{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return tx
+}
+
+type fixture struct {
+	db        *gorm.DB
+	service   *service.OrderService
+	warehouse *clientstest.Warehouse
+}
+
+func newFixture(t *testing.T, stock int) fixture {
+	t.Helper()
+
+	db := testDB(t)
+	brandId := testBrandID
+	if err := db.Create(&models.Factory{ID: testFactoryID, FactoryName: "Pabrik Uji", City: "Bandung"}).Error; err != nil {
+		t.Fatalf("seed factory: %v", err)
+	}
+	if err := db.Create(&models.Product{
+		ID:        testProductID,
+		FactoryID: testFactoryID,
+		BrandID:   &brandId,
+		SKU:       "KMJ-001",
+		Name:      "Kemeja Batik",
+		BasePrice: decimal.NewFromInt(150000),
+	}).Error; err != nil {
+		t.Fatalf("seed product: %v", err)
+	}
+
+	products := clientstest.NewProducts(clients.Product{
+		ID:            testProductID,
+		ProductCode:   "KMJ-001",
+		Name:          "Kemeja Batik",
+		BaseSellPrice: decimal.NewFromInt(150000),
+		Status:        clients.ProductStatusApproved,
+	})
+	rates := &clientstest.Rates{Rates: []clients.Rate{
+		{Courier: "jne", ServiceCode: "REG", Rate: decimal.NewFromInt(18000)},
+	}}
+	warehouse := clientstest.NewWarehouse(map[string]int{testProductID: stock})
+
+	return fixture{
+		db:        db,
+		service:   service.NewService(repository.NewOrderRepository(db), products, warehouse, rates),
+		warehouse: warehouse,
+	}
+}
+
+func checkoutPayload(quantity int) types.CreateOrderPayload {
+	return types.CreateOrderPayload{
+		UserID: testUserID,
+		Items:  []types.OrderItemPayload{{ProductID: testProductID, Quantity: quantity}},
+		ShippingAddress: types.ShippingAddressPayload{
+			Name:       "Siti",
+			Phone:      "081234567890",
+			Address:    "Jl. Merdeka 1",
+			City:       "Bandung",
+			Province:   "Jawa Barat",
+			District:   "Sumur Bandung",
+			PostalCode: "40111",
+		},
+	}
+}
+
+func outboxEvents(t *testing.T, db *gorm.DB, aggregateId string) []outbox.ServiceOutbox {
+	t.Helper()
+
+	var rows []outbox.ServiceOutbox
+	if err := db.Where(`"aggregateId" = ?`, aggregateId).Order(`"createdAt"`).Find(&rows).Error; err != nil {
+		t.Fatalf("read outbox: %v", err)
+	}
+	return rows
+}
+
+func TestCreateOrderWritesOrderCreatedToOutbox(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	checkout, err := f.service.CreateOrder(checkoutPayload(2), ctx)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if len(checkout.Orders) != 1 {
+		t.Fatalf("got %d orders, want 1", len(checkout.Orders))
+	}
+	order := checkout.Orders[0]
+
+	rows := outboxEvents(t, f.db, order.ID)
+	if len(rows) != 1 || rows[0].EventType != kafka.EventOrderCreated {
+		t.Fatalf("outbox rows = %+v, want one %s", rows, kafka.EventOrderCreated)
+	}
+	if rows[0].IsPublished {
+		t.Fatalf("row is published before the relay ran")
+	}
+
+	if available := f.warehouse.Available(testProductID); available != 8 {
+		t.Fatalf("warehouse has %d units left, want 8", available)
+	}
+
+	//the relay takes the row from there, the in-memory broker stands in for kafka
+	broker := kafkatest.NewBroker(1)
+	relay := outbox.NewRelay(f.db, broker.NewProducer("order_event"), outbox.DefaultRelayConfig("order-service"))
+	if _, err := relay.RelayBatch(ctx); err != nil {
+		t.Fatalf("RelayBatch: %v", err)
+	}
+
+	var published *kafka.Envelope
+	for _, msg := range broker.Messages("order_event") {
+		envelope, err := kafka.DecodeEvent(msg.Value)
+		if err != nil {
+			t.Fatalf("decode published event: %v", err)
+		}
+		if envelope.AggregateID == order.ID {
+			published = &envelope
+		}
+	}
+	if published == nil || published.Type != kafka.EventOrderCreated || published.ID != rows[0].ID {
+		t.Fatalf("published envelope = %+v, want %s with id %s", published, kafka.EventOrderCreated, rows[0].ID)
+	}
+
+	if rows := outboxEvents(t, f.db, order.ID); !rows[0].IsPublished {
+		t.Fatalf("row not marked published after relaying")
+	}
+}
+
+func TestCreateOrderWithoutStockWritesNothing(t *testing.T) {
+	f := newFixture(t, 1)
+
+	_, err := f.service.CreateOrder(checkoutPayload(2), context.Background())
+
+	var unavailable *service.UnavailableItemsError
+	if !errors.As(err, &unavailable) || unavailable.Items[0].Reason != service.ItemInsufficientStock {
+		t.Fatalf("CreateOrder error = %v, want %s", err, service.ItemInsufficientStock)
+	}
+
+	var count int64
+	if err := f.db.Model(&models.Order{}).Where(`"userId" = ?`, testUserID).Count(&count).Error; err != nil {
+		t.Fatalf("count orders: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("%d orders written for a failed checkout", count)
+	}
+	if available := f.warehouse.Available(testProductID); available != 1 {
+		t.Fatalf("warehouse has %d units left, want the 1 it started with", available)
+	}
+}
+
+func TestMarkOrderPaidWritesStatusChangeOnce(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	checkout, err := f.service.CreateOrder(checkoutPayload(1), ctx)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderId := checkout.Orders[0].ID
+
+	//a redelivered payment event must not add a second transition
+	for i := 0; i < 2; i++ {
+		if err := f.service.MarkOrderPaid(ctx, orderId, "pay-1"); err != nil {
+			t.Fatalf("MarkOrderPaid #%d: %v", i+1, err)
+		}
+	}
+
+	var order models.Order
+	if err := f.db.First(&order, "id = ?", orderId).Error; err != nil {
+		t.Fatalf("read order: %v", err)
+	}
+	if order.Status != statemachine.StatusPaid {
+		t.Fatalf("status = %s, want %s", order.Status, statemachine.StatusPaid)
+	}
+
+	rows := outboxEvents(t, f.db, orderId)
+	if len(rows) != 2 || rows[1].EventType != kafka.EventOrderStatusChanged {
+		t.Fatalf("outbox rows = %+v, want order.created then one %s", rows, kafka.EventOrderStatusChanged)
+	}
+}
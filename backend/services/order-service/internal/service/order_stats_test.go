@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/shopspring/decimal"
+)
+
+// one paid order, one cancelled before payment and one delivered with one of its two units returned
+func TestGetOrderStatsCountsWhatWasPaidFor(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	paid, err := f.service.CreateOrder(checkoutPayload(1), ctx)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	paidOrder := paid.Orders[0]
+	if err := f.service.MarkOrderPaid(ctx, paidOrder.ID, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+
+	cancelled, err := f.service.CreateOrder(checkoutPayload(1), ctx)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	if _, err := f.service.CancelOrder(ctx, cancelled.Orders[0].ID, types.CancelOrderPayload{Reason: "changed my mind"}, testUserID, service.ActorCustomer); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+
+	returned := deliveredOrder(t, f, 2)
+	ret, err := f.service.RequestReturn(ctx, returned.ID, returnPayload(returned, 1), testUserID)
+	if err != nil {
+		t.Fatalf("RequestReturn: %v", err)
+	}
+	ret = completeReturn(t, f, ret)
+
+	var orders []models.Order
+	if err := f.db.Preload("OrderItems").Where("id IN ?", []string{paidOrder.ID, returned.ID}).Find(&orders).Error; err != nil {
+		t.Fatalf("read orders: %v", err)
+	}
+	gmv := decimal.Zero
+	revenue := decimal.Zero
+	for _, order := range orders {
+		gmv = gmv.Add(order.TotalAmount)
+		item := order.OrderItems[0]
+		kept := decimal.NewFromInt(int64(item.Quantity - item.ReturnedQuantity))
+		revenue = revenue.Add(item.Subtotal.Sub(item.DiscountAmount).Mul(kept).Div(decimal.NewFromInt(int64(item.Quantity))))
+	}
+	gmv = gmv.Sub(ret.TotalAmount)
+
+	now := time.Now()
+	start := now.Add(-time.Hour)
+	end := now.Add(time.Hour)
+	stats, err := f.service.GetOrderStats(ctx, types.OrderStatsPayload{StartDate: &start, EndDate: &end})
+	if err != nil {
+		t.Fatalf("GetOrderStats: %v", err)
+	}
+
+	summary := stats.Summary
+	if summary.TotalOrders != 3 || summary.CancelledOrders != 1 {
+		t.Fatalf("summary = %+v, want 3 orders with 1 cancelled", summary)
+	}
+	if !summary.GMV.Equal(gmv) {
+		t.Fatalf("GMV = %s, want %s without the refunded unit", summary.GMV, gmv)
+	}
+	if !summary.AOV.Equal(gmv.Div(decimal.NewFromInt(2)).Floor()) {
+		t.Fatalf("AOV = %s, want GMV over the 2 paid orders", summary.AOV)
+	}
+	if summary.CancellationRate < 0.33 || summary.CancellationRate > 0.34 {
+		t.Fatalf("cancellation rate = %f, want a third", summary.CancellationRate)
+	}
+
+	statuses := map[string]int64{}
+	for _, row := range stats.ByStatus {
+		statuses[row.Status] = row.Orders
+	}
+	if statuses[statemachine.StatusPaid] != 1 || statuses[statemachine.StatusCancelled] != 1 || statuses[statemachine.StatusPartiallyRefunded] != 1 {
+		t.Fatalf("by status = %+v", stats.ByStatus)
+	}
+
+	var bucketed int64
+	for _, bucket := range stats.Buckets {
+		bucketed += bucket.Orders
+	}
+	if bucketed != 3 {
+		t.Fatalf("buckets hold %d orders, want 3", bucketed)
+	}
+
+	if len(stats.TopProducts) != 1 {
+		t.Fatalf("top products = %+v, want the one product", stats.TopProducts)
+	}
+	product := stats.TopProducts[0]
+	if product.ProductID != testProductID || product.Quantity != 3 || product.Orders != 2 || !product.Revenue.Equal(revenue) {
+		t.Fatalf("top product = %+v, want 3 units over 2 orders for %s", product, revenue)
+	}
+
+	if len(stats.TopFactories) != 1 || stats.TopFactories[0].ID != testFactoryID || !stats.TopFactories[0].Revenue.Equal(revenue) {
+		t.Fatalf("top factories = %+v, want %s with %s", stats.TopFactories, testFactoryID, revenue)
+	}
+}
+
+// orders outside the range or of another source are left out of every number
+func TestGetOrderStatsFiltersTheRange(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	checkout, err := f.service.CreateOrder(checkoutPayload(1), ctx)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderId := checkout.Orders[0].ID
+	if err := f.service.MarkOrderPaid(ctx, orderId, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+
+	lastMonth := time.Now().AddDate(0, -1, 0)
+	if err := f.db.Model(&models.Order{}).Where("id = ?", orderId).Update("createdAt", lastMonth).Error; err != nil {
+		t.Fatalf("move createdAt: %v", err)
+	}
+
+	now := time.Now()
+	start := now.Add(-24 * time.Hour)
+	stats, err := f.service.GetOrderStats(ctx, types.OrderStatsPayload{StartDate: &start, EndDate: &now})
+	if err != nil {
+		t.Fatalf("GetOrderStats: %v", err)
+	}
+	if stats.Summary.TotalOrders != 0 || !stats.Summary.GMV.IsZero() || len(stats.TopProducts) != 0 {
+		t.Fatalf("stats = %+v, want nothing from last month", stats)
+	}
+
+	start = lastMonth.Add(-time.Hour)
+	stats, err = f.service.GetOrderStats(ctx, types.OrderStatsPayload{StartDate: &start, EndDate: &now, OrderSource: "live_commerce"})
+	if err != nil {
+		t.Fatalf("GetOrderStats: %v", err)
+	}
+	if stats.Summary.TotalOrders != 0 {
+		t.Fatalf("%d orders for live_commerce, want none", stats.Summary.TotalOrders)
+	}
+}
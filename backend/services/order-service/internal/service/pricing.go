@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/pricing"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// products without a weight in their snapshot are quoted as a kilogram, the smallest step couriers charge by
+const defaultItemWeightGrams = 1000
+
+var (
+	ErrShippingUnavailable = errors.New("logistics service unavailable")
+	ErrNoShippingRate      = errors.New("no courier delivers this order to the shipping address")
+	ErrInvalidShippingCost = errors.New("shipping cost must be a whole rupiah amount of zero or more")
+	ErrShippingCostLocked  = errors.New("shipping cost can only change before the order is paid")
+)
+
+// the courier and service the customer picked, empty fields leave the choice to the cheapest rate
+type shippingChoice struct {
+	courier string
+	service string
+}
+
+// what a redeemed coupon takes off one order of a checkout
+type appliedCoupon struct {
+	code             string
+	itemDiscount     decimal.Decimal
+	shippingDiscount decimal.Decimal
+}
+
+// the orders of a checkout leave the warehouse together and are quoted as one parcel, each order carries the share
+// of the cost its items weigh
+func (service *OrderService) quoteShipping(ctx context.Context, orders []*models.Order, choice shippingChoice) error {
+	weights := make([]decimal.Decimal, len(orders))
+	weight := 0
+	itemValue := decimal.Zero
+	for i, order := range orders {
+		grams := parcelWeight(order.OrderItems)
+		weights[i] = decimal.NewFromInt(int64(grams))
+		weight += grams
+		itemValue = itemValue.Add(order.Subtotal)
+	}
+
+	request := clients.RateRequest{
+		OriginPostalCode: service.shippingOrigin,
+		DestPostalCode:   orders[0].ShippingPostalCode,
+		WeightGrams:      weight,
+		ItemValue:        itemValue.IntPart(),
+	}
+	if choice.courier != "" {
+		request.Couriers = []string{choice.courier}
+	}
+
+	rates, err := service.rates.GetRates(ctx, request)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrShippingUnavailable, err)
+	}
+
+	rate, ok := pickRate(rates, choice)
+	if !ok {
+		return ErrNoShippingRate
+	}
+
+	//couriers may quote fractions after their own discounts, the orders charge whole rupiah
+	shares := allocateProportionally(rate.Rate.Ceil(), weights)
+	for i, order := range orders {
+		order.ShippingCost = shares[i]
+		order.ShippingCourier = &rate.Courier
+		order.ShippingMethod = &rate.ServiceCode
+	}
+	return nil
+}
+
+func parcelWeight(items []models.OrderItem) int {
+	weight := 0
+	for _, item := range items {
+		grams := utils.GetIntFromJSONB(item.ProductSnapshot, "product.weight_grams")
+		if grams <= 0 {
+			grams = defaultItemWeightGrams
+		}
+		weight += grams * item.Quantity
+	}
+	return weight
+}
+
+// the requested service when there is one, otherwise the cheapest rate
+func pickRate(rates []clients.Rate, choice shippingChoice) (clients.Rate, bool) {
+	var picked clients.Rate
+	found := false
+	for _, rate := range rates {
+		if choice.courier != "" && rate.Courier != choice.courier {
+			continue
+		}
+		if choice.service != "" {
+			if rate.ServiceCode == choice.service {
+				return rate, true
+			}
+			continue
+		}
+		if !found || rate.Rate.LessThan(picked.Rate) {
+			picked, found = rate, true
+		}
+	}
+	return picked, found
+}
+
+// runs the pricing pipeline over the order's items and shipping cost and stores the amounts and the breakdown.
+// item discounts already include the coupon's share, it is taken out again so the breakdown shows it on its own
+func (service *OrderService) priceOrder(order *models.Order, coupon appliedCoupon) {
+	itemDiscount := decimal.Zero
+	for i := range order.OrderItems {
+		item := &order.OrderItems[i]
+		item.TotalAmount = item.Subtotal.Sub(item.DiscountAmount)
+		itemDiscount = itemDiscount.Add(item.DiscountAmount)
+	}
+
+	result := service.pricing.Price(pricing.Input{
+		Subtotal:               order.Subtotal,
+		ItemDiscount:           itemDiscount.Sub(coupon.itemDiscount),
+		CouponCode:             coupon.code,
+		CouponItemDiscount:     coupon.itemDiscount,
+		CouponShippingDiscount: coupon.shippingDiscount,
+		ShippingCost:           order.ShippingCost,
+		ShippingService:        shippingService(order),
+	})
+
+	order.ShippingCost = result.ShippingCost
+	order.DiscountAmount = result.DiscountAmount
+	order.TaxAmount = result.TaxAmount
+	order.TotalAmount = result.TotalAmount
+	order.PriceBreakdown = result.Breakdown
+}
+
+func shippingService(order *models.Order) string {
+	if order.ShippingCourier == nil {
+		return "shipping"
+	}
+	if order.ShippingMethod == nil {
+		return *order.ShippingCourier
+	}
+	return *order.ShippingCourier + " " + *order.ShippingMethod
+}
+
+// replaces the quote checkout got, for couriers repricing a parcel or support fixing a wrong address. the coupon
+// keeps what it took off when the order was created, a shipping discount shrinks with a cheaper cost but never grows
+func (service *OrderService) UpdateShippingCost(ctx context.Context, orderId string, payload types.UpdateShippingCostPayload, actorId string) (types.OrderResponse, error) {
+	if payload.ShippingCost.IsNegative() || !payload.ShippingCost.Equal(payload.ShippingCost.Floor()) {
+		return types.OrderResponse{}, ErrInvalidShippingCost
+	}
+
+	var updated models.Order
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		order, err := txRepository.GetOrderByIDForUpdate(ctx, orderId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+
+		if !statemachine.IsUnpaid(order.Status) {
+			return ErrShippingCostLocked
+		}
+
+		if order.OrderItems, err = txRepository.GetOrderItems(ctx, order.ID); err != nil {
+			return err
+		}
+
+		previousCost := order.ShippingCost
+		order.ShippingCost = payload.ShippingCost
+		if payload.Courier != "" {
+			order.ShippingCourier = &payload.Courier
+		}
+		if payload.ShippingService != "" {
+			order.ShippingMethod = &payload.ShippingService
+		}
+
+		order.UpdatedAt = time.Now()
+		service.priceOrder(&order, appliedCoupon{
+			code:             derefString(order.CouponCode),
+			itemDiscount:     order.PriceBreakdown.Amount(pricing.StepCoupon).Neg(),
+			shippingDiscount: order.PriceBreakdown.Amount(pricing.StepShippingDiscount).Neg(),
+		})
+
+		if err := txRepository.UpdateOrderPricing(ctx, &order); err != nil {
+			return err
+		}
+
+		updated = order
+		return txRepository.CreateOutboxEvent(ctx, orderRepricedEvent(order, previousCost, payload.Reason, actorId))
+	})
+	if err != nil {
+		return types.OrderResponse{}, err
+	}
+
+	return service.parseToOrderResponse([]models.Order{updated})[0], nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	sharedTypes "github.com/Flow-Indo/LAKOO/backend/shared/types"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
+)
+
+// product-service lookups a checkout runs at once
+const maxProductRequests = 8
+
+// why a line of a checkout can't be ordered
+const (
+	ItemNotFound   = "not_found"
+	ItemInactive   = "inactive"
+	ItemOutOfStock = "out_of_stock"
+)
+
+var (
+	ErrItemsUnavailable          = errors.New("some items are not available")
+	ErrProductServiceUnavailable = errors.New("product service unavailable")
+)
+
+type UnavailableItem struct {
+	Index     int    `json:"index"` //position of the line in the request's items
+	ProductID string `json:"productId"`
+	Reason    string `json:"reason"`
+	Requested int    `json:"requested,omitempty"`
+	Available *int   `json:"available,omitempty"` //set when the warehouse could only hold part of the quantity
+}
+
+// every line that failed, so the client can fix the whole cart at once
+type UnavailableItemsError struct {
+	Items []UnavailableItem
+}
+
+func (e *UnavailableItemsError) Error() string {
+	lines := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		lines = append(lines, fmt.Sprintf("items[%d] %s: %s", item.Index, item.ProductID, item.Reason))
+	}
+	return fmt.Sprintf("%s: %s", ErrItemsUnavailable, strings.Join(lines, ", "))
+}
+
+func (e *UnavailableItemsError) Unwrap() error {
+	return ErrItemsUnavailable
+}
+
+// price, sku, name, seller and image come from product-service, never from the client payload. the local catalog
+// still supplies the factory and brand an item ships from, a product missing from either can't be ordered
+func (service *OrderService) priceItems(ctx context.Context, items []types.OrderItemPayload) ([]models.OrderItem, decimal.Decimal, error) {
+	productIds := productIDs(items)
+
+	products, err := service.fetchProducts(ctx, productIds)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	catalog, err := service.productsByID(ctx, service.orderRepository, productIds)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	var unavailable []UnavailableItem
+	subtotal := decimal.Zero
+	orderItems := make([]models.OrderItem, 0, len(items))
+	for i, item := range items {
+		product, found := products[item.ProductID]
+		local, listed := catalog[item.ProductID]
+		if reason := unavailableReason(product, found && listed); reason != "" {
+			unavailable = append(unavailable, UnavailableItem{Index: i, ProductID: item.ProductID, Reason: reason})
+			continue
+		}
+
+		itemSubtotal := product.BaseSellPrice.Mul(decimal.NewFromInt(int64(item.Quantity)))
+		subtotal = subtotal.Add(itemSubtotal)
+
+		orderItems = append(orderItems, models.OrderItem{
+			ProductID:       product.ID,
+			FactoryID:       local.FactoryID,
+			BrandID:         local.BrandID,
+			SellerID:        product.SellerID,
+			SKU:             product.ProductCode,
+			ProductName:     product.Name,
+			Quantity:        item.Quantity,
+			UnitPrice:       product.BaseSellPrice,
+			Subtotal:        itemSubtotal,
+			DiscountAmount:  decimal.Zero,
+			ProductSnapshot: productSnapshot(product, local),
+		})
+	}
+
+	if len(unavailable) > 0 {
+		return nil, decimal.Zero, &UnavailableItemsError{Items: unavailable}
+	}
+
+	return orderItems, subtotal, nil
+}
+
+// one request per distinct product, at most maxProductRequests at a time. unknown products are left out of the map
+func (service *OrderService) fetchProducts(ctx context.Context, productIds []string) (map[string]clients.Product, error) {
+	var mu sync.Mutex
+	products := make(map[string]clients.Product, len(productIds))
+	requested := make(map[string]bool, len(productIds))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxProductRequests)
+	for _, productId := range productIds {
+		if requested[productId] {
+			continue
+		}
+		requested[productId] = true
+
+		group.Go(func() error {
+			product, err := service.products.GetProduct(groupCtx, productId)
+			if err != nil {
+				if errors.Is(err, clients.ErrProductNotFound) {
+					return nil
+				}
+				return fmt.Errorf("%w: %v", ErrProductServiceUnavailable, err)
+			}
+
+			mu.Lock()
+			products[productId] = product
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func unavailableReason(product clients.Product, found bool) string {
+	switch {
+	case !found:
+		return ItemNotFound
+	case product.Status == clients.ProductStatusOutOfStock:
+		return ItemOutOfStock
+	case product.Status != clients.ProductStatusApproved || product.DeletedAt != nil || !product.BaseSellPrice.IsPositive():
+		return ItemInactive
+	default:
+		return ""
+	}
+}
+
+// the product as it was when ordered, in the shape parseProductSnapshot reads
+func productSnapshot(product clients.Product, local models.Product) sharedTypes.JSONB {
+	snapshot := sharedTypes.JSONB{
+		"factory": map[string]interface{}{
+			"id":           local.FactoryID,
+			"city":         local.Factory.City,
+			"factory_name": local.Factory.FactoryName,
+		},
+		"product": map[string]interface{}{
+			"id":                product.ID,
+			"sku":               product.ProductCode,
+			"name":              product.Name,
+			"width_cm":          decimalInt(product.WidthCm),
+			"height_cm":         decimalInt(product.HeightCm),
+			"length_cm":         decimalInt(product.LengthCm),
+			"base_price":        int(product.BaseSellPrice.IntPart()),
+			"factory_id":        local.FactoryID,
+			"description":       derefString(product.Description),
+			"weight_grams":      derefInt(product.WeightGrams),
+			"primary_image_url": derefString(product.PrimaryImageURL),
+		},
+	}
+
+	if product.Category != nil {
+		snapshot["category"] = map[string]interface{}{
+			"id":   product.Category.ID,
+			"name": product.Category.Name,
+			"slug": product.Category.Slug,
+		}
+	}
+
+	return snapshot
+}
+
+// whole centimetres, rounded up so a parcel is never quoted smaller than it is
+func decimalInt(value *decimal.Decimal) int {
+	if value == nil {
+		return 0
+	}
+	return int(value.Ceil().IntPart())
+}
+
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/shopspring/decimal"
+)
+
+// holds every lookup until want of them are in flight at once, so a client called one product at a time times out
+type concurrentProducts struct {
+	want    int
+	missing string
+	failing string
+
+	mu      sync.Mutex
+	calls   map[string]int
+	started int
+	all     chan struct{}
+}
+
+func (p *concurrentProducts) GetProduct(ctx context.Context, productId string) (clients.Product, error) {
+	p.mu.Lock()
+	p.calls[productId]++
+	p.started++
+	if p.started == p.want {
+		close(p.all)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.all:
+	case <-time.After(time.Second):
+		return clients.Product{}, errors.New("lookups were not concurrent")
+	}
+
+	switch productId {
+	case p.missing:
+		return clients.Product{}, clients.ErrProductNotFound
+	case p.failing:
+		return clients.Product{}, errors.New("connection refused")
+	}
+	return clients.Product{ID: productId, BaseSellPrice: decimal.NewFromInt(150000)}, nil
+}
+
+func TestFetchProductsRunsLookupsConcurrently(t *testing.T) {
+	products := &concurrentProducts{want: 3, missing: "p-3", calls: map[string]int{}, all: make(chan struct{})}
+	service := &OrderService{products: products}
+
+	fetched, err := service.fetchProducts(context.Background(), []string{"p-1", "p-2", "p-1", "p-3"})
+	if err != nil {
+		t.Fatalf("fetchProducts: %v", err)
+	}
+
+	if len(fetched) != 2 || fetched["p-1"].ID != "p-1" || fetched["p-2"].ID != "p-2" {
+		t.Fatalf("fetched = %+v, want p-1 and p-2 without the unknown p-3", fetched)
+	}
+	for id, calls := range products.calls {
+		if calls != 1 {
+			t.Fatalf("%s looked up %d times, want once", id, calls)
+		}
+	}
+}
+
+func TestFetchProductsFailsWhenProductServiceDoes(t *testing.T) {
+	products := &concurrentProducts{want: 2, failing: "p-2", calls: map[string]int{}, all: make(chan struct{})}
+	service := &OrderService{products: products}
+
+	if _, err := service.fetchProducts(context.Background(), []string{"p-1", "p-2"}); !errors.Is(err, ErrProductServiceUnavailable) {
+		t.Fatalf("fetchProducts error = %v, want %v", err, ErrProductServiceUnavailable)
+	}
+}
+
+// the snapshot keeps where the item ships from in the shape order responses read it back
+func TestProductSnapshotKeepsTheFactory(t *testing.T) {
+	weight := 800
+	local := models.Product{
+		ID:        "p-1",
+		FactoryID: "f-1",
+		Factory:   models.Factory{ID: "f-1", FactoryName: "Pabrik Uji", City: "Bandung"},
+	}
+	product := clients.Product{ID: "p-1", ProductCode: "KMJ-001", Name: "Kemeja Batik", BaseSellPrice: decimal.NewFromInt(150000), WeightGrams: &weight}
+
+	parsed := (&OrderService{}).parseProductSnapshot(productSnapshot(product, local))
+
+	if parsed.Factory.ID != "f-1" || parsed.Factory.FactoryName != "Pabrik Uji" || parsed.Factory.City != "Bandung" {
+		t.Fatalf("factory = %+v, want Pabrik Uji in Bandung", parsed.Factory)
+	}
+	if parsed.Product.SKU != "KMJ-001" || parsed.Product.FactoryID != "f-1" || parsed.Product.WeightGrams != 800 || parsed.Product.BasePrice != 150000 {
+		t.Fatalf("product = %+v", parsed.Product)
+	}
+}
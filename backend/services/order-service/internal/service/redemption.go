@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/coupon"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// prices the cart the same way CreateOrder would and evaluates the coupon against it, nothing is redeemed
+func (service *OrderService) ValidateCoupon(ctx context.Context, payload types.ValidateCouponPayload) (coupon.Result, error) {
+	orderItems, _, err := service.priceItems(ctx, payload.Items)
+	if err != nil {
+		return coupon.Result{}, err
+	}
+
+	found, err := service.orderRepository.GetCouponByCode(ctx, normalizeCouponCode(payload.Code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return coupon.Result{}, coupon.ErrUnknownCode
+		}
+		return coupon.Result{}, err
+	}
+
+	cart, err := service.couponCart(ctx, service.orderRepository, found.ID, payload.UserID, orderItems, payload.ShippingCost)
+	if err != nil {
+		return coupon.Result{}, err
+	}
+
+	return coupon.Evaluate(found, cart, time.Now())
+}
+
+// must run in the transaction that creates the orders. the coupon row stays locked until it commits, so concurrent
+// checkouts with the same code evaluate one after another against the counters the previous one left behind.
+// the coupon is evaluated against the whole checkout, not each order of the split on its own
+func (service *OrderService) redeemCoupon(ctx context.Context, txRepository *repository.OrderRepository, orders []*models.Order, code string) (*models.CouponUsage, error) {
+	found, err := txRepository.GetCouponByCodeForUpdate(ctx, normalizeCouponCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, coupon.ErrUnknownCode
+		}
+		return nil, err
+	}
+
+	var orderItems []models.OrderItem
+	shippingCost := decimal.Zero
+	for _, order := range orders {
+		orderItems = append(orderItems, order.OrderItems...)
+		shippingCost = shippingCost.Add(order.ShippingCost)
+	}
+
+	cart, err := service.couponCart(ctx, txRepository, found.ID, orders[0].UserID, orderItems, shippingCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := coupon.Evaluate(found, cart, now)
+	if err != nil {
+		return nil, err
+	}
+
+	redeemed, err := txRepository.IncrementCouponUsage(ctx, found.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, coupon.ErrUsageLimitReached
+	}
+
+	service.applyCoupon(orders, result)
+
+	return &models.CouponUsage{
+		CouponID:       found.ID,
+		UserID:         orders[0].UserID,
+		DiscountAmount: result.TotalDiscount,
+		UsedAt:         now,
+	}, nil
+}
+
+// lines are keyed by their index in orderItems, for a checkout the items of all its orders one after another
+func (service *OrderService) couponCart(ctx context.Context, txRepository *repository.OrderRepository, couponId string, userId string, orderItems []models.OrderItem, shippingCost decimal.Decimal) (coupon.Cart, error) {
+	productIds := make([]string, 0, len(orderItems))
+	for _, item := range orderItems {
+		productIds = append(productIds, item.ProductID)
+	}
+
+	productsById, err := service.productsByID(ctx, txRepository, productIds)
+	if err != nil {
+		return coupon.Cart{}, err
+	}
+
+	usages, err := txRepository.CountCouponUsages(ctx, couponId, userId)
+	if err != nil {
+		return coupon.Cart{}, err
+	}
+
+	previousOrders, err := txRepository.CountUserOrders(ctx, userId)
+	if err != nil {
+		return coupon.Cart{}, err
+	}
+
+	cart := coupon.Cart{
+		Lines:        make([]coupon.Line, 0, len(orderItems)),
+		ShippingCost: shippingCost,
+		UserUsages:   int(usages),
+		NewCustomer:  previousOrders == 0,
+	}
+	for i, item := range orderItems {
+		product := productsById[item.ProductID]
+		cart.Lines = append(cart.Lines, coupon.Line{
+			ID:         strconv.Itoa(i),
+			ProductID:  item.ProductID,
+			BrandID:    derefString(product.BrandID),
+			CategoryID: derefString(product.CategoryID),
+			Quantity:   item.Quantity,
+			Subtotal:   item.Subtotal,
+		})
+	}
+
+	return cart, nil
+}
+
+// item discounts land on the order holding the item, a shipping discount is split by each order's shipping cost.
+// every order is priced again with its share of the coupon
+func (service *OrderService) applyCoupon(orders []*models.Order, result coupon.Result) {
+	var items []*models.OrderItem
+	shippingCosts := make([]decimal.Decimal, len(orders))
+	for i, order := range orders {
+		for j := range order.OrderItems {
+			order.OrderItems[j].DiscountAmount = decimal.Zero
+			items = append(items, &order.OrderItems[j])
+		}
+		shippingCosts[i] = order.ShippingCost
+	}
+
+	for _, line := range result.Lines {
+		if i, err := strconv.Atoi(line.LineID); err == nil && i < len(items) {
+			items[i].DiscountAmount = line.Discount
+		}
+	}
+
+	shippingDiscounts := allocateProportionally(result.ShippingDiscount, shippingCosts)
+	for i, order := range orders {
+		itemDiscount := decimal.Zero
+		for _, item := range order.OrderItems {
+			itemDiscount = itemDiscount.Add(item.DiscountAmount)
+		}
+
+		order.CouponID = &result.CouponID
+		order.CouponCode = &result.Code
+		service.priceOrder(order, appliedCoupon{code: result.Code, itemDiscount: itemDiscount, shippingDiscount: shippingDiscounts[i]})
+	}
+}
+
+// codes are stored upper case, customers may type them any way
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
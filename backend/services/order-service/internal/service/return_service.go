@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/utils"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	returnTypeRefund        = "refund"
+	returnTypeExchange      = "exchange"
+	defaultRefundMethod     = "original_payment"
+	maxReturnNumberAttempts = 5
+)
+
+var (
+	ErrReturnNotFound         = errors.New("return not found")
+	ErrOrderNotReturnable     = errors.New("order cannot be returned in its current status")
+	ErrReturnWindowClosed     = errors.New("return window has closed")
+	ErrOrderItemNotFound      = errors.New("order item not found")
+	ErrReturnQuantityExceeded = errors.New("return quantity exceeds what is left to return")
+	ErrReturnNumberExhausted  = errors.New("could not generate a unique return number")
+)
+
+// the return window always runs from delivery, confirming receipt or the order completing on its own doesn't move
+// it. false until the order was delivered
+func (service *OrderService) ReturnDeadline(order models.Order) (time.Time, bool) {
+	if order.DeliveredAt == nil {
+		return time.Time{}, false
+	}
+	return order.DeliveredAt.Add(service.returnWindow), true
+}
+
+func (service *OrderService) RequestReturn(ctx context.Context, orderId string, payload types.CreateReturnPayload, actorId string) (models.Return, error) {
+	now := time.Now()
+	ret := models.Return{
+		UserID:        actorId,
+		OrderID:       orderId,
+		ReturnType:    payload.ReturnType,
+		Reason:        payload.Reason,
+		ReasonDetail:  optionalString(payload.ReasonDetail),
+		CustomerNotes: optionalString(payload.CustomerNotes),
+		Images:        payload.Images,
+		Status:        statemachine.ReturnRequested,
+		//the parcel was delivered as paid for, returns refund the goods and their PPN but not the shipping
+		ShippingRefund: decimal.Zero,
+		RequestedAt:    now,
+	}
+	if ret.ReturnType == "" {
+		ret.ReturnType = returnTypeRefund
+	}
+
+	for attempt := 0; attempt < maxReturnNumberAttempts; attempt++ {
+		returnNumber, err := utils.GenerateReturnNumber(now)
+		if err != nil {
+			return models.Return{}, err
+		}
+		ret.ReturnNumber = returnNumber
+
+		err = service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+			//the order lock serialises return requests, two requests can't both claim the last unit
+			order, err := lockOrder(ctx, txRepository, orderId)
+			if err != nil {
+				return err
+			}
+
+			if order.UserID != actorId {
+				return ErrNotOrderOwner
+			}
+
+			if err := service.prepareReturn(ctx, txRepository, order, payload.Items, &ret, now); err != nil {
+				return err
+			}
+
+			return txRepository.CreateReturn(ctx, &ret)
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ret, err
+		}
+
+		log.Printf("Return number %s already taken, retrying", returnNumber)
+	}
+
+	return models.Return{}, ErrReturnNumberExhausted
+}
+
+// checks the window and the quantities still returnable, then prices the return from the order items
+func (service *OrderService) prepareReturn(ctx context.Context, txRepository *repository.OrderRepository, order models.Order, items []types.ReturnItemPayload, ret *models.Return, now time.Time) error {
+	switch order.Status {
+	case statemachine.StatusDelivered, statemachine.StatusCompleted, statemachine.StatusPartiallyRefunded:
+	default:
+		return fmt.Errorf("%w: %s", ErrOrderNotReturnable, order.Status)
+	}
+
+	deadline, ok := service.ReturnDeadline(order)
+	if !ok || now.After(deadline) {
+		return ErrReturnWindowClosed
+	}
+	ret.Deadline = deadline
+
+	orderItems, err := service.orderItemsByID(ctx, txRepository, order.ID)
+	if err != nil {
+		return err
+	}
+
+	//quantities already claimed by returns that are still in progress
+	claimed := map[string]int{}
+	returns, err := txRepository.GetReturnsByOrderID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	for _, existing := range returns {
+		if !statemachine.IsOpenReturn(existing.Status) {
+			continue
+		}
+		for _, item := range existing.Items {
+			claimed[item.OrderItemID] += item.Quantity
+		}
+	}
+
+	goodsTotal := decimal.Zero
+	for _, orderItem := range orderItems {
+		goodsTotal = goodsTotal.Add(orderItem.Subtotal.Sub(orderItem.DiscountAmount))
+	}
+
+	subtotal, tax := decimal.Zero, decimal.Zero
+	ret.Items = make(models.ReturnItems, 0, len(items))
+	for _, item := range items {
+		orderItem, ok := orderItems[item.OrderItemID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrOrderItemNotFound, item.OrderItemID)
+		}
+
+		before := orderItem.ReturnedQuantity + claimed[item.OrderItemID]
+		claimed[item.OrderItemID] += item.Quantity
+		if orderItem.ReturnedQuantity+claimed[item.OrderItemID] > orderItem.Quantity {
+			return fmt.Errorf("%w: %s", ErrReturnQuantityExceeded, item.OrderItemID)
+		}
+
+		goods, itemTax := returnedUnitsPrice(orderItem, order.TaxAmount, goodsTotal, before, item.Quantity)
+		subtotal = subtotal.Add(goods)
+		tax = tax.Add(itemTax)
+		ret.Items = append(ret.Items, models.ReturnItem{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Reason:      item.Reason,
+			Amount:      goods.Add(itemTax),
+		})
+	}
+
+	ret.SubtotalAmount = subtotal
+	ret.TotalAmount = subtotal.Add(tax).Add(ret.ShippingRefund)
+	return nil
+}
+
+// what the customer paid for quantity units of a line after the before units already returned: the line's price after
+// item and coupon discounts, plus the share of the order's PPN those goods carried. shares are floored on the running
+// count, so a line returned in parts adds up to exactly what was paid for it
+func returnedUnitsPrice(orderItem models.OrderItem, orderTax decimal.Decimal, goodsTotal decimal.Decimal, before int, quantity int) (decimal.Decimal, decimal.Decimal) {
+	lineGoods := orderItem.Subtotal.Sub(orderItem.DiscountAmount)
+	lineTax := decimal.Zero
+	if goodsTotal.IsPositive() {
+		lineTax = orderTax.Mul(lineGoods).Div(goodsTotal)
+	}
+
+	share := func(amount decimal.Decimal, units int) decimal.Decimal {
+		return amount.Mul(decimal.NewFromInt(int64(units))).Div(decimal.NewFromInt(int64(orderItem.Quantity))).Floor()
+	}
+	after := before + quantity
+
+	return share(lineGoods, after).Sub(share(lineGoods, before)), share(lineTax, after).Sub(share(lineTax, before))
+}
+
+func (service *OrderService) GetOrderReturns(ctx context.Context, orderId string, actorId string, actorType string) ([]models.Return, error) {
+	order, err := service.orderRepository.GetOrderByID(ctx, orderId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+
+	if actorType == ActorCustomer && order.UserID != actorId {
+		return nil, ErrNotOrderOwner
+	}
+
+	return service.orderRepository.GetReturnsByOrderID(ctx, orderId)
+}
+
+func (service *OrderService) ApproveReturn(ctx context.Context, returnId string, actorId string) (models.Return, error) {
+	return service.updateReturn(ctx, returnId, func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error {
+		return statemachine.TransitionReturn(ret, statemachine.ReturnApproved, optionalString(actorId), now)
+	})
+}
+
+// admins reject a request outright or after inspection found the item not eligible
+func (service *OrderService) RejectReturn(ctx context.Context, returnId string, payload types.RejectReturnPayload, actorId string) (models.Return, error) {
+	return service.updateReturn(ctx, returnId, func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error {
+		if err := statemachine.TransitionReturn(ret, statemachine.ReturnRejected, optionalString(actorId), now); err != nil {
+			return err
+		}
+		ret.RejectionReason = optionalString(payload.Reason)
+		return nil
+	})
+}
+
+func (service *OrderService) ShipReturn(ctx context.Context, returnId string, payload types.ShipReturnPayload, actorId string) (models.Return, error) {
+	return service.updateReturn(ctx, returnId, func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error {
+		if ret.UserID != actorId {
+			return ErrNotOrderOwner
+		}
+		if err := statemachine.TransitionReturn(ret, statemachine.ReturnInTransit, nil, now); err != nil {
+			return err
+		}
+		ret.ReturnCourier = optionalString(payload.Courier)
+		ret.ReturnTrackingNo = optionalString(payload.TrackingNo)
+		return nil
+	})
+}
+
+func (service *OrderService) CancelReturn(ctx context.Context, returnId string, actorId string) (models.Return, error) {
+	return service.updateReturn(ctx, returnId, func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error {
+		if ret.UserID != actorId {
+			return ErrNotOrderOwner
+		}
+		return statemachine.TransitionReturn(ret, statemachine.ReturnCancelled, nil, now)
+	})
+}
+
+func (service *OrderService) ReceiveReturn(ctx context.Context, returnId string) (models.Return, error) {
+	return service.updateReturn(ctx, returnId, func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error {
+		return statemachine.TransitionReturn(ret, statemachine.ReturnReceived, nil, now)
+	})
+}
+
+func (service *OrderService) InspectReturn(ctx context.Context, returnId string, payload types.InspectReturnPayload, actorId string) (models.Return, error) {
+	return service.updateReturn(ctx, returnId, func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error {
+		if err := statemachine.TransitionReturn(ret, statemachine.ReturnInspecting, nil, now); err != nil {
+			return err
+		}
+		ret.InspectedAt = &now
+		ret.InspectedBy = optionalString(actorId)
+		ret.ItemCondition = optionalString(payload.ItemCondition)
+		ret.InspectionNotes = optionalString(payload.Notes)
+		return nil
+	})
+}
+
+// books the returned quantities on the order items, moves the order to partially_refunded or refunded and asks
+// payment-service for the refund. exchanges only book the quantities, the replacement ships as a new order
+func (service *OrderService) CompleteReturn(ctx context.Context, returnId string, payload types.CompleteReturnPayload, actorId string) (models.Return, error) {
+	return service.updateReturn(ctx, returnId, func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error {
+		if err := statemachine.TransitionReturn(ret, statemachine.ReturnCompleted, nil, now); err != nil {
+			return err
+		}
+
+		for _, item := range ret.Items {
+			added, err := txRepository.AddReturnedQuantity(ctx, item.OrderItemID, item.Quantity)
+			if err != nil {
+				return err
+			}
+			if !added {
+				return fmt.Errorf("%w: %s", ErrReturnQuantityExceeded, item.OrderItemID)
+			}
+		}
+
+		if ret.ReturnType == returnTypeExchange {
+			return nil
+		}
+
+		refundMethod := payload.RefundMethod
+		if refundMethod == "" {
+			refundMethod = defaultRefundMethod
+		}
+		ret.RefundMethod = &refundMethod
+
+		orderItems, err := service.orderItemsByID(ctx, txRepository, order.ID)
+		if err != nil {
+			return err
+		}
+
+		fullRefund := true
+		for _, orderItem := range orderItems {
+			if orderItem.ReturnedQuantity < orderItem.Quantity {
+				fullRefund = false
+			}
+		}
+
+		nextStatus := statemachine.StatusPartiallyRefunded
+		if fullRefund {
+			nextStatus = statemachine.StatusRefunded
+		}
+
+		if order.Status != nextStatus {
+			if err := service.transitionOrder(ctx, txRepository, &order, statusChange{
+				to:            nextStatus,
+				reason:        "return " + ret.ReturnNumber + " completed",
+				changedBy:     actorId,
+				changedByType: ActorAdmin,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return txRepository.CreateOutboxEvent(ctx, refundRequestedEvent(order, *ret, orderItems, fullRefund, now))
+	})
+}
+
+// locks the order before the return, the same order RequestReturn takes its lock in
+func (service *OrderService) updateReturn(ctx context.Context, returnId string, apply func(txRepository *repository.OrderRepository, order models.Order, ret *models.Return, now time.Time) error) (models.Return, error) {
+	var ret models.Return
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		unlocked, err := txRepository.GetReturnByID(ctx, returnId)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReturnNotFound
+			}
+			return err
+		}
+
+		order, err := lockOrder(ctx, txRepository, unlocked.OrderID)
+		if err != nil {
+			return err
+		}
+
+		ret, err = txRepository.GetReturnByIDForUpdate(ctx, returnId)
+		if err != nil {
+			return err
+		}
+
+		if err := apply(txRepository, order, &ret, time.Now()); err != nil {
+			return err
+		}
+
+		return txRepository.UpdateReturn(ctx, &ret)
+	})
+
+	return ret, err
+}
+
+func (service *OrderService) orderItemsByID(ctx context.Context, txRepository *repository.OrderRepository, orderId string) (map[string]models.OrderItem, error) {
+	orderItems, err := txRepository.GetOrderItems(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	byId := make(map[string]models.OrderItem, len(orderItems))
+	for _, item := range orderItems {
+		byId[item.ID] = item
+	}
+	return byId, nil
+}
@@ -0,0 +1,212 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/config"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+const testAdminID = "1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"
+
+func TestReturnDeadlineCountsFromDelivery(t *testing.T) {
+	orders := service.NewService(nil, nil, nil, nil)
+	window := config.Envs.ORDER_RETURN_WINDOW
+	deliveredAt := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	confirmedAt := deliveredAt.Add(2 * 24 * time.Hour)
+	autoCompletedAt := deliveredAt.Add(window)
+
+	tests := []struct {
+		name  string
+		order models.Order
+		ok    bool
+	}{
+		{name: "not delivered", order: models.Order{}},
+		{name: "delivered", order: models.Order{DeliveredAt: &deliveredAt}, ok: true},
+		{name: "receipt confirmed early", order: models.Order{DeliveredAt: &deliveredAt, CompletedAt: &confirmedAt}, ok: true},
+		{name: "completed by the job", order: models.Order{DeliveredAt: &deliveredAt, CompletedAt: &autoCompletedAt}, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadline, ok := orders.ReturnDeadline(tt.order)
+			if ok != tt.ok {
+				t.Fatalf("ReturnDeadline ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && !deadline.Equal(deliveredAt.Add(window)) {
+				t.Fatalf("deadline = %s, want %s", deadline, deliveredAt.Add(window))
+			}
+		})
+	}
+}
+
+// pays and walks the order up to delivered the way admins and the courier webhooks would
+func deliveredOrder(t *testing.T, f fixture, quantity int) models.Order {
+	t.Helper()
+	ctx := context.Background()
+
+	checkout, err := f.service.CreateOrder(checkoutPayload(quantity), ctx)
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	orderId := checkout.Orders[0].ID
+
+	if err := f.service.MarkOrderPaid(ctx, orderId, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+	for _, status := range []string{
+		statemachine.StatusConfirmed,
+		statemachine.StatusProcessing,
+		statemachine.StatusReadyToShip,
+		statemachine.StatusShipped,
+		statemachine.StatusDelivered,
+	} {
+		if _, err := f.service.UpdateOrderStatus(ctx, orderId, types.UpdateOrderStatusPayload{Status: status}, testAdminID, service.ActorAdmin); err != nil {
+			t.Fatalf("UpdateOrderStatus(%s): %v", status, err)
+		}
+	}
+
+	return readOrder(t, f, orderId)
+}
+
+func returnPayload(order models.Order, quantity int) types.CreateReturnPayload {
+	return types.CreateReturnPayload{
+		Reason: "defective",
+		Items:  []types.ReturnItemPayload{{OrderItemID: order.OrderItems[0].ID, Quantity: quantity}},
+	}
+}
+
+// approves the return and takes it through the customer's shipment and the warehouse's inspection to completion
+func completeReturn(t *testing.T, f fixture, ret models.Return) models.Return {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := f.service.ApproveReturn(ctx, ret.ID, testAdminID); err != nil {
+		t.Fatalf("ApproveReturn: %v", err)
+	}
+	if _, err := f.service.ShipReturn(ctx, ret.ID, types.ShipReturnPayload{Courier: "jne", TrackingNo: "JNE123"}, testUserID); err != nil {
+		t.Fatalf("ShipReturn: %v", err)
+	}
+	if _, err := f.service.ReceiveReturn(ctx, ret.ID); err != nil {
+		t.Fatalf("ReceiveReturn: %v", err)
+	}
+	if _, err := f.service.InspectReturn(ctx, ret.ID, types.InspectReturnPayload{ItemCondition: "damaged"}, testAdminID); err != nil {
+		t.Fatalf("InspectReturn: %v", err)
+	}
+	completed, err := f.service.CompleteReturn(ctx, ret.ID, types.CompleteReturnPayload{}, testAdminID)
+	if err != nil {
+		t.Fatalf("CompleteReturn: %v", err)
+	}
+	return completed
+}
+
+// requested, approved, shipped back, received, inspected and completed, the order follows the refunded quantities
+func TestReturnFlowRefundsTheOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		returned int
+		status   string
+		full     bool
+	}{
+		{name: "part of the order", returned: 1, status: statemachine.StatusPartiallyRefunded},
+		{name: "whole order", returned: 2, status: statemachine.StatusRefunded, full: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, 10)
+			ctx := context.Background()
+			order := deliveredOrder(t, f, 2)
+
+			ret, err := f.service.RequestReturn(ctx, order.ID, returnPayload(order, tt.returned), testUserID)
+			if err != nil {
+				t.Fatalf("RequestReturn: %v", err)
+			}
+			if !ret.Deadline.Equal(order.DeliveredAt.Add(config.Envs.ORDER_RETURN_WINDOW)) {
+				t.Fatalf("deadline = %s, want the window from delivery at %s", ret.Deadline, order.DeliveredAt)
+			}
+
+			completed := completeReturn(t, f, ret)
+			if completed.Status != statemachine.ReturnCompleted {
+				t.Fatalf("return status = %s, want %s", completed.Status, statemachine.ReturnCompleted)
+			}
+
+			var refunded models.Order
+			if err := f.db.Preload("OrderItems").First(&refunded, "id = ?", order.ID).Error; err != nil {
+				t.Fatalf("read order: %v", err)
+			}
+			if refunded.Status != tt.status {
+				t.Fatalf("order status = %s, want %s", refunded.Status, tt.status)
+			}
+			if refunded.OrderItems[0].ReturnedQuantity != tt.returned {
+				t.Fatalf("returned quantity = %d, want %d", refunded.OrderItems[0].ReturnedQuantity, tt.returned)
+			}
+
+			rows := outboxEvents(t, f.db, order.ID)
+			last := rows[len(rows)-1]
+			if last.EventType != kafka.EventRefundRequested {
+				t.Fatalf("last outbox row = %s, want %s", last.EventType, kafka.EventRefundRequested)
+			}
+			envelope, err := last.Envelope("order-service")
+			if err != nil {
+				t.Fatalf("Envelope: %v", err)
+			}
+			var payload kafka.RefundRequested
+			if err := envelope.DecodePayload(&payload); err != nil {
+				t.Fatalf("DecodePayload: %v", err)
+			}
+			if payload.FullRefund != tt.full || payload.RefundMethod != "original_payment" || len(payload.Items) != 1 ||
+				payload.Items[0].Quantity != tt.returned {
+				t.Fatalf("refund payload = %+v", payload)
+			}
+		})
+	}
+}
+
+func TestRequestReturnRejectsWhatCannotBeReturned(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+	order := deliveredOrder(t, f, 2)
+
+	if _, err := f.service.RequestReturn(ctx, order.ID, returnPayload(order, 3), testUserID); !errors.Is(err, service.ErrReturnQuantityExceeded) {
+		t.Fatalf("RequestReturn of 3 units error = %v, want %v", err, service.ErrReturnQuantityExceeded)
+	}
+	if _, err := f.service.RequestReturn(ctx, order.ID, returnPayload(order, 1), testAdminID); !errors.Is(err, service.ErrNotOrderOwner) {
+		t.Fatalf("RequestReturn by someone else error = %v, want %v", err, service.ErrNotOrderOwner)
+	}
+
+	//an open return holds its units, a second one can only claim what is left
+	if _, err := f.service.RequestReturn(ctx, order.ID, returnPayload(order, 2), testUserID); err != nil {
+		t.Fatalf("RequestReturn: %v", err)
+	}
+	if _, err := f.service.RequestReturn(ctx, order.ID, returnPayload(order, 1), testUserID); !errors.Is(err, service.ErrReturnQuantityExceeded) {
+		t.Fatalf("second RequestReturn error = %v, want %v", err, service.ErrReturnQuantityExceeded)
+	}
+}
+
+// confirming receipt completes the order, the window still closes where delivery put it
+func TestReturnWindowClosesFromDeliveryAfterCompletion(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+	order := deliveredOrder(t, f, 1)
+
+	if _, err := f.service.ConfirmReceived(ctx, order.ID, testUserID); err != nil {
+		t.Fatalf("ConfirmReceived: %v", err)
+	}
+
+	deliveredAt := time.Now().Add(-config.Envs.ORDER_RETURN_WINDOW - time.Hour)
+	if err := f.db.Model(&models.Order{}).Where("id = ?", order.ID).Update("deliveredAt", deliveredAt).Error; err != nil {
+		t.Fatalf("move deliveredAt: %v", err)
+	}
+
+	if _, err := f.service.RequestReturn(ctx, order.ID, returnPayload(order, 1), testUserID); !errors.Is(err, service.ErrReturnWindowClosed) {
+		t.Fatalf("RequestReturn error = %v, want %v", err, service.ErrReturnWindowClosed)
+	}
+}
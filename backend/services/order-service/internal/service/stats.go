@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultStatsRange    = 30 * 24 * time.Hour
+	defaultStatsInterval = "day"
+	defaultStatsTop      = 10
+	maxStatsBuckets      = 400
+	//buckets follow the calendar of our customers, postgres gets the zone name and go the fixed offset, WIB has no DST
+	statsTimeZone = "Asia/Jakarta"
+)
+
+var (
+	ErrInvalidStatsRange = errors.New("invalid stats range")
+
+	statsLocation = time.FixedZone("WIB", 7*60*60)
+)
+
+// aggregates orders created in the range, every number is computed in postgres rather than from loaded orders
+func (service *OrderService) GetOrderStats(ctx context.Context, payload types.OrderStatsPayload) (types.OrderStatsResponse, error) {
+	filter, err := toStatsFilter(payload, time.Now())
+	if err != nil {
+		return types.OrderStatsResponse{}, err
+	}
+
+	statusCounts, err := service.orderRepository.GetStatusCounts(ctx, filter)
+	if err != nil {
+		return types.OrderStatsResponse{}, err
+	}
+
+	buckets, err := service.orderRepository.GetStatsBuckets(ctx, filter)
+	if err != nil {
+		return types.OrderStatsResponse{}, err
+	}
+
+	topProducts, err := service.orderRepository.GetTopProducts(ctx, filter)
+	if err != nil {
+		return types.OrderStatsResponse{}, err
+	}
+
+	topFactories, err := service.orderRepository.GetTopFactories(ctx, filter)
+	if err != nil {
+		return types.OrderStatsResponse{}, err
+	}
+
+	topSellers, err := service.orderRepository.GetTopSellers(ctx, filter)
+	if err != nil {
+		return types.OrderStatsResponse{}, err
+	}
+
+	stats := types.OrderStatsResponse{
+		From:         filter.From,
+		To:           filter.To,
+		Interval:     filter.Interval,
+		ByStatus:     make([]types.StatusCount, 0, len(statusCounts)),
+		Buckets:      fillBuckets(filter, buckets),
+		TopProducts:  make([]types.TopProductStat, 0, len(topProducts)),
+		TopFactories: toMerchantStats(topFactories),
+		TopSellers:   toMerchantStats(topSellers),
+	}
+
+	var gmvOrders int64
+	stats.Summary.GMV = decimal.Zero
+	for _, row := range statusCounts {
+		stats.ByStatus = append(stats.ByStatus, types.StatusCount{Status: row.Status, Orders: row.Orders, Amount: row.Amount})
+
+		stats.Summary.TotalOrders += row.Orders
+		if row.Status == statemachine.StatusCancelled {
+			stats.Summary.CancelledOrders += row.Orders
+		}
+		stats.Summary.GMV = stats.Summary.GMV.Add(row.GMV)
+		gmvOrders += row.GMVOrders
+	}
+
+	stats.Summary.AOV = averageOrderValue(stats.Summary.GMV, gmvOrders)
+	if stats.Summary.TotalOrders > 0 {
+		stats.Summary.CancellationRate = float64(stats.Summary.CancelledOrders) / float64(stats.Summary.TotalOrders)
+	}
+
+	for _, row := range topProducts {
+		stats.TopProducts = append(stats.TopProducts, types.TopProductStat{
+			ProductID:   row.ProductID,
+			ProductName: row.ProductName,
+			Quantity:    row.Quantity,
+			Orders:      row.Orders,
+			Revenue:     row.Revenue,
+		})
+	}
+
+	return stats, nil
+}
+
+func toStatsFilter(payload types.OrderStatsPayload, now time.Time) (repository.StatsFilter, error) {
+	filter := repository.StatsFilter{
+		To:          now,
+		SellerID:    payload.SellerID,
+		BrandID:     payload.BrandID,
+		OrderSource: payload.OrderSource,
+		Interval:    defaultStatsInterval,
+		TimeZone:    statsTimeZone,
+		TopLimit:    defaultStatsTop,
+	}
+
+	//a date without a time covers the whole day, like the order list
+	if payload.EndDate != nil {
+		filter.To = *payload.EndDate
+		if filter.To.Equal(filter.To.Truncate(24 * time.Hour)) {
+			filter.To = filter.To.Add(24 * time.Hour)
+		}
+	}
+
+	filter.From = filter.To.Add(-defaultStatsRange)
+	if payload.StartDate != nil {
+		filter.From = *payload.StartDate
+	}
+
+	if !filter.From.Before(filter.To) {
+		return repository.StatsFilter{}, fmt.Errorf("%w: start_date must be before end_date", ErrInvalidStatsRange)
+	}
+
+	if payload.Interval != "" {
+		filter.Interval = payload.Interval
+	}
+	if payload.Top > 0 {
+		filter.TopLimit = payload.Top
+	}
+
+	if len(bucketStarts(filter)) > maxStatsBuckets {
+		return repository.StatsFilter{}, fmt.Errorf("%w: more than %d %s buckets, use a shorter range or a longer interval", ErrInvalidStatsRange, maxStatsBuckets, filter.Interval)
+	}
+
+	return filter, nil
+}
+
+// postgres only returns buckets that had orders, charts want every bucket of the range
+func fillBuckets(filter repository.StatsFilter, rows []repository.StatsBucketRow) []types.OrderStatsBucket {
+	rowsByStart := make(map[int64]repository.StatsBucketRow, len(rows))
+	for _, row := range rows {
+		rowsByStart[row.Bucket.Unix()] = row
+	}
+
+	starts := bucketStarts(filter)
+	buckets := make([]types.OrderStatsBucket, 0, len(starts))
+	for _, start := range starts {
+		row := rowsByStart[start.Unix()]
+		buckets = append(buckets, types.OrderStatsBucket{
+			Start:           start,
+			Orders:          row.Orders,
+			CancelledOrders: row.Cancelled,
+			GMV:             row.GMV,
+			AOV:             averageOrderValue(row.GMV, row.GMVOrders),
+		})
+	}
+
+	return buckets
+}
+
+// the same starts date_trunc produces in statsTimeZone, weeks start on monday
+func bucketStarts(filter repository.StatsFilter) []time.Time {
+	from := filter.From.In(statsLocation)
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, statsLocation)
+	switch filter.Interval {
+	case "week":
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+	case "month":
+		start = start.AddDate(0, 0, 1-start.Day())
+	}
+
+	var starts []time.Time
+	for ; start.Before(filter.To) && len(starts) <= maxStatsBuckets; start = nextBucket(start, filter.Interval) {
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+func nextBucket(start time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "month":
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// whole rupiah, rounded down like every other amount
+func averageOrderValue(gmv decimal.Decimal, orders int64) decimal.Decimal {
+	if orders <= 0 {
+		return decimal.Zero
+	}
+	return gmv.Div(decimal.NewFromInt(orders)).Floor()
+}
+
+func toMerchantStats(rows []repository.TopMerchantRow) []types.TopMerchantStat {
+	stats := make([]types.TopMerchantStat, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, types.TopMerchantStat{ID: row.ID, Orders: row.Orders, Revenue: row.Revenue})
+	}
+	return stats
+}
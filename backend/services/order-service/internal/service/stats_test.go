@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+	"github.com/shopspring/decimal"
+)
+
+func wib(year int, month time.Month, day int, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, statsLocation)
+}
+
+func TestToStatsFilter(t *testing.T) {
+	now := time.Date(2026, 3, 10, 5, 0, 0, 0, time.UTC)
+	endDate := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	endTime := time.Date(2026, 3, 5, 12, 30, 0, 0, time.UTC)
+	startDate := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		payload  types.OrderStatsPayload
+		from     time.Time
+		to       time.Time
+		interval string
+		top      int
+	}{
+		{name: "defaults", from: now.Add(-defaultStatsRange), to: now, interval: "day", top: defaultStatsTop},
+		{
+			name:     "end date covers its whole day",
+			payload:  types.OrderStatsPayload{StartDate: &startDate, EndDate: &endDate, Interval: "week", Top: 5},
+			from:     startDate,
+			to:       endDate.Add(24 * time.Hour),
+			interval: "week",
+			top:      5,
+		},
+		{
+			name:     "end time is kept",
+			payload:  types.OrderStatsPayload{EndDate: &endTime},
+			from:     endTime.Add(-defaultStatsRange),
+			to:       endTime,
+			interval: "day",
+			top:      defaultStatsTop,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := toStatsFilter(tt.payload, now)
+			if err != nil {
+				t.Fatalf("toStatsFilter: %v", err)
+			}
+			if !filter.From.Equal(tt.from) || !filter.To.Equal(tt.to) {
+				t.Fatalf("range = %s - %s, want %s - %s", filter.From, filter.To, tt.from, tt.to)
+			}
+			if filter.Interval != tt.interval || filter.TopLimit != tt.top || filter.TimeZone != statsTimeZone {
+				t.Fatalf("filter = %+v", filter)
+			}
+		})
+	}
+}
+
+func TestToStatsFilterRejectsBadRanges(t *testing.T) {
+	now := time.Date(2026, 3, 10, 5, 0, 0, 0, time.UTC)
+	early := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		payload types.OrderStatsPayload
+	}{
+		{name: "start after end", payload: types.OrderStatsPayload{StartDate: &late, EndDate: &early}},
+		{name: "start equals end", payload: types.OrderStatsPayload{StartDate: &now, EndDate: &now}},
+		{name: "too many daily buckets", payload: types.OrderStatsPayload{StartDate: &early, EndDate: &late}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := toStatsFilter(tt.payload, now); !errors.Is(err, ErrInvalidStatsRange) {
+				t.Fatalf("toStatsFilter error = %v, want %v", err, ErrInvalidStatsRange)
+			}
+		})
+	}
+
+	//the same two years fit in monthly buckets
+	if _, err := toStatsFilter(types.OrderStatsPayload{StartDate: &early, EndDate: &late, Interval: "month"}, now); err != nil {
+		t.Fatalf("monthly toStatsFilter: %v", err)
+	}
+}
+
+func TestBucketStartsFollowJakartaCalendar(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		interval string
+		want     []time.Time
+	}{
+		{
+			//20:00 UTC is already the next day in Jakarta
+			name:     "day",
+			from:     time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC),
+			to:       wib(2026, 3, 4, 0),
+			interval: "day",
+			want:     []time.Time{wib(2026, 3, 2, 0), wib(2026, 3, 3, 0)},
+		},
+		{
+			name:     "week starts on monday",
+			from:     wib(2026, 3, 8, 15),
+			to:       wib(2026, 3, 10, 0),
+			interval: "week",
+			want:     []time.Time{wib(2026, 3, 2, 0), wib(2026, 3, 9, 0)},
+		},
+		{
+			name:     "month",
+			from:     wib(2026, 1, 15, 0),
+			to:       wib(2026, 3, 2, 0),
+			interval: "month",
+			want:     []time.Time{wib(2026, 1, 1, 0), wib(2026, 2, 1, 0), wib(2026, 3, 1, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts := bucketStarts(repository.StatsFilter{From: tt.from, To: tt.to, Interval: tt.interval})
+			if len(starts) != len(tt.want) {
+				t.Fatalf("starts = %v, want %v", starts, tt.want)
+			}
+			for i := range starts {
+				if !starts[i].Equal(tt.want[i]) {
+					t.Fatalf("starts[%d] = %s, want %s", i, starts[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFillBucketsAddsEmptyBuckets(t *testing.T) {
+	filter := repository.StatsFilter{From: wib(2026, 3, 1, 0), To: wib(2026, 3, 4, 0), Interval: "day"}
+	rows := []repository.StatsBucketRow{{
+		//postgres hands the bucket back in UTC
+		Bucket:    wib(2026, 3, 2, 0).UTC(),
+		Orders:    3,
+		Cancelled: 1,
+		GMVOrders: 2,
+		GMV:       decimal.NewFromInt(100001),
+	}}
+
+	buckets := fillBuckets(filter, rows)
+
+	if len(buckets) != 3 {
+		t.Fatalf("got %d buckets, want 3", len(buckets))
+	}
+	for i, bucket := range buckets {
+		if !bucket.Start.Equal(wib(2026, 3, 1+i, 0)) {
+			t.Fatalf("bucket %d starts %s", i, bucket.Start)
+		}
+		if i != 1 && (bucket.Orders != 0 || !bucket.GMV.IsZero() || !bucket.AOV.IsZero()) {
+			t.Fatalf("bucket %d = %+v, want empty", i, bucket)
+		}
+	}
+	if filled := buckets[1]; filled.Orders != 3 || filled.CancelledOrders != 1 || !filled.GMV.Equal(decimal.NewFromInt(100001)) ||
+		!filled.AOV.Equal(decimal.NewFromInt(50000)) {
+		t.Fatalf("bucket 1 = %+v, want 3 orders, 1 cancelled and an AOV of 50000", filled)
+	}
+}
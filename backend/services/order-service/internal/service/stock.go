@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/clients"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/repository"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+)
+
+// what happened to the warehouse stock an order item holds
+const (
+	ReservationReserved  = "reserved"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired" //the warehouse let it lapse before the order was paid
+)
+
+const ItemInsufficientStock = "insufficient_stock"
+
+var ErrWarehouseUnavailable = errors.New("warehouse service unavailable")
+
+// holds warehouse stock for every house brand line before the orders are written, seller items are stocked by
+// the seller. lineIndex maps an item id to its position in the request. when any line comes up short everything
+// reserved so far is given back and the error lists every short line
+func (service *OrderService) reserveStock(ctx context.Context, orders []*models.Order, lineIndex map[string]int) error {
+	var unavailable []UnavailableItem
+	for _, order := range orders {
+		for i := range order.OrderItems {
+			item := &order.OrderItems[i]
+			if item.SellerID != nil {
+				continue
+			}
+
+			reservation, err := service.warehouse.Reserve(ctx, clients.ReserveRequest{
+				ProductID:   item.ProductID,
+				VariantID:   item.VariantID,
+				Quantity:    item.Quantity,
+				OrderID:     order.ID,
+				OrderItemID: item.ID,
+			})
+			if err != nil {
+				service.releaseStock(ctx, orders, "checkout_failed")
+				return fmt.Errorf("%w: %v", ErrWarehouseUnavailable, err)
+			}
+
+			if !reservation.Reserved {
+				available := max(item.Quantity-reservation.Shortage, 0)
+				reason := ItemInsufficientStock
+				if reservation.Shortage == 0 || available == 0 {
+					reason = ItemOutOfStock
+					available = 0
+				}
+				unavailable = append(unavailable, UnavailableItem{
+					Index:     lineIndex[item.ID],
+					ProductID: item.ProductID,
+					Reason:    reason,
+					Requested: item.Quantity,
+					Available: &available,
+				})
+				continue
+			}
+
+			item.ReservationID = &reservation.ReservationID
+			item.ReservationStatus = optionalString(ReservationReserved)
+		}
+	}
+
+	if len(unavailable) > 0 {
+		service.releaseStock(ctx, orders, "checkout_failed")
+		return &UnavailableItemsError{Items: unavailable}
+	}
+	return nil
+}
+
+// best effort for orders that were never written, whatever fails here lapses in the warehouse on its own
+func (service *OrderService) releaseStock(ctx context.Context, orders []*models.Order, reason string) {
+	for _, order := range orders {
+		for i := range order.OrderItems {
+			item := &order.OrderItems[i]
+			if item.ReservationID == nil {
+				continue
+			}
+
+			if err := service.warehouse.Release(ctx, *item.ReservationID, reason); err != nil {
+				log.Printf("Releasing reservation %s of order %s: %v", *item.ReservationID, order.ID, err)
+			}
+			item.ReservationID = nil
+			item.ReservationStatus = nil
+		}
+	}
+}
+
+// order statuses whose reserved stock is kept for good, every status a paid order moves through short of being
+// refunded in full
+var reservationConfirmingStatuses = []string{
+	statemachine.StatusPaid,
+	statemachine.StatusConfirmed,
+	statemachine.StatusProcessing,
+	statemachine.StatusReadyToShip,
+	statemachine.StatusShipped,
+	statemachine.StatusInTransit,
+	statemachine.StatusOutForDelivery,
+	statemachine.StatusDelivered,
+	statemachine.StatusCompleted,
+	statemachine.StatusPartiallyRefunded,
+}
+
+// order statuses whose reserved stock goes back to the warehouse
+var reservationReleasingStatuses = []string{
+	statemachine.StatusCancelled,
+	statemachine.StatusRefunded,
+}
+
+// confirms the reservations of paid orders and releases those of cancelled or refunded ones, returns how many items
+// were settled. a batch is claimed in its own transaction and the warehouse is called after it committed, so no row
+// stays locked across the network. an item is only marked once the warehouse answered, a failed call hands the
+// claim back for the next sweep and a claim older than lease is taken over. stock of an order cancelled after its
+// reservation was confirmed goes back through returns, not here
+func (service *OrderService) SettleStockReservations(ctx context.Context, now time.Time, lease time.Duration, batchSize int) (int, error) {
+	var items []repository.ReservationToSettle
+	err := service.orderRepository.Transaction(ctx, func(txRepository *repository.OrderRepository) error {
+		statuses := slices.Concat(reservationConfirmingStatuses, reservationReleasingStatuses)
+
+		var err error
+		items, err = txRepository.ClaimReservationsToSettle(ctx, statuses, now.Add(-lease), now, batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var settled int
+	for _, item := range items {
+		status, err := service.settleReservation(ctx, item)
+		if err != nil {
+			log.Printf("Settling reservation %s of order %s: %v", *item.ReservationID, item.OrderID, err)
+			if err := service.orderRepository.UnclaimReservation(ctx, item.ID); err != nil {
+				log.Printf("Unclaiming reservation %s of order %s: %v", *item.ReservationID, item.OrderID, err)
+			}
+			continue
+		}
+
+		if err := service.orderRepository.SettleReservation(ctx, item.ID, status); err != nil {
+			return settled, err
+		}
+		settled++
+	}
+
+	return settled, nil
+}
+
+func (service *OrderService) settleReservation(ctx context.Context, item repository.ReservationToSettle) (string, error) {
+	if slices.Contains(reservationReleasingStatuses, item.OrderStatus) {
+		err := service.warehouse.Release(ctx, *item.ReservationID, "order_"+item.OrderStatus)
+		if err == nil || errors.Is(err, clients.ErrReservationNotReserved) {
+			return ReservationReleased, nil
+		}
+		return "", err
+	}
+
+	err := service.warehouse.Confirm(ctx, *item.ReservationID)
+	if errors.Is(err, clients.ErrReservationNotReserved) {
+		log.Printf("Reservation %s of paid order %s had already lapsed in the warehouse", *item.ReservationID, item.OrderID)
+		return ReservationExpired, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return ReservationConfirmed, nil
+}
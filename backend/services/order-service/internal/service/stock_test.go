@@ -0,0 +1,205 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/coupon"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/service"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/types"
+)
+
+// the single item of a one-line order as stored
+func reservedItem(t *testing.T, f fixture, orderId string) models.OrderItem {
+	t.Helper()
+
+	var items []models.OrderItem
+	if err := f.db.Where(`"orderId" = ?`, orderId).Find(&items).Error; err != nil {
+		t.Fatalf("read order items: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("order has %d items, want 1", len(items))
+	}
+	return items[0]
+}
+
+func assertReservation(t *testing.T, f fixture, item models.OrderItem, status string) {
+	t.Helper()
+
+	if item.ReservationID == nil || item.ReservationStatus == nil || *item.ReservationStatus != status {
+		t.Fatalf("item reservation = %v/%v, want %s", item.ReservationID, item.ReservationStatus, status)
+	}
+	if got := f.warehouse.Status(*item.ReservationID); got != status {
+		t.Fatalf("warehouse has reservation %s as %q, want %q", *item.ReservationID, got, status)
+	}
+}
+
+func createOrder(t *testing.T, f fixture, quantity int) string {
+	t.Helper()
+
+	checkout, err := f.service.CreateOrder(checkoutPayload(quantity), context.Background())
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	return checkout.Orders[0].ID
+}
+
+const testClaimLease = 10 * time.Minute
+
+func settle(t *testing.T, f fixture, want int) {
+	t.Helper()
+	settleAt(t, f, time.Now(), want)
+}
+
+func settleAt(t *testing.T, f fixture, now time.Time, want int) {
+	t.Helper()
+
+	settled, err := f.service.SettleStockReservations(context.Background(), now, testClaimLease, 10)
+	if err != nil {
+		t.Fatalf("SettleStockReservations: %v", err)
+	}
+	if settled != want {
+		t.Fatalf("settled %d reservations, want %d", settled, want)
+	}
+}
+
+func TestCreateOrderReservesHouseBrandStock(t *testing.T) {
+	f := newFixture(t, 10)
+
+	orderId := createOrder(t, f, 2)
+
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationReserved)
+	if available := f.warehouse.Available(testProductID); available != 8 {
+		t.Fatalf("warehouse has %d units left, want 8", available)
+	}
+}
+
+// the coupon is redeemed inside createCheckout after the stock was reserved, nothing may stay held when it fails
+func TestFailedCheckoutReleasesReservedStock(t *testing.T) {
+	f := newFixture(t, 10)
+
+	payload := checkoutPayload(2)
+	payload.CouponCode = "TIDAKADA"
+	if _, err := f.service.CreateOrder(payload, context.Background()); !errors.Is(err, coupon.ErrUnknownCode) {
+		t.Fatalf("CreateOrder error = %v, want %v", err, coupon.ErrUnknownCode)
+	}
+
+	if available := f.warehouse.Available(testProductID); available != 10 {
+		t.Fatalf("warehouse has %d units left, want all 10 back", available)
+	}
+
+	var count int64
+	if err := f.db.Model(&models.Order{}).Where(`"userId" = ?`, testUserID).Count(&count).Error; err != nil {
+		t.Fatalf("count orders: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("%d orders written for a failed checkout", count)
+	}
+}
+
+func TestSettleStockReservationsConfirmsPaidOrder(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	orderId := createOrder(t, f, 2)
+	//nothing to settle while the order waits for payment
+	settle(t, f, 0)
+
+	if err := f.service.MarkOrderPaid(ctx, orderId, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+	settle(t, f, 1)
+
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationConfirmed)
+	if available := f.warehouse.Available(testProductID); available != 8 {
+		t.Fatalf("warehouse has %d units left, want 8", available)
+	}
+
+	//a settled item is not picked up again
+	settle(t, f, 0)
+}
+
+func TestSettleStockReservationsReleasesCancelledOrder(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	orderId := createOrder(t, f, 2)
+	if _, err := f.service.CancelOrder(ctx, orderId, types.CancelOrderPayload{Reason: "changed my mind"}, testUserID, service.ActorCustomer); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	settle(t, f, 1)
+
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationReleased)
+	if available := f.warehouse.Available(testProductID); available != 10 {
+		t.Fatalf("warehouse has %d units left, want all 10 back", available)
+	}
+}
+
+// a refund before the sweep got to the order puts the stock back instead of taking it
+func TestSettleStockReservationsReleasesRefundedOrder(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	orderId := createOrder(t, f, 2)
+	if err := f.service.MarkOrderPaid(ctx, orderId, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+	if _, err := f.service.UpdateOrderStatus(ctx, orderId, types.UpdateOrderStatusPayload{Status: statemachine.StatusRefunded}, testAdminID, service.ActorAdmin); err != nil {
+		t.Fatalf("UpdateOrderStatus(%s): %v", statemachine.StatusRefunded, err)
+	}
+	settle(t, f, 1)
+
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationReleased)
+	if available := f.warehouse.Available(testProductID); available != 10 {
+		t.Fatalf("warehouse has %d units left, want all 10 back", available)
+	}
+}
+
+// a failed warehouse call gives the claim back, the next sweep settles the item
+func TestSettleStockReservationsRetriesFailedWarehouseCalls(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	orderId := createOrder(t, f, 2)
+	if err := f.service.MarkOrderPaid(ctx, orderId, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+
+	f.warehouse.FailSettling(errors.New("connection refused"))
+	settle(t, f, 0)
+	if item := reservedItem(t, f, orderId); item.ReservationClaimedAt != nil {
+		t.Fatalf("failed item still claimed at %s", item.ReservationClaimedAt)
+	}
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationReserved)
+
+	f.warehouse.FailSettling(nil)
+	settle(t, f, 1)
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationConfirmed)
+}
+
+// an item claimed by a sweep that never finished is left alone until its lease ran out
+func TestSettleStockReservationsTakesOverLapsedClaims(t *testing.T) {
+	f := newFixture(t, 10)
+	ctx := context.Background()
+
+	orderId := createOrder(t, f, 2)
+	if err := f.service.MarkOrderPaid(ctx, orderId, "pay-1"); err != nil {
+		t.Fatalf("MarkOrderPaid: %v", err)
+	}
+
+	claimedAt := time.Now()
+	item := reservedItem(t, f, orderId)
+	if err := f.db.Model(&models.OrderItem{}).Where("id = ?", item.ID).Update("reservationClaimedAt", claimedAt).Error; err != nil {
+		t.Fatalf("claim item: %v", err)
+	}
+
+	settleAt(t, f, claimedAt.Add(testClaimLease/2), 0)
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationReserved)
+
+	settleAt(t, f, claimedAt.Add(testClaimLease+time.Minute), 1)
+	assertReservation(t, f, reservedItem(t, f, orderId), service.ReservationConfirmed)
+}
@@ -0,0 +1,110 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+)
+
+const (
+	StatusPending           = "pending"
+	StatusAwaitingPayment   = "awaiting_payment"
+	StatusPaid              = "paid"
+	StatusConfirmed         = "confirmed"
+	StatusProcessing        = "processing"
+	StatusReadyToShip       = "ready_to_ship"
+	StatusShipped           = "shipped"
+	StatusInTransit         = "in_transit"
+	StatusOutForDelivery    = "out_for_delivery"
+	StatusDelivered         = "delivered"
+	StatusCompleted         = "completed"
+	StatusCancelled         = "cancelled"
+	StatusRefunded          = "refunded"
+	StatusPartiallyRefunded = "partially_refunded"
+)
+
+var (
+	ErrUnknownStatus     = errors.New("unknown order status")
+	ErrInvalidTransition = errors.New("invalid order status transition")
+)
+
+// every status the order schema knows about, mapped to the statuses it may move to
+var transitions = map[string][]string{
+	StatusPending:           {StatusAwaitingPayment, StatusPaid, StatusCancelled},
+	StatusAwaitingPayment:   {StatusPaid, StatusCancelled},
+	StatusPaid:              {StatusConfirmed, StatusCancelled, StatusRefunded},
+	StatusConfirmed:         {StatusProcessing, StatusCancelled, StatusRefunded},
+	StatusProcessing:        {StatusReadyToShip, StatusCancelled, StatusRefunded},
+	StatusReadyToShip:       {StatusShipped, StatusCancelled, StatusRefunded},
+	StatusShipped:           {StatusInTransit, StatusDelivered},
+	StatusInTransit:         {StatusOutForDelivery, StatusDelivered},
+	StatusOutForDelivery:    {StatusDelivered},
+	StatusDelivered:         {StatusCompleted, StatusPartiallyRefunded, StatusRefunded},
+	StatusCompleted:         {StatusPartiallyRefunded, StatusRefunded},
+	StatusPartiallyRefunded: {StatusRefunded},
+	StatusCancelled:         {},
+	StatusRefunded:          {},
+}
+
+func IsKnownStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// customers may cancel until the order is being prepared, after that only an admin can
+var customerCancellable = map[string]bool{
+	StatusPending:         true,
+	StatusAwaitingPayment: true,
+	StatusPaid:            true,
+	StatusConfirmed:       true,
+}
+
+func CanCustomerCancel(status string) bool {
+	return customerCancellable[status]
+}
+
+// nothing has been paid yet, cancelling needs no refund
+func IsUnpaid(status string) bool {
+	return status == StatusPending || status == StatusAwaitingPayment
+}
+
+func CanTransition(from string, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// moves the order to the next status and stamps the timestamp that belongs to it,
+// the order is left untouched when the transition is not allowed
+func Transition(order *models.Order, to string, at time.Time) error {
+	if !IsKnownStatus(to) {
+		return fmt.Errorf("%w: %s", ErrUnknownStatus, to)
+	}
+
+	if !CanTransition(order.Status, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, order.Status, to)
+	}
+
+	order.Status = to
+
+	switch to {
+	case StatusPaid:
+		order.PaidAt = &at
+	case StatusShipped:
+		order.ShippedAt = &at
+	case StatusDelivered:
+		order.DeliveredAt = &at
+	case StatusCompleted:
+		order.CompletedAt = &at
+	case StatusCancelled:
+		order.CancelledAt = &at
+	}
+
+	return nil
+}
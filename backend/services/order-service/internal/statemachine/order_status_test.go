@@ -0,0 +1,107 @@
+package statemachine_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/internal/statemachine"
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+)
+
+func TestTransitionStampsTimestamp(t *testing.T) {
+	at := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		from  string
+		to    string
+		stamp func(order models.Order) *time.Time
+	}{
+		{statemachine.StatusPending, statemachine.StatusPaid, func(o models.Order) *time.Time { return o.PaidAt }},
+		{statemachine.StatusAwaitingPayment, statemachine.StatusPaid, func(o models.Order) *time.Time { return o.PaidAt }},
+		{statemachine.StatusReadyToShip, statemachine.StatusShipped, func(o models.Order) *time.Time { return o.ShippedAt }},
+		{statemachine.StatusShipped, statemachine.StatusDelivered, func(o models.Order) *time.Time { return o.DeliveredAt }},
+		{statemachine.StatusOutForDelivery, statemachine.StatusDelivered, func(o models.Order) *time.Time { return o.DeliveredAt }},
+		{statemachine.StatusDelivered, statemachine.StatusCompleted, func(o models.Order) *time.Time { return o.CompletedAt }},
+		{statemachine.StatusPending, statemachine.StatusCancelled, func(o models.Order) *time.Time { return o.CancelledAt }},
+		{statemachine.StatusReadyToShip, statemachine.StatusCancelled, func(o models.Order) *time.Time { return o.CancelledAt }},
+		{statemachine.StatusPaid, statemachine.StatusConfirmed, nil},
+		{statemachine.StatusCompleted, statemachine.StatusPartiallyRefunded, nil},
+		{statemachine.StatusPartiallyRefunded, statemachine.StatusRefunded, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			order := models.Order{Status: tt.from}
+			if err := statemachine.Transition(&order, tt.to, at); err != nil {
+				t.Fatalf("Transition: %v", err)
+			}
+			if order.Status != tt.to {
+				t.Fatalf("status = %s, want %s", order.Status, tt.to)
+			}
+			if tt.stamp != nil {
+				if stamped := tt.stamp(order); stamped == nil || !stamped.Equal(at) {
+					t.Fatalf("timestamp = %v, want %v", stamped, at)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitionRejectsIllegalMoves(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		err  error
+	}{
+		{statemachine.StatusPending, statemachine.StatusShipped, statemachine.ErrInvalidTransition},
+		{statemachine.StatusPaid, statemachine.StatusPending, statemachine.ErrInvalidTransition},
+		{statemachine.StatusAwaitingPayment, statemachine.StatusPending, statemachine.ErrInvalidTransition},
+		{statemachine.StatusShipped, statemachine.StatusCancelled, statemachine.ErrInvalidTransition},
+		{statemachine.StatusDelivered, statemachine.StatusCancelled, statemachine.ErrInvalidTransition},
+		{statemachine.StatusDelivered, statemachine.StatusShipped, statemachine.ErrInvalidTransition},
+		{statemachine.StatusPartiallyRefunded, statemachine.StatusCompleted, statemachine.ErrInvalidTransition},
+		{statemachine.StatusCancelled, statemachine.StatusPaid, statemachine.ErrInvalidTransition},
+		{statemachine.StatusRefunded, statemachine.StatusCompleted, statemachine.ErrInvalidTransition},
+		{statemachine.StatusCompleted, statemachine.StatusCompleted, statemachine.ErrInvalidTransition},
+		{"lost", statemachine.StatusPaid, statemachine.ErrInvalidTransition},
+		{statemachine.StatusPending, "lost", statemachine.ErrUnknownStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+"->"+tt.to, func(t *testing.T) {
+			order := models.Order{Status: tt.from}
+			err := statemachine.Transition(&order, tt.to, time.Now())
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Transition error = %v, want %v", err, tt.err)
+			}
+			//a rejected transition leaves the order as it was
+			if order.Status != tt.from || order.PaidAt != nil || order.ShippedAt != nil || order.CompletedAt != nil || order.CancelledAt != nil {
+				t.Fatalf("order changed by a rejected transition: %+v", order)
+			}
+		})
+	}
+}
+
+func TestCanCustomerCancel(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{statemachine.StatusPending, true},
+		{statemachine.StatusAwaitingPayment, true},
+		{statemachine.StatusPaid, true},
+		{statemachine.StatusConfirmed, true},
+		{statemachine.StatusProcessing, false},
+		{statemachine.StatusReadyToShip, false},
+		{statemachine.StatusShipped, false},
+		{statemachine.StatusDelivered, false},
+		{statemachine.StatusCancelled, false},
+	}
+
+	for _, tt := range tests {
+		if got := statemachine.CanCustomerCancel(tt.status); got != tt.want {
+			t.Errorf("CanCustomerCancel(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+)
+
+const (
+	ReturnRequested        = "requested"
+	ReturnApproved         = "approved"
+	ReturnRejected         = "rejected"
+	ReturnAwaitingShipment = "awaiting_shipment"
+	ReturnInTransit        = "in_transit"
+	ReturnReceived         = "received"
+	ReturnInspecting       = "inspecting"
+	ReturnCompleted        = "completed"
+	ReturnCancelled        = "cancelled"
+)
+
+var ErrInvalidReturnTransition = errors.New("invalid return status transition")
+
+var returnTransitions = map[string][]string{
+	ReturnRequested:        {ReturnApproved, ReturnRejected, ReturnCancelled},
+	ReturnApproved:         {ReturnAwaitingShipment, ReturnInTransit, ReturnCancelled},
+	ReturnAwaitingShipment: {ReturnInTransit, ReturnCancelled},
+	ReturnInTransit:        {ReturnReceived},
+	ReturnReceived:         {ReturnInspecting},
+	ReturnInspecting:       {ReturnCompleted, ReturnRejected},
+	ReturnRejected:         {},
+	ReturnCompleted:        {},
+	ReturnCancelled:        {},
+}
+
+// returns still holding quantity of their order items, a new return can only claim what is left
+func IsOpenReturn(status string) bool {
+	return status != ReturnRejected && status != ReturnCompleted && status != ReturnCancelled
+}
+
+func CanTransitionReturn(from string, to string) bool {
+	for _, next := range returnTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// moves the return to the next status and stamps approvedAt/rejectedAt, the return is left untouched when not allowed
+func TransitionReturn(ret *models.Return, to string, actorId *string, at time.Time) error {
+	if !CanTransitionReturn(ret.Status, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidReturnTransition, ret.Status, to)
+	}
+
+	ret.Status = to
+
+	switch to {
+	case ReturnApproved:
+		ret.ApprovedAt = &at
+		ret.ApprovedBy = actorId
+	case ReturnRejected:
+		ret.RejectedAt = &at
+		ret.RejectedBy = actorId
+	}
+
+	return nil
+}
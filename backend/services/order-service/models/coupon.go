@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Coupon struct {
+	ID                    string           `gorm:"column:id;type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	Code                  string           `gorm:"column:code;type:varchar(50);uniqueIndex;not null" json:"code"`
+	Name                  string           `gorm:"column:name;type:varchar(255);not null" json:"name"`
+	Description           *string          `gorm:"column:description;type:text;null" json:"description"`
+	DiscountType          string           `gorm:"column:discountType;type:varchar(50);not null" json:"discount_type"`
+	DiscountValue         decimal.Decimal  `gorm:"column:discountValue;type:decimal(15, 2);not null" json:"discount_value"`
+	MinOrderAmount        *decimal.Decimal `gorm:"column:minOrderAmount;type:decimal(15, 2);null" json:"min_order_amount"`
+	MaxDiscountAmount     *decimal.Decimal `gorm:"column:maxDiscountAmount;type:decimal(15, 2);null" json:"max_discount_amount"`
+	TotalUsageLimit       *int             `gorm:"column:totalUsageLimit;null" json:"total_usage_limit"`
+	PerUserLimit          int              `gorm:"column:perUserLimit;not null;default:1" json:"per_user_limit"`
+	UsedCount             int              `gorm:"column:usedCount;not null;default:0" json:"used_count"`
+	ApplicableBrandIDs    UUIDArray        `gorm:"column:applicableBrandIds;type:uuid[]" json:"applicable_brand_ids"`
+	ApplicableCategoryIDs UUIDArray        `gorm:"column:applicableCategoryIds;type:uuid[]" json:"applicable_category_ids"`
+	ApplicableProductIDs  UUIDArray        `gorm:"column:applicableProductIds;type:uuid[]" json:"applicable_product_ids"`
+	ExcludedProductIDs    UUIDArray        `gorm:"column:excludedProductIds;type:uuid[]" json:"excluded_product_ids"`
+	NewCustomersOnly      bool             `gorm:"column:newCustomersOnly;not null;default:false" json:"new_customers_only"`
+	StartsAt              time.Time        `gorm:"column:startsAt;not null" json:"starts_at"`
+	ExpiresAt             time.Time        `gorm:"column:expiresAt;not null" json:"expires_at"`
+	IsActive              bool             `gorm:"column:isActive;not null" json:"is_active"` //no gorm default, it would turn an explicit false into true on create
+	Source                string           `gorm:"column:source;type:varchar(50);not null;default:manual" json:"source"`
+	LiveSessionID         *string          `gorm:"column:liveSessionId;type:uuid;null" json:"live_session_id"`
+	CampaignID            *string          `gorm:"column:campaignId;type:uuid;null" json:"campaign_id"`
+	CreatedBy             *string          `gorm:"column:createdBy;type:uuid;null" json:"created_by"`
+	CreatedAt             time.Time        `gorm:"column:createdAt;not null" json:"created_at"`
+	UpdatedAt             time.Time        `gorm:"column:updatedAt;not null" json:"updated_at"`
+	DeletedAt             *time.Time       `gorm:"column:deletedAt;null;index" json:"deleted_at,omitempty"`
+}
+
+func (Coupon) TableName() string {
+	return "coupon"
+}
+
+type CouponUsage struct {
+	ID             string          `gorm:"column:id;type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	CouponID       string          `gorm:"column:couponId;type:uuid;not null;index" json:"coupon_id"`
+	UserID         string          `gorm:"column:userId;type:uuid;not null;index" json:"user_id"`
+	OrderID        string          `gorm:"column:orderId;type:uuid;not null;index" json:"order_id"`
+	DiscountAmount decimal.Decimal `gorm:"column:discountAmount;type:decimal(15, 2);not null" json:"discount_amount"`
+	UsedAt         time.Time       `gorm:"column:usedAt;not null" json:"used_at"`
+}
+
+func (CouponUsage) TableName() string {
+	return "coupon_usage"
+}
+
+// postgres uuid[] column, empty means no restriction. uuids never need quoting in the array literal
+type UUIDArray []string
+
+func (a *UUIDArray) Scan(value interface{}) error {
+	var literal string
+	switch v := value.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		literal = string(v)
+	case string:
+		literal = v
+	default:
+		return errors.New("unsupported type for uuid array")
+	}
+
+	literal = strings.Trim(literal, "{}")
+	if literal == "" {
+		*a = UUIDArray{}
+		return nil
+	}
+
+	*a = strings.Split(literal, ",")
+	return nil
+}
+
+func (a UUIDArray) Value() (driver.Value, error) {
+	return "{" + strings.Join(a, ",") + "}", nil
+}
+
+func (a UUIDArray) Contains(id string) bool {
+	for _, value := range a {
+		if value == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// a group buy (grosir) of one product, orders joining it are held after payment until PaidQuantity reaches MinQuantity
+type GroupSession struct {
+	ID             string          `gorm:"column:id;type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ProductID      string          `gorm:"column:productId;type:uuid;not null;index" json:"product_id"`
+	Title          string          `gorm:"column:title;type:varchar(255);not null" json:"title"`
+	UnitPrice      decimal.Decimal `gorm:"column:unitPrice;type:bigint;not null" json:"unit_price"` // the group price every participant pays
+	MinQuantity    int             `gorm:"column:minQuantity;not null" json:"min_quantity"`
+	MaxQuantity    *int            `gorm:"column:maxQuantity;null" json:"max_quantity"`
+	JoinedQuantity int             `gorm:"column:joinedQuantity;not null;default:0" json:"joined_quantity"` // units on orders that are not cancelled
+	PaidQuantity   int             `gorm:"column:paidQuantity;not null;default:0" json:"paid_quantity"`     // of those, units already paid for
+	Status         string          `gorm:"column:status;type:varchar(20);not null;index" json:"status"`     // open, filled or expired
+	ExpiresAt      time.Time       `gorm:"column:expiresAt;not null;index" json:"expires_at"`
+	FilledAt       *time.Time      `gorm:"column:filledAt;null" json:"filled_at"`
+	ExpiredAt      *time.Time      `gorm:"column:expiredAt;null" json:"expired_at"`
+	CreatedBy      *string         `gorm:"column:createdBy;type:uuid;null" json:"created_by"`
+	CreatedAt      time.Time       `gorm:"column:createdAt;not null" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"column:updatedAt;not null" json:"updated_at"`
+}
+
+func (GroupSession) TableName() string {
+	return "group_session"
+}
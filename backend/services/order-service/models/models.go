@@ -8,66 +8,109 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// mirrors the Order model of the prisma schema, camelCase columns in the singular order table
 type Order struct {
-	ID                    string          `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	OrderNumber           string          `gorm:"uniqueIndex;not null" json:"order_number"`
-	UserID                string          `gorm:"type:uuid;not null" json:"user_id"`
-	GroupSessionID        *string         `gorm:"type:uuid;null" json:"group_session_id"`
-	Status                string          `gorm:"type:varchar(50);not null" json:"status"`
-	Subtotal              decimal.Decimal `gorm:"type:decimal(10, 2)" json:"subtotal"`
-	ShippingCost          decimal.Decimal `gorm:"type:bigint;not null" json:"shipping_cost"`
-	TaxAmount             decimal.Decimal `gorm:"type:bigint;not null" json:"tax_amount"`
-	DiscountAmount        decimal.Decimal `gorm:"type:bigint;not null" json:"discount_amount"`
-	TotalAmount           decimal.Decimal `gorm:"type:bigint;not null" json:"total_amount"`
-	ShippingName          string          `gorm:"type:varchar(255);not null" json:"shipping_name"`
-	ShippingPhone         string          `gorm:"type:varchar(20);not null" json:"shipping_phone"`
-	ShippingProvince      string          `gorm:"type:varchar(100);not null" json:"shipping_province"`
-	ShippingCity          string          `gorm:"type:varchar(100);not null" json:"shipping_city"`
-	ShippingDistrict      string          `gorm:"type:varchar(100);not null" json:"shipping_district"`
-	ShippingPostalCode    string          `gorm:"type:varchar(10);not null" json:"shipping_postal_code"`
-	ShippingAddress       string          `gorm:"type:text;not null" json:"shipping_address"`
-	ShippingNotes         *string         `gorm:"type:text;null" json:"shipping_notes"`
-	EstimatedDeliveryDate *time.Time      `gorm:"null" json:"estimated_delivery_date"`
-	PaidAt                *time.Time      `gorm:"null" json:"paid_at"`
-	ShippedAt             *time.Time      `gorm:"null" json:"shipped_at"`
-	DeliveredAt           *time.Time      `gorm:"null" json:"delivered_at"`
-	CancelledAt           *time.Time      `gorm:"null" json:"cancelled_at"`
-	CreatedAt             time.Time       `gorm:"not null" json:"created_at"`
-	UpdatedAt             time.Time       `gorm:"not null" json:"updated_at"`
+	ID                    string          `gorm:"column:id;type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	OrderNumber           string          `gorm:"column:orderNumber;type:varchar(50);uniqueIndex;not null" json:"order_number"`
+	UserID                string          `gorm:"column:userId;type:uuid;not null;index" json:"user_id"`
+	GroupSessionID        *string         `gorm:"column:groupSessionId;type:uuid;null;index" json:"group_session_id"`
+	CheckoutID            *string         `gorm:"column:checkoutId;type:uuid;null;index" json:"checkout_id"`                      // shared by the orders one checkout was split into
+	OrderSource           string          `gorm:"column:orderSource;type:varchar(50);not null;default:brand" json:"order_source"` // brand, seller or live_commerce
+	BrandID               *string         `gorm:"column:brandId;type:uuid;null;index" json:"brand_id"`
+	SellerID              *string         `gorm:"column:sellerId;type:uuid;null;index" json:"seller_id"`
+	Status                string          `gorm:"column:status;type:varchar(50);not null;index" json:"status"`
+	Subtotal              decimal.Decimal `gorm:"column:subtotal;type:decimal(15,2);not null" json:"subtotal"`
+	ShippingCost          decimal.Decimal `gorm:"column:shippingCost;type:decimal(15,2);not null" json:"shipping_cost"`
+	TaxAmount             decimal.Decimal `gorm:"column:taxAmount;type:decimal(15,2);not null" json:"tax_amount"`
+	DiscountAmount        decimal.Decimal `gorm:"column:discountAmount;type:decimal(15,2);not null" json:"discount_amount"`
+	CouponID              *string         `gorm:"column:couponId;type:uuid;null" json:"coupon_id"`
+	CouponCode            *string         `gorm:"column:couponCode;type:varchar(50);null" json:"coupon_code"`
+	TotalAmount           decimal.Decimal `gorm:"column:totalAmount;type:decimal(15,2);not null" json:"total_amount"`
+	PriceBreakdown        PriceBreakdown  `gorm:"column:priceBreakdown;type:jsonb" json:"price_breakdown"`
+	ShippingCourier       *string         `gorm:"column:shippingCourier;type:varchar(50);null" json:"shipping_courier"`
+	ShippingMethod        *string         `gorm:"column:shippingMethod;type:varchar(100);null" json:"shipping_method"` // the courier's service code
+	ShippingName          string          `gorm:"column:shippingRecipient;type:varchar(255);not null" json:"shipping_name"`
+	ShippingPhone         string          `gorm:"column:shippingPhone;type:varchar(20);not null" json:"shipping_phone"`
+	ShippingProvince      string          `gorm:"column:shippingProvince;type:varchar(100);not null" json:"shipping_province"`
+	ShippingCity          string          `gorm:"column:shippingCity;type:varchar(100);not null" json:"shipping_city"`
+	ShippingDistrict      string          `gorm:"column:shippingDistrict;type:varchar(100);null" json:"shipping_district"`
+	ShippingPostalCode    string          `gorm:"column:shippingPostalCode;type:varchar(10);not null" json:"shipping_postal_code"`
+	ShippingAddress       string          `gorm:"column:shippingStreet;type:text;not null" json:"shipping_address"`
+	ShippingNotes         *string         `gorm:"column:customerNotes;type:text;null" json:"shipping_notes"`
+	CustomerName          string          `gorm:"column:customerName;type:varchar(255);not null" json:"customer_name"` // who ordered, as they were at checkout
+	CustomerPhone         string          `gorm:"column:customerPhone;type:varchar(20);not null" json:"customer_phone"`
+	CustomerEmail         *string         `gorm:"column:customerEmail;type:varchar(255);null" json:"customer_email"`
+	EstimatedDeliveryDate *time.Time      `gorm:"column:estimatedDelivery;null" json:"estimated_delivery_date"`
+	PaidAt                *time.Time      `gorm:"column:paidAt;null" json:"paid_at"`
+	ShippedAt             *time.Time      `gorm:"column:shippedAt;null" json:"shipped_at"`
+	DeliveredAt           *time.Time      `gorm:"column:deliveredAt;null" json:"delivered_at"`
+	CompletedAt           *time.Time      `gorm:"column:completedAt;null" json:"completed_at"`
+	CancelledAt           *time.Time      `gorm:"column:cancelledAt;null" json:"cancelled_at"`
+	CancelReason          *string         `gorm:"column:cancelReason;type:varchar(500);null" json:"cancel_reason"`
+	CancelledBy           *string         `gorm:"column:cancelledBy;type:varchar(50);null" json:"cancelled_by"` // customer, admin or system
+	IdempotencyKey        *string         `gorm:"column:idempotencyKey;type:varchar(100);uniqueIndex;null" json:"-"`
+	CreatedAt             time.Time       `gorm:"column:createdAt;not null;index" json:"created_at"`
+	UpdatedAt             time.Time       `gorm:"column:updatedAt;not null" json:"updated_at"`
 
 	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"order_items"`
 	User       User        `gorm:"foreignKey:UserID" json:"users"`
 }
 
+func (Order) TableName() string {
+	return "order"
+}
+
+// mirrors the OrderItem model of the prisma schema, the snapshot columns keep what the product was when ordered
 type OrderItem struct {
-	ID              string          `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
-	OrderID         string          `gorm:"type:uuid;not null" json:"order_id"`
-	ProductID       string          `gorm:"type:uuid;not null" json:"product_id"`
-	VariantID       *string         `gorm:"type:uuid;null" json:"variant_id"`
-	FactoryID       string          `gorm:"type:uuid;not null" json:"factory_id"`
-	SKU             string          `gorm:"type:varchar(100);not null" json:"sku"`
-	ProductName     string          `gorm:"type:varchar(255);not null" json:"product_name"`
-	VariantName     *string         `gorm:"type:varchar(255);null" json:"variant_name"`
-	Quantity        int             `gorm:"not null" json:"quantity"`
-	UnitPrice       decimal.Decimal `gorm:"type:bigint;not null" json:"unit_price"`
-	Subtotal        decimal.Decimal `gorm:"type:bigint;not null" json:"subtotal"`
-	ProductSnapshot types.JSONB     `gorm:"type:jsonb" json:"product_snapshot"`
-	CreatedAt       time.Time       `gorm:"not null" json:"created_at"`
+	ID                   string          `gorm:"column:id;type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	OrderID              string          `gorm:"column:orderId;type:uuid;not null;index" json:"order_id"`
+	ProductID            string          `gorm:"column:productId;type:uuid;null;index" json:"product_id"`
+	VariantID            *string         `gorm:"column:variantId;type:uuid;null" json:"variant_id"`
+	FactoryID            string          `gorm:"column:factoryId;type:uuid;null;index" json:"factory_id"`
+	BrandID              *string         `gorm:"column:brandId;type:uuid;null" json:"brand_id"`
+	SellerID             *string         `gorm:"column:sellerId;type:uuid;null" json:"seller_id"`
+	SKU                  string          `gorm:"column:snapshotSku;type:varchar(100);null" json:"sku"`
+	ProductName          string          `gorm:"column:snapshotProductName;type:varchar(255);not null" json:"product_name"`
+	VariantName          *string         `gorm:"column:snapshotVariantName;type:varchar(255);null" json:"variant_name"`
+	Quantity             int             `gorm:"column:quantity;not null" json:"quantity"`
+	ReturnedQuantity     int             `gorm:"column:returnedQuantity;not null;default:0" json:"returned_quantity"`
+	UnitPrice            decimal.Decimal `gorm:"column:unitPrice;type:decimal(15,2);not null" json:"unit_price"`
+	Subtotal             decimal.Decimal `gorm:"column:subtotal;type:decimal(15,2);not null" json:"subtotal"`
+	DiscountAmount       decimal.Decimal `gorm:"column:discountAmount;type:decimal(15,2);not null;default:0" json:"discount_amount"`
+	TotalAmount          decimal.Decimal `gorm:"column:totalAmount;type:decimal(15,2);not null" json:"total_amount"` // subtotal less the discount, set by priceOrder
+	ProductSnapshot      types.JSONB     `gorm:"column:productSnapshot;type:jsonb" json:"product_snapshot"`
+	ReservationID        *string         `gorm:"column:reservationId;type:uuid;null" json:"reservation_id"`                      // warehouse stock held for house brand items, nil for seller items
+	ReservationStatus    *string         `gorm:"column:reservationStatus;type:varchar(20);null;index" json:"reservation_status"` // reserved, confirmed, released or expired
+	ReservationClaimedAt *time.Time      `gorm:"column:reservationClaimedAt;null" json:"-"`                                      // when a sweep took the reservation to settle it
+	CreatedAt            time.Time       `gorm:"column:createdAt;not null" json:"created_at"`
 
 	Order   Order   `gorm:"foreignKey:OrderID" json:"-"`
 	Product Product `gorm:"foreignKey:ProductID" json:"products"`
 	Factory Factory `gorm:"foreignKey:FactoryID" json:"factories"`
 }
 
+func (OrderItem) TableName() string {
+	return "order_item"
+}
+
 type Product struct {
-	ID              string `gorm:"type:uuid;primaryKey" json:"id"`
-	Name            string `gorm:"not null" json:"name"`
-	PrimaryImageURL string `gorm:"type:text" json:"primary_image_url"`
+	ID              string          `gorm:"type:uuid;primaryKey" json:"id"`
+	FactoryID       string          `gorm:"type:uuid;not null" json:"factory_id"`
+	BrandID         *string         `gorm:"type:uuid;null" json:"brand_id"`
+	SellerID        *string         `gorm:"type:uuid;null" json:"seller_id"`
+	CategoryID      *string         `gorm:"type:uuid;null" json:"category_id"`
+	SKU             string          `gorm:"type:varchar(100);not null" json:"sku"`
+	Name            string          `gorm:"not null" json:"name"`
+	BasePrice       decimal.Decimal `gorm:"type:bigint;not null" json:"base_price"`
+	PrimaryImageURL string          `gorm:"type:text" json:"primary_image_url"`
+
+	Factory Factory `gorm:"foreignKey:FactoryID" json:"factories"`
 }
 
 type Factory struct {
 	ID          string `gorm:"type:uuid;primaryKey" json:"id"`
 	FactoryName string `gorm:"not null" json:"factory_name"`
+	City        string `gorm:"type:varchar(100)" json:"city"`
 }
 
 type User struct {
@@ -76,3 +119,21 @@ type User struct {
 	LastName  string `gorm:"not null" json:"last_name"`
 	Email     string `gorm:"uniqueIndex;not null" json:"email"`
 }
+
+// mirrors the OrderStatusHistory model of the prisma schema, camelCase columns in the singular order_status_history
+type OrderStatusHistory struct {
+	ID            string      `gorm:"column:id;type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	OrderID       string      `gorm:"column:orderId;type:uuid;not null;index" json:"order_id"`
+	FromStatus    *string     `gorm:"column:fromStatus;type:varchar(50);null" json:"from_status"`
+	ToStatus      string      `gorm:"column:toStatus;type:varchar(50);not null" json:"to_status"`
+	Reason        *string     `gorm:"column:reason;type:varchar(500);null" json:"reason"`
+	Notes         *string     `gorm:"column:notes;type:text;null" json:"notes"`
+	ChangedBy     *string     `gorm:"column:changedBy;type:varchar(100);null" json:"changed_by"` // whatever x-user-id carried, not necessarily a uuid
+	ChangedByType *string     `gorm:"column:changedByType;type:varchar(50);null" json:"changed_by_type"`
+	Metadata      types.JSONB `gorm:"column:metadata;type:jsonb" json:"metadata"`
+	CreatedAt     time.Time   `gorm:"column:createdAt;not null;index" json:"created_at"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
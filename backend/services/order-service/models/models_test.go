@@ -0,0 +1,98 @@
+package models_test
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+	"gorm.io/gorm/schema"
+)
+
+// the database is created by prisma db push, gorm only reads and writes what the schema defines
+const prismaSchema = "../../../../order-service-schema.prisma"
+
+type prismaModel struct {
+	table  string
+	fields map[string]bool
+}
+
+var mapPattern = regexp.MustCompile(`@@map\("([^"]+)"\)`)
+
+func readPrismaModels(t *testing.T) map[string]prismaModel {
+	t.Helper()
+
+	file, err := os.Open(prismaSchema)
+	if err != nil {
+		t.Fatalf("open prisma schema: %v", err)
+	}
+	defer file.Close()
+
+	parsed := make(map[string]prismaModel)
+	var name string
+	var current prismaModel
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "model "):
+			name = strings.Fields(line)[1]
+			current = prismaModel{fields: make(map[string]bool)}
+		case name == "":
+		case line == "}":
+			parsed[name] = current
+			name = ""
+		case mapPattern.MatchString(line):
+			current.table = mapPattern.FindStringSubmatch(line)[1]
+		case line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "@@"):
+		default:
+			current.fields[strings.Fields(line)[0]] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read prisma schema: %v", err)
+	}
+	return parsed
+}
+
+func TestModelsMatchPrismaSchema(t *testing.T) {
+	prisma := readPrismaModels(t)
+
+	tests := []struct {
+		model  string
+		record any
+	}{
+		{model: "Order", record: &models.Order{}},
+		{model: "OrderItem", record: &models.OrderItem{}},
+		{model: "OrderStatusHistory", record: &models.OrderStatusHistory{}},
+		{model: "Return", record: &models.Return{}},
+		{model: "Coupon", record: &models.Coupon{}},
+		{model: "CouponUsage", record: &models.CouponUsage{}},
+		{model: "GroupSession", record: &models.GroupSession{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			want, ok := prisma[tt.model]
+			if !ok {
+				t.Fatalf("no model %s in the prisma schema", tt.model)
+			}
+
+			parsed, err := schema.Parse(tt.record, &sync.Map{}, schema.NamingStrategy{})
+			if err != nil {
+				t.Fatalf("parse %s: %v", tt.model, err)
+			}
+			if parsed.Table != want.table {
+				t.Errorf("table = %q, want %q", parsed.Table, want.table)
+			}
+			for _, field := range parsed.Fields {
+				if field.DBName != "" && !want.fields[field.DBName] {
+					t.Errorf("%s.%s maps to column %q, which prisma doesn't define", tt.model, field.Name, field.DBName)
+				}
+			}
+		})
+	}
+}
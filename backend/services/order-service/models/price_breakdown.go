@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/shopspring/decimal"
+)
+
+// one step of pricing an order, Amount is what the step added (negative for discounts) and Total the running
+// total after it
+type PriceStep struct {
+	Step        string          `json:"step"`
+	Description string          `json:"description"`
+	Amount      decimal.Decimal `json:"amount"`
+	Total       decimal.Decimal `json:"total"`
+}
+
+// every step in the order it ran, stored as a json array so the totals on the order can be traced back
+type PriceBreakdown []PriceStep
+
+func (breakdown *PriceBreakdown) Scan(value interface{}) error {
+	return scanJSON(value, breakdown)
+}
+
+func (breakdown PriceBreakdown) Value() (interface{}, error) {
+	if breakdown == nil {
+		return "[]", nil
+	}
+	return json.Marshal(breakdown)
+}
+
+// the amount the named step recorded, zero when it didn't run
+func (breakdown PriceBreakdown) Amount(step string) decimal.Decimal {
+	for _, entry := range breakdown {
+		if entry.Step == step {
+			return entry.Amount
+		}
+	}
+	return decimal.Zero
+}
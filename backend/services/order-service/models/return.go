@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Return struct {
+	ID               string          `gorm:"column:id;type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ReturnNumber     string          `gorm:"column:returnNumber;type:varchar(50);uniqueIndex;not null" json:"return_number"`
+	OrderID          string          `gorm:"column:orderId;type:uuid;not null;index" json:"order_id"`
+	UserID           string          `gorm:"column:userId;type:uuid;not null;index" json:"user_id"`
+	ReturnType       string          `gorm:"column:returnType;type:varchar(50);not null" json:"return_type"`
+	Reason           string          `gorm:"column:reason;type:varchar(50);not null" json:"reason"`
+	ReasonDetail     *string         `gorm:"column:reasonDetail;type:varchar(500);null" json:"reason_detail"`
+	CustomerNotes    *string         `gorm:"column:customerNotes;type:text;null" json:"customer_notes"`
+	Items            ReturnItems     `gorm:"column:items;type:jsonb;not null" json:"items"`
+	SubtotalAmount   decimal.Decimal `gorm:"column:subtotalAmount;type:bigint;not null" json:"subtotal_amount"`
+	ShippingRefund   decimal.Decimal `gorm:"column:shippingRefund;type:bigint;not null" json:"shipping_refund"`
+	TotalAmount      decimal.Decimal `gorm:"column:totalAmount;type:bigint;not null" json:"total_amount"`
+	ReturnTrackingNo *string         `gorm:"column:returnTrackingNo;type:varchar(100);null" json:"return_tracking_no"`
+	ReturnCourier    *string         `gorm:"column:returnCourier;type:varchar(50);null" json:"return_courier"`
+	InspectedAt      *time.Time      `gorm:"column:inspectedAt;null" json:"inspected_at"`
+	InspectedBy      *string         `gorm:"column:inspectedBy;type:uuid;null" json:"inspected_by"`
+	InspectionNotes  *string         `gorm:"column:inspectionNotes;type:text;null" json:"inspection_notes"`
+	ItemCondition    *string         `gorm:"column:itemCondition;type:varchar(50);null" json:"item_condition"`
+	Status           string          `gorm:"column:status;type:varchar(50);not null;index" json:"status"`
+	Images           StringList      `gorm:"column:images;type:jsonb" json:"images"`
+	RefundMethod     *string         `gorm:"column:refundMethod;type:varchar(50);null" json:"refund_method"`
+	RefundedAt       *time.Time      `gorm:"column:refundedAt;null" json:"refunded_at"`
+	ApprovedBy       *string         `gorm:"column:approvedBy;type:uuid;null" json:"approved_by"`
+	ApprovedAt       *time.Time      `gorm:"column:approvedAt;null" json:"approved_at"`
+	RejectedBy       *string         `gorm:"column:rejectedBy;type:uuid;null" json:"rejected_by"`
+	RejectedAt       *time.Time      `gorm:"column:rejectedAt;null" json:"rejected_at"`
+	RejectionReason  *string         `gorm:"column:rejectionReason;type:varchar(500);null" json:"rejection_reason"`
+	RequestedAt      time.Time       `gorm:"column:requestedAt;not null" json:"requested_at"`
+	Deadline         time.Time       `gorm:"column:deadline;not null" json:"deadline"`
+	CreatedAt        time.Time       `gorm:"column:createdAt;not null" json:"created_at"`
+	UpdatedAt        time.Time       `gorm:"column:updatedAt;not null" json:"updated_at"`
+}
+
+func (Return) TableName() string {
+	return "return"
+}
+
+// amount is what the returned units cost the customer, priced when the return is requested
+type ReturnItem struct {
+	OrderItemID string          `json:"orderItemId"`
+	Quantity    int             `json:"quantity"`
+	Reason      string          `json:"reason,omitempty"`
+	Amount      decimal.Decimal `json:"amount"`
+}
+
+// stored as a json array, the same shape the prisma schema documents for Return.items
+type ReturnItems []ReturnItem
+
+func (items *ReturnItems) Scan(value interface{}) error {
+	return scanJSON(value, items)
+}
+
+func (items ReturnItems) Value() (interface{}, error) {
+	if items == nil {
+		return "[]", nil
+	}
+	return json.Marshal(items)
+}
+
+type StringList []string
+
+func (list *StringList) Scan(value interface{}) error {
+	return scanJSON(value, list)
+}
+
+func (list StringList) Value() (interface{}, error) {
+	if list == nil {
+		return nil, nil
+	}
+	return json.Marshal(list)
+}
+
+func scanJSON(value interface{}, target any) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, target)
+	case string:
+		return json.Unmarshal([]byte(v), target)
+	default:
+		return errors.New("unsupported type for jsonb column")
+	}
+}
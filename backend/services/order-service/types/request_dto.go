@@ -1,23 +1,47 @@
 package types
 
-import "time"
+import (
+	"time"
 
+	"github.com/shopspring/decimal"
+)
+
+// status accepts a repeated parameter or a comma separated list, dates are RFC3339 or YYYY-MM-DD.
+// passing cursor, empty for the first page, switches from page/limit to keyset pagination
 type OrderFilterPayload struct {
-	userId        string    `schema:"user_id"`
-	factoryId     string    `schema:"factory_id"`
-	status        string    `schema:"status"`
-	isGroupBuying bool      `schema:"is_group_buying"`
-	search        string    `schema:"search"`
-	page          int       `schema:"page"`
-	limit         int       `schema:"limit"`
-	startDate     time.Time `schema:"start_date"`
-	endDate       time.Time `schema:"end_date"`
+	UserID        string     `query:"user_id" validate:"omitempty,uuid"`
+	FactoryID     string     `query:"factory_id" validate:"omitempty,uuid"`
+	Status        []string   `query:"status"`
+	IsGroupBuying *bool      `query:"is_group_buying"`
+	Search        string     `query:"search" validate:"max=100"`
+	StartDate     *time.Time `query:"start_date"`
+	EndDate       *time.Time `query:"end_date"`
+	Page          int        `query:"page" validate:"omitempty,min=1"`
+	Limit         int        `query:"limit" validate:"omitempty,min=1,max=100"`
+	SortBy        string     `query:"sort_by" validate:"omitempty,oneof=created_at updated_at total_amount order_number status"`
+	SortOrder     string     `query:"sort_order" validate:"omitempty,oneof=asc desc"`
+	Cursor        *string    `query:"cursor"`
+}
+
+// dates are RFC3339 or YYYY-MM-DD like the order list, the range defaults to the last 30 days
+type OrderStatsPayload struct {
+	StartDate   *time.Time `query:"start_date"`
+	EndDate     *time.Time `query:"end_date"`
+	SellerID    string     `query:"seller_id" validate:"omitempty,uuid"`
+	BrandID     string     `query:"brand_id" validate:"omitempty,uuid"`
+	OrderSource string     `query:"order_source" validate:"omitempty,oneof=brand seller live_commerce"`
+	Interval    string     `query:"interval" validate:"omitempty,oneof=day week month"`
+	Top         int        `query:"top" validate:"omitempty,min=1,max=50"`
 }
 
 type CreateOrderPayload struct {
 	UserID          string                 `json:"userId" validate:"required,uuid4"`
 	Items           []OrderItemPayload     `json:"items" validate:"required,min=1,dive"`
 	ShippingAddress ShippingAddressPayload `json:"shippingAddress" validate:"required"`
+	CouponCode      string                 `json:"couponCode,omitempty" validate:"max=50"`
+	Courier         string                 `json:"courier,omitempty" validate:"max=50"`          //empty lets checkout take the cheapest courier
+	ShippingService string                 `json:"shippingService,omitempty" validate:"max=100"` //the courier's service code
+	IdempotencyKey  string                 `json:"-"`                                            //from the Idempotency-Key header
 }
 
 // Read implements io.Reader.
@@ -39,3 +63,113 @@ type ShippingAddressPayload struct {
 	District   string `json:"district" validate:"required"`
 	PostalCode string `json:"postalCode,omitempty"` // Optional field
 }
+
+type UpdateOrderStatusPayload struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason,omitempty" validate:"max=500"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+// a manual quote replacing the one checkout got, Courier and ShippingService are kept when left empty.
+// zero is a valid cost, the service rejects negative ones
+type UpdateShippingCostPayload struct {
+	ShippingCost    decimal.Decimal `json:"shippingCost"`
+	Courier         string          `json:"courier,omitempty" validate:"max=50"`
+	ShippingService string          `json:"shippingService,omitempty" validate:"max=100"`
+	Reason          string          `json:"reason" validate:"required,max=500"`
+}
+
+type CancelOrderPayload struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+type CreateReturnPayload struct {
+	ReturnType    string              `json:"returnType,omitempty" validate:"omitempty,oneof=refund exchange store_credit"`
+	Reason        string              `json:"reason" validate:"required,oneof=damaged defective wrong_item not_as_described size_issue quality_issue changed_mind other"`
+	ReasonDetail  string              `json:"reasonDetail,omitempty" validate:"max=500"`
+	CustomerNotes string              `json:"customerNotes,omitempty"`
+	Items         []ReturnItemPayload `json:"items" validate:"required,min=1,dive"`
+	Images        []string            `json:"images,omitempty" validate:"max=10,dive,url"`
+}
+
+type ReturnItemPayload struct {
+	OrderItemID string `json:"orderItemId" validate:"required,uuid"`
+	Quantity    int    `json:"quantity" validate:"required,min=1"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type RejectReturnPayload struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type ShipReturnPayload struct {
+	Courier    string `json:"courier" validate:"required,max=50"`
+	TrackingNo string `json:"trackingNo" validate:"required,max=100"`
+}
+
+type InspectReturnPayload struct {
+	ItemCondition string `json:"itemCondition" validate:"required,oneof=unopened like_new used damaged"`
+	Notes         string `json:"notes,omitempty"`
+}
+
+type CompleteReturnPayload struct {
+	RefundMethod string `json:"refundMethod,omitempty" validate:"omitempty,oneof=original_payment wallet bank_transfer"`
+}
+
+type CouponFilterPayload struct {
+	Search   string `query:"search" validate:"max=100"`
+	IsActive *bool  `query:"is_active"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	Limit    int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// amounts are checked by the service, the validator can't compare decimals
+type CouponPayload struct {
+	Code                  string           `json:"code" validate:"required,max=50,alphanumunicode"`
+	Name                  string           `json:"name" validate:"required,max=255"`
+	Description           *string          `json:"description,omitempty"`
+	DiscountType          string           `json:"discountType" validate:"required,oneof=percentage fixed_amount free_shipping"`
+	DiscountValue         decimal.Decimal  `json:"discountValue"`
+	MinOrderAmount        *decimal.Decimal `json:"minOrderAmount,omitempty"`
+	MaxDiscountAmount     *decimal.Decimal `json:"maxDiscountAmount,omitempty"`
+	TotalUsageLimit       *int             `json:"totalUsageLimit,omitempty" validate:"omitempty,min=1"`
+	PerUserLimit          int              `json:"perUserLimit,omitempty" validate:"omitempty,min=1"`
+	ApplicableBrandIDs    []string         `json:"applicableBrandIds,omitempty" validate:"dive,uuid"`
+	ApplicableCategoryIDs []string         `json:"applicableCategoryIds,omitempty" validate:"dive,uuid"`
+	ApplicableProductIDs  []string         `json:"applicableProductIds,omitempty" validate:"dive,uuid"`
+	ExcludedProductIDs    []string         `json:"excludedProductIds,omitempty" validate:"dive,uuid"`
+	NewCustomersOnly      bool             `json:"newCustomersOnly,omitempty"`
+	StartsAt              time.Time        `json:"startsAt" validate:"required"`
+	ExpiresAt             time.Time        `json:"expiresAt" validate:"required"`
+	IsActive              *bool            `json:"isActive,omitempty"`
+	Source                string           `json:"source,omitempty" validate:"omitempty,oneof=manual live_deal campaign referral loyalty recovery"`
+	LiveSessionID         *string          `json:"liveSessionId,omitempty" validate:"omitempty,uuid"`
+	CampaignID            *string          `json:"campaignId,omitempty" validate:"omitempty,uuid"`
+}
+
+// the cart is priced from the products table like CreateOrderPayload, shipping is what checkout quoted
+type ValidateCouponPayload struct {
+	UserID       string             `json:"userId" validate:"required,uuid4"`
+	Code         string             `json:"code" validate:"required,max=50"`
+	Items        []OrderItemPayload `json:"items" validate:"required,min=1,dive"`
+	ShippingCost decimal.Decimal    `json:"shippingCost"`
+}
+
+type CreateGroupSessionPayload struct {
+	ProductID   string           `json:"productId" validate:"required,uuid"`
+	Title       string           `json:"title" validate:"required,max=255"`
+	UnitPrice   *decimal.Decimal `json:"unitPrice,omitempty"` //defaults to the product's base price
+	MinQuantity int              `json:"minQuantity" validate:"required,min=1"`
+	MaxQuantity *int             `json:"maxQuantity,omitempty" validate:"omitempty,min=1"`
+	ExpiresAt   time.Time        `json:"expiresAt" validate:"required"`
+}
+
+type JoinGroupSessionPayload struct {
+	UserID          string                 `json:"userId" validate:"required,uuid4"`
+	Quantity        int                    `json:"quantity" validate:"required,min=1"`
+	ShippingAddress ShippingAddressPayload `json:"shippingAddress" validate:"required"`
+	Courier         string                 `json:"courier,omitempty" validate:"max=50"`
+	ShippingService string                 `json:"shippingService,omitempty" validate:"max=100"`
+	IdempotencyKey  string                 `json:"-"` //from the Idempotency-Key header
+}
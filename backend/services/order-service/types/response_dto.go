@@ -11,12 +11,19 @@ type OrderResponse struct {
 	OrderNumber           string          `json:"order_number"`
 	UserID                string          `json:"user_id"`
 	GroupSessionID        *string         `json:"group_session_id"`
+	CheckoutID            *string         `json:"checkout_id"`
+	OrderSource           string          `json:"order_source"`
+	BrandID               *string         `json:"brand_id"`
+	SellerID              *string         `json:"seller_id"`
 	Status                string          `json:"status"`
 	Subtotal              decimal.Decimal `json:"subtotal"`
 	ShippingCost          decimal.Decimal `json:"shipping_cost"`
 	TaxAmount             decimal.Decimal `json:"tax_amount"`
 	DiscountAmount        decimal.Decimal `json:"discount_amount"`
+	CouponCode            *string         `json:"coupon_code"`
 	TotalAmount           decimal.Decimal `json:"total_amount"`
+	ShippingCourier       *string         `json:"shipping_courier"`
+	ShippingMethod        *string         `json:"shipping_method"`
 	ShippingName          string          `json:"shipping_name"`
 	ShippingPhone         string          `json:"shipping_phone"`
 	ShippingProvince      string          `json:"shipping_province"`
@@ -29,28 +36,70 @@ type OrderResponse struct {
 	PaidAt                *time.Time      `json:"paid_at"`
 	ShippedAt             *time.Time      `json:"shipped_at"`
 	DeliveredAt           *time.Time      `json:"delivered_at"`
+	CompletedAt           *time.Time      `json:"completed_at"`
 	CancelledAt           *time.Time      `json:"cancelled_at"`
+	CancelReason          *string         `json:"cancel_reason"`
+	CancelledBy           *string         `json:"cancelled_by"`
 	CreatedAt             time.Time       `json:"created_at"`
 	UpdatedAt             time.Time       `json:"updated_at"`
 
-	OrderItems []OrderItemResponse `json:"order_items"`
-	User       UserResponse        `json:"users"`
+	PriceBreakdown []PriceStepResponse `json:"price_breakdown"`
+	OrderItems     []OrderItemResponse `json:"order_items"`
+	User           UserResponse        `json:"users"`
+}
+
+// one pricing step of an order, amount is negative for discounts and total is the running total after the step
+type PriceStepResponse struct {
+	Step        string          `json:"step"`
+	Description string          `json:"description"`
+	Amount      decimal.Decimal `json:"amount"`
+	Total       decimal.Decimal `json:"total"`
+}
+
+// what a checkout created, one order per seller or brand. payment charges total_amount once for the whole group
+type CheckoutResponse struct {
+	CheckoutID     string          `json:"checkout_id"`
+	Subtotal       decimal.Decimal `json:"subtotal"`
+	ShippingCost   decimal.Decimal `json:"shipping_cost"`
+	TaxAmount      decimal.Decimal `json:"tax_amount"`
+	DiscountAmount decimal.Decimal `json:"discount_amount"`
+	TotalAmount    decimal.Decimal `json:"total_amount"`
+	Orders         []OrderResponse `json:"orders"`
+}
+
+// a single order with everything the customer app and support console show on the order page
+type OrderDetailResponse struct {
+	OrderResponse
+	StatusHistory []OrderStatusHistoryResponse `json:"status_history"`
+}
+
+type OrderStatusHistoryResponse struct {
+	ID            string    `json:"id"`
+	FromStatus    *string   `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	Reason        *string   `json:"reason"`
+	Notes         *string   `json:"notes"`
+	ChangedBy     *string   `json:"changed_by"`
+	ChangedByType *string   `json:"changed_by_type"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 type OrderItemResponse struct {
-	ID              string          `json:"id"`
-	OrderID         string          `json:"order_id"`
-	ProductID       string          `json:"product_id"`
-	VariantID       *string         `json:"variant_id"`
-	FactoryID       string          `json:"factory_id"`
-	SKU             string          `json:"sku"`
-	ProductName     string          `json:"product_name"`
-	VariantName     *string         `json:"variant_name"`
-	Quantity        int             `json:"quantity"`
-	UnitPrice       decimal.Decimal `json:"unit_price"`
-	Subtotal        decimal.Decimal `json:"subtotal"`
-	ProductSnapshot ProductSnapshot `json:"product_snapshot"`
-	CreatedAt       time.Time       `json:"created_at"`
+	ID               string          `json:"id"`
+	OrderID          string          `json:"order_id"`
+	ProductID        string          `json:"product_id"`
+	VariantID        *string         `json:"variant_id"`
+	FactoryID        string          `json:"factory_id"`
+	SKU              string          `json:"sku"`
+	ProductName      string          `json:"product_name"`
+	VariantName      *string         `json:"variant_name"`
+	Quantity         int             `json:"quantity"`
+	UnitPrice        decimal.Decimal `json:"unit_price"`
+	Subtotal         decimal.Decimal `json:"subtotal"`
+	DiscountAmount   decimal.Decimal `json:"discount_amount"`
+	ReturnedQuantity int             `json:"returned_quantity"`
+	ProductSnapshot  ProductSnapshot `json:"product_snapshot"`
+	CreatedAt        time.Time       `json:"created_at"`
 
 	Product ProductResponse `json:"products"`
 	Factory FactoryResponse `json:"factories"`
@@ -73,3 +122,53 @@ type UserResponse struct {
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
 }
+
+// gmv, aov and revenue only count paid orders, partially refunded ones less what was refunded. by_status amounts
+// are the plain order totals, cancellation_rate is cancelled orders over all orders in the range
+type OrderStatsResponse struct {
+	From         time.Time          `json:"from"`
+	To           time.Time          `json:"to"`
+	Interval     string             `json:"interval"`
+	Summary      OrderStatsSummary  `json:"summary"`
+	ByStatus     []StatusCount      `json:"by_status"`
+	Buckets      []OrderStatsBucket `json:"buckets"`
+	TopProducts  []TopProductStat   `json:"top_products"`
+	TopFactories []TopMerchantStat  `json:"top_factories"`
+	TopSellers   []TopMerchantStat  `json:"top_sellers"`
+}
+
+type OrderStatsSummary struct {
+	TotalOrders      int64           `json:"total_orders"`
+	CancelledOrders  int64           `json:"cancelled_orders"`
+	GMV              decimal.Decimal `json:"gmv"`
+	AOV              decimal.Decimal `json:"aov"`
+	CancellationRate float64         `json:"cancellation_rate"`
+}
+
+type StatusCount struct {
+	Status string          `json:"status"`
+	Orders int64           `json:"orders"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+type OrderStatsBucket struct {
+	Start           time.Time       `json:"start"`
+	Orders          int64           `json:"orders"`
+	CancelledOrders int64           `json:"cancelled_orders"`
+	GMV             decimal.Decimal `json:"gmv"`
+	AOV             decimal.Decimal `json:"aov"`
+}
+
+type TopProductStat struct {
+	ProductID   string          `json:"product_id"`
+	ProductName string          `json:"product_name"`
+	Quantity    int64           `json:"quantity"`
+	Orders      int64           `json:"orders"`
+	Revenue     decimal.Decimal `json:"revenue"`
+}
+
+type TopMerchantStat struct {
+	ID      string          `json:"id"`
+	Orders  int64           `json:"orders"`
+	Revenue decimal.Decimal `json:"revenue"`
+}
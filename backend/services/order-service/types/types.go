@@ -1,5 +1,7 @@
 package types
 
+import "github.com/Flow-Indo/LAKOO/backend/services/order-service/models"
+
 type ProductSnapshot struct {
 	Factory  ProductSnapshotFactory  `json:"factory"`
 	Product  ProductSnapshotProduct  `json:"product"`
@@ -43,3 +45,19 @@ type Pagination struct {
 	Total      int `json:"total"`
 	TotalPages int `json:"totalPages"`
 }
+
+// returned instead of PaginatedOrdersResponse in cursor mode, next_cursor is null on the last page
+type CursorOrdersResponse struct {
+	Data       []OrderResponse  `json:"data"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+type CursorPagination struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+type PaginatedCouponsResponse struct {
+	Data       []models.Coupon `json:"data"`
+	Pagination Pagination      `json:"pagination"`
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OrderNumberPrefix  = "ORD"
+	ReturnNumberPrefix = "RET"
+
+	numberSuffixLength = 5
+)
+
+// no 0/O, 1/I/L so support can read the number back over the phone without mixups
+const numberAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// orders are dated in WIB since that is the day the customer sees on their receipt
+var numberLocation = time.FixedZone("WIB", 7*60*60)
+
+// ORD-20260115-XXXXX, the suffix is random so callers must retry on a unique violation
+func GenerateOrderNumber(now time.Time) (string, error) {
+	return generateNumber(OrderNumberPrefix, now)
+}
+
+// RET-20260115-XXXXX, same scheme as order numbers
+func GenerateReturnNumber(now time.Time) (string, error) {
+	return generateNumber(ReturnNumberPrefix, now)
+}
+
+func generateNumber(prefix string, now time.Time) (string, error) {
+	suffix := make([]byte, numberSuffixLength)
+	alphabetLength := big.NewInt(int64(len(numberAlphabet)))
+
+	for i := range suffix {
+		index, err := rand.Int(rand.Reader, alphabetLength)
+		if err != nil {
+			return "", err
+		}
+		suffix[i] = numberAlphabet[index.Int64()]
+	}
+
+	return prefix + "-" + now.In(numberLocation).Format("20060102") + "-" + string(suffix), nil
+}
+
+// random version 4 uuid, for ids that have to be known before anything is inserted
+func GenerateID() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"reflect"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/schema"
@@ -18,6 +19,7 @@ func init() {
 	decoder = schema.NewDecoder()
 	decoder.IgnoreUnknownKeys(true)
 	decoder.SetAliasTag("query")
+	decoder.RegisterConverter(time.Time{}, convertTime)
 
 	validate = validator.New()
 }
@@ -44,12 +46,23 @@ func DecodeQueryParamsWithValidation(payload any, r *http.Request) error {
 		return err
 	}
 
-	return validatePayload(payload)
+	return ValidatePayload(payload)
 }
 
-func validatePayload(payload any) error {
+func ValidatePayload(payload any) error {
 	if err := validate.Struct(payload); err != nil {
 		return errors.New("validation error: " + err.Error())
 	}
 	return nil
 }
+
+// an invalid reflect.Value makes Decode fail with a conversion error for the field
+func convertTime(value string) reflect.Value {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return reflect.ValueOf(parsed)
+		}
+	}
+
+	return reflect.Value{}
+}
@@ -29,7 +29,7 @@ func GetStringFromJSONB(data types.JSONB, path string) string {
 
 	keys := strings.Split(path, ".")
 
-	current := interface{}(data)
+	current := interface{}(map[string]interface{}(data)) //the named JSONB type would fail the map assertion below
 	for _, key := range keys {
 		if currentMap, ok := current.(map[string]interface{}); ok { //assertion where value.(type) tries to assert that value is of a specific type
 			if next, exists := currentMap[key]; exists {
@@ -64,7 +64,7 @@ func GetIntFromJSONB(data types.JSONB, path string) int {
 
 	keys := strings.Split(path, ".")
 
-	current := interface{}(data)
+	current := interface{}(map[string]interface{}(data))
 	for _, key := range keys {
 		if currentMap, ok := current.(map[string]interface{}); ok {
 			if next, exists := currentMap[key]; exists {
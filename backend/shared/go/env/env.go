@@ -3,16 +3,18 @@ package env
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// values are never logged, the same helper reads broker passwords and service secrets
 func GetEnv(key string, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
-		log.Println("Value: ", value)
 		return value
 	}
 
-	log.Println("Returning feedback")
+	log.Printf("%s not set, returning fallback", key)
 	return fallback
 }
 
@@ -29,3 +31,63 @@ func GetEnvAsSlice(key string, fallback []string, separator string) []string {
 
 	return fallback
 }
+
+func GetEnvAsInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		if err == nil {
+			return parsed
+		}
+
+		log.Printf("Invalid int for %s, returning fallback", key)
+	}
+
+	return fallback
+}
+
+// accepts anything time.ParseDuration does, e.g. "30s" or "5m"
+func GetEnvAsDuration(key string, fallback time.Duration) time.Duration {
+	if value, ok := os.LookupEnv(key); ok {
+		parsed, err := time.ParseDuration(strings.TrimSpace(value))
+		if err == nil {
+			return parsed
+		}
+
+		log.Printf("Invalid duration for %s, returning fallback", key)
+	}
+
+	return fallback
+}
+
+func GetEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err == nil {
+			return parsed
+		}
+
+		log.Printf("Invalid bool for %s, returning fallback", key)
+	}
+
+	return fallback
+}
+
+// parses "a=1,b=2" into a map, entries without "=" are skipped
+func GetEnvAsMap(key string, fallback map[string]string, separator string) map[string]string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	values := make(map[string]string)
+	for _, entry := range strings.Split(value, separator) {
+		name, mapped, found := strings.Cut(entry, "=")
+		if !found {
+			log.Printf("Invalid entry %q in %s, skipping", entry, key)
+			continue
+		}
+		values[strings.TrimSpace(name)] = strings.TrimSpace(mapped)
+	}
+
+	return values
+}
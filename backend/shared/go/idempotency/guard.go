@@ -0,0 +1,65 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+// same shape as the events.Handler interface services register on their routers
+type Handler interface {
+	Handle(ctx context.Context, key []byte, message []byte) error
+}
+
+// wraps a handler so a redelivered event, recognised by its envelope id, is acknowledged without running it again.
+// an event another consumer is still handling fails with ErrEventInFlight, it may yet be released and must come back
+type Guard struct {
+	store         *Store
+	consumerGroup string
+	next          Handler
+}
+
+func NewGuard(store *Store, consumerGroup string, next Handler) *Guard {
+	return &Guard{
+		store:         store,
+		consumerGroup: consumerGroup,
+		next:          next,
+	}
+}
+
+func (g *Guard) Handle(ctx context.Context, key []byte, message []byte) error {
+	envelope, err := kafka.DecodeEvent(message)
+	if err != nil {
+		if errors.Is(err, kafka.ErrInvalidEnvelope) {
+			//without an event id there is nothing to deduplicate on, let the handler decide what to do with it
+			return g.next.Handle(ctx, key, message)
+		}
+		return err
+	}
+
+	claimed, err := g.store.Claim(ctx, envelope.ID, g.consumerGroup)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		log.Printf("skipping duplicate event %s (%s) for %s", envelope.ID, envelope.Type, g.consumerGroup)
+		return nil
+	}
+
+	if err := g.next.Handle(ctx, key, message); err != nil {
+		//ctx may be the reason the handler failed, the release must still go through
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if releaseErr := g.store.Release(releaseCtx, envelope.ID, g.consumerGroup); releaseErr != nil {
+			log.Printf("failed to release event %s for %s: %v", envelope.ID, g.consumerGroup, releaseErr)
+		}
+		return err
+	}
+
+	return g.store.Complete(ctx, envelope.ID, g.consumerGroup)
+}
@@ -0,0 +1,216 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/idempotency"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	eventID = "3f6c1d2e-4b5a-4c7d-8e9f-0a1b2c3d4e5f"
+	group   = "order-service"
+)
+
+type handler struct {
+	err   error
+	calls int
+}
+
+func (h *handler) Handle(ctx context.Context, key []byte, message []byte) error {
+	h.calls++
+	return h.err
+}
+
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	return db, mock
+}
+
+func event(t *testing.T) []byte {
+	t.Helper()
+
+	envelope, err := kafka.NewEnvelope(kafka.EventPaymentSucceeded, 1, "order-1", "payment-service", kafka.PaymentSucceeded{OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	envelope.ID = eventID
+
+	message, err := kafka.EncodeEvent(envelope)
+	if err != nil {
+		t.Fatalf("EncodeEvent: %v", err)
+	}
+	return message
+}
+
+func expectClaim(mock sqlmock.Sqlmock, claimed bool) {
+	var affected int64
+	if claimed {
+		affected = 1
+	}
+	mock.ExpectExec(`INSERT INTO processed_event .* ON CONFLICT \("eventId", "consumerGroup"\) DO UPDATE`).
+		WithArgs(eventID, group, "processing", "processing", float64(300)).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+// the row that kept the insert from claiming, nil when it was released in between
+func expectExisting(mock sqlmock.Sqlmock, status *string) {
+	rows := sqlmock.NewRows([]string{"eventId", "consumerGroup", "status", "claimedAt", "processedAt"})
+	if status != nil {
+		rows.AddRow(eventID, group, *status, nil, nil)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "processed_event" WHERE "eventId" = \$1 AND "consumerGroup" = \$2`).
+		WithArgs(eventID, group, 1).
+		WillReturnRows(rows)
+}
+
+func expectComplete(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "processed_event" SET "processedAt"=\$1,"status"=\$2 WHERE "eventId" = \$3 AND "consumerGroup" = \$4`).
+		WithArgs(sqlmock.AnyArg(), "done", eventID, group).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func expectRelease(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "processed_event" WHERE "eventId" = \$1 AND "consumerGroup" = \$2 AND "status" = \$3`).
+		WithArgs(eventID, group, "processing").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func status(value string) *string {
+	return &value
+}
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		name       string
+		expect     func(mock sqlmock.Sqlmock)
+		handlerErr error
+		calls      int
+		err        error
+	}{
+		{
+			name: "first delivery is handled and recorded",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectClaim(mock, true)
+				expectComplete(mock)
+			},
+			calls: 1,
+		},
+		{
+			name: "processed event is acknowledged without handling",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectClaim(mock, false)
+				expectExisting(mock, status("done"))
+			},
+		},
+		{
+			name: "event another consumer is handling comes back later",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectClaim(mock, false)
+				expectExisting(mock, status("processing"))
+			},
+			err: idempotency.ErrEventInFlight,
+		},
+		{
+			name: "claim released since the insert comes back later",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectClaim(mock, false)
+				expectExisting(mock, nil)
+			},
+			err: idempotency.ErrEventInFlight,
+		},
+		{
+			name: "failed handler gives the claim back",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectClaim(mock, true)
+				expectRelease(mock)
+			},
+			handlerErr: errors.New("order service down"),
+			calls:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+			tt.expect(mock)
+			next := &handler{err: tt.handlerErr}
+
+			guard := idempotency.NewGuard(idempotency.NewStore(db, 5*time.Minute), group, next)
+			err := guard.Handle(context.Background(), []byte("order-1"), event(t))
+
+			want := tt.err
+			if tt.handlerErr != nil {
+				want = tt.handlerErr
+			}
+			if !errors.Is(err, want) || (want == nil && err != nil) {
+				t.Fatalf("Handle error = %v, want %v", err, want)
+			}
+			if next.calls != tt.calls {
+				t.Fatalf("handler ran %d times, want %d", next.calls, tt.calls)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// nothing to deduplicate on, the handler decides what to do with the message
+func TestGuardPassesInvalidEnvelopeThrough(t *testing.T) {
+	db, mock := mockDB(t)
+	next := &handler{}
+
+	guard := idempotency.NewGuard(idempotency.NewStore(db, 5*time.Minute), group, next)
+	if err := guard.Handle(context.Background(), nil, []byte(`{"orderId":"order-1"}`)); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if next.calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", next.calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreCleanupRemovesRowsPastTTL(t *testing.T) {
+	db, mock := mockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "processed_event" WHERE "claimedAt" < \$1`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	removed, err := idempotency.NewStore(db, 5*time.Minute).Cleanup(context.Background(), 14*24*time.Hour)
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if removed != 3 {
+		t.Fatalf("removed %d rows, want 3", removed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,127 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	statusProcessing = "processing"
+	statusDone       = "done"
+)
+
+// one row per event and consumer group, declared as ProcessedEvent in the service schemas
+type ProcessedEvent struct {
+	EventID       string     `gorm:"column:eventId;type:varchar(100);primaryKey" json:"event_id"`
+	ConsumerGroup string     `gorm:"column:consumerGroup;type:varchar(100);primaryKey" json:"consumer_group"`
+	Status        string     `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	ClaimedAt     time.Time  `gorm:"column:claimedAt;not null" json:"claimed_at"`
+	ProcessedAt   *time.Time `gorm:"column:processedAt;null" json:"processed_at"`
+}
+
+func (ProcessedEvent) TableName() string {
+	return "processed_event"
+}
+
+// another consumer holds an unexpired claim, the event must be redelivered rather than acknowledged
+var ErrEventInFlight = errors.New("event is being handled by another consumer")
+
+type Store struct {
+	db *gorm.DB
+	// a claim that was never completed, e.g. because the process crashed mid-handle, can be taken over after this long
+	lease time.Duration
+}
+
+func NewStore(db *gorm.DB, lease time.Duration) *Store {
+	return &Store{
+		db:    db,
+		lease: lease,
+	}
+}
+
+// returns true when the caller now owns the event and should handle it, false when it was already handled,
+// ErrEventInFlight while another consumer is handling it right now
+func (s *Store) Claim(ctx context.Context, eventId string, consumerGroup string) (bool, error) {
+	results := s.db.WithContext(ctx).Exec(`
+		INSERT INTO processed_event ("eventId", "consumerGroup", "status", "claimedAt")
+		VALUES (?, ?, ?, NOW())
+		ON CONFLICT ("eventId", "consumerGroup") DO UPDATE
+			SET "claimedAt" = NOW()
+			WHERE processed_event."status" = ? AND processed_event."claimedAt" < NOW() - ? * INTERVAL '1 second'`,
+		eventId, consumerGroup, statusProcessing,
+		statusProcessing, s.lease.Seconds(),
+	)
+	if results.Error != nil {
+		return false, results.Error
+	}
+
+	if results.RowsAffected == 1 {
+		return true, nil
+	}
+
+	var event ProcessedEvent
+	err := s.db.WithContext(ctx).
+		Where(`"eventId" = ? AND "consumerGroup" = ?`, eventId, consumerGroup).
+		Take(&event).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	//a claim released since the insert above is in flight too, the redelivery claims it again
+	if err == nil && event.Status == statusDone {
+		return false, nil
+	}
+	return false, ErrEventInFlight
+}
+
+func (s *Store) Complete(ctx context.Context, eventId string, consumerGroup string) error {
+	return s.db.WithContext(ctx).
+		Model(&ProcessedEvent{}).
+		Where(`"eventId" = ? AND "consumerGroup" = ?`, eventId, consumerGroup).
+		Updates(map[string]any{
+			"status":      statusDone,
+			"processedAt": time.Now(),
+		}).Error
+}
+
+// gives up a claim after a failed handle so the redelivered event is handled again
+func (s *Store) Release(ctx context.Context, eventId string, consumerGroup string) error {
+	return s.db.WithContext(ctx).
+		Where(`"eventId" = ? AND "consumerGroup" = ? AND "status" = ?`, eventId, consumerGroup, statusProcessing).
+		Delete(&ProcessedEvent{}).Error
+}
+
+// removes rows older than ttl, events redelivered after that are handled again so ttl must exceed the topic retention
+func (s *Store) Cleanup(ctx context.Context, ttl time.Duration) (int64, error) {
+	results := s.db.WithContext(ctx).
+		Where(`"claimedAt" < ?`, time.Now().Add(-ttl)).
+		Delete(&ProcessedEvent{})
+	return results.RowsAffected, results.Error
+}
+
+// blocks until ctx is cancelled
+func (s *Store) RunCleanup(ctx context.Context, ttl time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.Cleanup(ctx, ttl)
+			if err != nil {
+				log.Printf("processed events cleanup: %v", err)
+				continue
+			}
+
+			if removed > 0 {
+				log.Printf("processed events cleanup: removed %d rows", removed)
+			}
+		}
+	}
+}
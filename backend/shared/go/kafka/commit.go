@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Committer interface {
+	Commit(ctx context.Context, msgs ...Message) error
+}
+
+// collects handled messages and commits them in batches, only ever pass messages whose handling is finished.
+// use one batcher per reader, offsets can only be committed through the reader that fetched them
+type CommitBatcher struct {
+	committer Committer
+	size      int
+	interval  time.Duration
+
+	flushing    sync.Mutex //one commit at a time, an older snapshot committed last would move the offset back
+	mu          sync.Mutex
+	pending     map[topicPartition]Message
+	count       int
+	lastFlushed time.Time
+}
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+func NewCommitBatcher(committer Committer, size int, interval time.Duration) *CommitBatcher {
+	return &CommitBatcher{
+		committer:   committer,
+		size:        max(size, 1),
+		interval:    interval,
+		pending:     make(map[topicPartition]Message),
+		lastFlushed: time.Now(),
+	}
+}
+
+// marks msg as handled and commits once the batch is full or the interval has passed
+func (b *CommitBatcher) MarkDone(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	key := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	if current, ok := b.pending[key]; !ok || msg.Offset > current.Offset {
+		b.pending[key] = msg
+	}
+	b.count++
+	due := b.count >= b.size || time.Since(b.lastFlushed) >= b.interval
+	b.mu.Unlock()
+
+	if !due {
+		return nil
+	}
+
+	return b.Flush(ctx)
+}
+
+// flushes every interval until ctx is cancelled, so the last messages before a quiet spell don't wait for the next one.
+// run it next to the fetch loop and stop it before the final Flush
+func (b *CommitBatcher) Run(ctx context.Context, onError func(error)) {
+	if b.interval <= 0 {
+		return //MarkDone already commits every message
+	}
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Flush(ctx); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// commits everything marked so far, call it before closing the reader so a rebalance doesn't replay handled messages
+func (b *CommitBatcher) Flush(ctx context.Context) error {
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+
+	b.mu.Lock()
+	if len(b.pending) == 0 {
+		b.lastFlushed = time.Now()
+		b.mu.Unlock()
+		return nil
+	}
+
+	msgs := make([]Message, 0, len(b.pending))
+	for _, msg := range b.pending {
+		msgs = append(msgs, msg)
+	}
+	b.mu.Unlock()
+
+	if err := b.committer.Commit(ctx, msgs...); err != nil {
+		return err //pending is kept, the next flush tries again
+	}
+
+	b.mu.Lock()
+	for _, msg := range msgs {
+		key := topicPartition{topic: msg.Topic, partition: msg.Partition}
+		if b.pending[key].Offset == msg.Offset {
+			delete(b.pending, key)
+		}
+	}
+	b.count = 0
+	b.lastFlushed = time.Now()
+	b.mu.Unlock()
+
+	return nil
+}
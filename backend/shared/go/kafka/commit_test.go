@@ -0,0 +1,138 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+type committer struct {
+	mu      sync.Mutex
+	fail    bool
+	commits [][]kafka.Message
+	done    chan struct{} //receives after every successful commit
+}
+
+func newCommitter() *committer {
+	return &committer{done: make(chan struct{}, 16)}
+}
+
+func (c *committer) Commit(ctx context.Context, msgs ...kafka.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fail {
+		return errors.New("coordinator not available")
+	}
+	c.commits = append(c.commits, msgs)
+	c.done <- struct{}{}
+	return nil
+}
+
+// the committed offset per partition of topic orders
+func (c *committer) offsets() map[int]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	offsets := map[int]int64{}
+	for _, commit := range c.commits {
+		for _, msg := range commit {
+			offsets[msg.Partition] = msg.Offset
+		}
+	}
+	return offsets
+}
+
+func msg(partition int, offset int64) kafka.Message {
+	return kafka.Message{Topic: "orders", Partition: partition, Offset: offset}
+}
+
+func TestCommitBatcherCommitsHighestOffsetOnceFull(t *testing.T) {
+	c := newCommitter()
+	batcher := kafka.NewCommitBatcher(c, 4, time.Hour)
+	ctx := context.Background()
+
+	for _, m := range []kafka.Message{msg(0, 5), msg(1, 2), msg(0, 3)} {
+		if err := batcher.MarkDone(ctx, m); err != nil {
+			t.Fatalf("MarkDone: %v", err)
+		}
+	}
+	if len(c.commits) != 0 {
+		t.Fatalf("committed %v before the batch was full", c.commits)
+	}
+
+	if err := batcher.MarkDone(ctx, msg(0, 4)); err != nil {
+		t.Fatalf("MarkDone: %v", err)
+	}
+	if len(c.commits) != 1 {
+		t.Fatalf("got %d commits, want 1", len(c.commits))
+	}
+	//an offset handled out of order never moves the partition back
+	if offsets := c.offsets(); offsets[0] != 5 || offsets[1] != 2 {
+		t.Fatalf("committed offsets %v, want partition 0 at 5 and 1 at 2", offsets)
+	}
+}
+
+func TestCommitBatcherKeepsOffsetsWhenCommitFails(t *testing.T) {
+	c := newCommitter()
+	c.fail = true
+	batcher := kafka.NewCommitBatcher(c, 1, time.Hour)
+	ctx := context.Background()
+
+	if err := batcher.MarkDone(ctx, msg(0, 7)); err == nil {
+		t.Fatal("MarkDone succeeded while the commit failed")
+	}
+
+	c.fail = false
+	if err := batcher.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if offsets := c.offsets(); offsets[0] != 7 {
+		t.Fatalf("committed offsets %v, want partition 0 at 7", offsets)
+	}
+
+	//nothing left to commit
+	if err := batcher.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(c.commits) != 1 {
+		t.Fatalf("got %d commits, want 1", len(c.commits))
+	}
+}
+
+func TestCommitBatcherRunFlushesWithoutNewMessages(t *testing.T) {
+	c := newCommitter()
+	batcher := kafka.NewCommitBatcher(c, 100, 10*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := batcher.MarkDone(ctx, msg(0, 1)); err != nil {
+		t.Fatalf("MarkDone: %v", err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		batcher.Run(ctx, func(err error) { t.Errorf("Run: %v", err) })
+	}()
+
+	select {
+	case <-c.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("offsets were not committed by the ticker")
+	}
+	if offsets := c.offsets(); offsets[0] != 1 {
+		t.Fatalf("committed offsets %v, want partition 0 at 1", offsets)
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after ctx was cancelled")
+	}
+}
@@ -1,11 +1,227 @@
 package kafka
 
-// func Writer(brokers []string, topic string) *kafka.Writer {
-// 	return &kafka.Writer{
-// 		Addr:     kafka.TCP(brokers...),
-// 		Topic:    topic,
-// 		Balancer: &kafka.LeastBytes{},
-// 	}
-// }
-
-// func NewKafkaReader()
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/env"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+var ErrInvalidConfig = errors.New("kafka: invalid producer config")
+
+type ProducerConfig struct {
+	Brokers      []string
+	ClientID     string
+	Topic        string            // used for messages without a topic and event types without a route
+	Routes       map[string]string // event type -> topic, e.g. "order.created" -> "order_event"
+	Acks         string            // all, one or none
+	Compression  string            // none, gzip, snappy, lz4 or zstd
+	BatchSize    int
+	BatchTimeout time.Duration
+	WriteTimeout time.Duration
+	TLS          TLSConfig
+	SASL         SASLConfig
+}
+
+type TLSConfig struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string // client certificate, only needed for mutual tls
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+type SASLConfig struct {
+	Mechanism string // empty disables sasl, otherwise plain, scram-sha-256 or scram-sha-512
+	Username  string
+	Password  string
+}
+
+// acks default to all, a relay marks outbox rows published as soon as the write returns.
+// every publish is a synchronous write, so the batch timeout is kept short or each call would wait it out
+func DefaultProducerConfig(brokers []string, topic string) ProducerConfig {
+	return ProducerConfig{
+		Brokers:      brokers,
+		Topic:        topic,
+		Acks:         "all",
+		Compression:  "none",
+		BatchSize:    100,
+		BatchTimeout: 10 * time.Millisecond,
+		WriteTimeout: 10 * time.Second,
+	}
+}
+
+// clientId and topic are the service's own defaults, KAFKA_CLIENT_ID and KAFKA_TOPIC override them per environment.
+// KAFKA_TOPIC_ROUTES looks like "order.created=order_event,order.cancelled=order_cancelled_event"
+func ProducerConfigFromEnv(clientId string, topic string) ProducerConfig {
+	defaults := DefaultProducerConfig([]string{"localhost:9092"}, topic)
+
+	return ProducerConfig{
+		Brokers:      env.GetEnvAsSlice("KAFKA_BROKERS", defaults.Brokers, ","),
+		ClientID:     env.GetEnv("KAFKA_CLIENT_ID", clientId),
+		Topic:        env.GetEnv("KAFKA_TOPIC", defaults.Topic),
+		Routes:       env.GetEnvAsMap("KAFKA_TOPIC_ROUTES", nil, ","),
+		Acks:         env.GetEnv("KAFKA_ACKS", defaults.Acks),
+		Compression:  env.GetEnv("KAFKA_COMPRESSION", defaults.Compression),
+		BatchSize:    env.GetEnvAsInt("KAFKA_BATCH_SIZE", defaults.BatchSize),
+		BatchTimeout: env.GetEnvAsDuration("KAFKA_BATCH_TIMEOUT", defaults.BatchTimeout),
+		WriteTimeout: env.GetEnvAsDuration("KAFKA_WRITE_TIMEOUT", defaults.WriteTimeout),
+		TLS: TLSConfig{
+			Enabled:            env.GetEnvAsBool("KAFKA_TLS_ENABLED", false),
+			CAFile:             env.GetEnv("KAFKA_TLS_CA_FILE", ""),
+			CertFile:           env.GetEnv("KAFKA_TLS_CERT_FILE", ""),
+			KeyFile:            env.GetEnv("KAFKA_TLS_KEY_FILE", ""),
+			InsecureSkipVerify: env.GetEnvAsBool("KAFKA_TLS_INSECURE_SKIP_VERIFY", false),
+		},
+		SASL: SASLConfig{
+			Mechanism: env.GetEnv("KAFKA_SASL_MECHANISM", ""),
+			Username:  env.GetEnv("KAFKA_SASL_USERNAME", ""),
+			Password:  env.GetEnv("KAFKA_SASL_PASSWORD", ""),
+		},
+	}
+}
+
+// picks the topic an event type is published to
+func (c ProducerConfig) TopicFor(eventType string) string {
+	if topic, ok := c.Routes[eventType]; ok && topic != "" {
+		return topic
+	}
+	return c.Topic
+}
+
+func (c ProducerConfig) writer() (*kafka.Writer, error) {
+	if len(c.Brokers) == 0 {
+		return nil, fmt.Errorf("%w: no brokers", ErrInvalidConfig)
+	}
+
+	acks, err := requiredAcks(c.Acks)
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := compressionCodec(c.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := c.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	//the topic is set per message so one writer can serve every route
+	return &kafka.Writer{
+		Addr:         kafka.TCP(c.Brokers...),
+		Balancer:     &kafka.Hash{}, //events are keyed by aggregate, one order's events stay on one partition and in order
+		RequiredAcks: acks,
+		Compression:  compression,
+		BatchSize:    c.BatchSize,
+		BatchTimeout: c.BatchTimeout,
+		WriteTimeout: c.WriteTimeout,
+		Transport:    transport,
+	}, nil
+}
+
+func (c ProducerConfig) transport() (*kafka.Transport, error) {
+	transport := &kafka.Transport{ClientID: c.ClientID}
+
+	if c.TLS.Enabled {
+		tlsConfig, err := c.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLS = tlsConfig
+	}
+
+	if c.SASL.Mechanism != "" {
+		mechanism, err := c.SASL.mechanism()
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = mechanism
+	}
+
+	return transport, nil
+}
+
+func (c TLSConfig) load() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		caCert, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: reading tls ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("%w: no certificates in %s", ErrInvalidConfig, c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: loading tls client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func (c SASLConfig) mechanism() (sasl.Mechanism, error) {
+	switch strings.ToLower(c.Mechanism) {
+	case "plain":
+		return plain.Mechanism{Username: c.Username, Password: c.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, c.Username, c.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, c.Username, c.Password)
+	default:
+		return nil, fmt.Errorf("%w: unknown sasl mechanism %q", ErrInvalidConfig, c.Mechanism)
+	}
+}
+
+func requiredAcks(acks string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(acks) {
+	case "", "all":
+		return kafka.RequireAll, nil
+	case "one", "1":
+		return kafka.RequireOne, nil
+	case "none", "0":
+		return kafka.RequireNone, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown acks %q", ErrInvalidConfig, acks)
+	}
+}
+
+func compressionCodec(compression string) (kafka.Compression, error) {
+	switch strings.ToLower(compression) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown compression %q", ErrInvalidConfig, compression)
+	}
+}
@@ -0,0 +1,20 @@
+package kafka_test
+
+import (
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// kafkatest partitions like kafka.Hash, a different balancer here would let tests pass on an ordering production lacks
+func TestWriterBalancesByKey(t *testing.T) {
+	writer, err := kafka.ProducerConfig{Brokers: []string{"localhost:9092"}, Acks: "all", Compression: "none"}.Writer()
+	if err != nil {
+		t.Fatalf("writer: %v", err)
+	}
+
+	if _, ok := writer.Balancer.(*kafkago.Hash); !ok {
+		t.Fatalf("balancer = %T, want *kafka.Hash", writer.Balancer)
+	}
+}
@@ -20,6 +20,8 @@ func NewConsumer(brokers []string, topic string, groupId string) *KafkaConsumer
 	}
 }
 
+// ReadMessage, Read and ReadEvent commit the offset before returning, a crash while handling loses the message.
+// use Fetch together with Commit or a CommitBatcher when the handler must succeed first
 func (c *KafkaConsumer) ReadMessage(ctx context.Context) ([]byte, []byte, error) {
 	msg, err := c.reader.ReadMessage(ctx) //when ctx is cancelled or times out, this will return an error
 	if err != nil {
@@ -29,6 +31,53 @@ func (c *KafkaConsumer) ReadMessage(ctx context.Context) ([]byte, []byte, error)
 	return msg.Key, msg.Value, nil
 }
 
+// same as ReadMessage but keeps topic, partition, offset and headers
+func (c *KafkaConsumer) Read(ctx context.Context) (Message, error) {
+	msg, err := c.reader.ReadMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return fromKafkaMessage(msg), nil
+}
+
+// does not commit, the message is redelivered after a restart or rebalance until it is passed to Commit
+func (c *KafkaConsumer) Fetch(ctx context.Context) (Message, error) {
+	msg, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return fromKafkaMessage(msg), nil
+}
+
+// commits the highest offset per partition among msgs, which also commits every earlier offset of that partition
+func (c *KafkaConsumer) Commit(ctx context.Context, msgs ...Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	kafkaMessages := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		kafkaMessages[i] = kafka.Message{
+			Topic:     msg.Topic,
+			Partition: msg.Partition,
+			Offset:    msg.Offset,
+		}
+	}
+
+	return c.reader.CommitMessages(ctx, kafkaMessages...)
+}
+
+func (c *KafkaConsumer) ReadEvent(ctx context.Context) (Envelope, error) {
+	_, value, err := c.ReadMessage(ctx)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return DecodeEvent(value)
+}
+
 func (c *KafkaConsumer) Close() error {
 	return c.reader.Close()
 }
@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrInvalidEnvelope = errors.New("invalid event envelope")
+	ErrInvalidPayload  = errors.New("invalid event payload")
+)
+
+// wire format shared by every service, the payload shape is decided by Type and Version
+type Envelope struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	AggregateID   string          `json:"aggregateId"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Producer      string          `json:"producer"`
+	CorrelationID string          `json:"correlationId,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func NewEnvelope(eventType string, version int, aggregateID string, producer string, payload any) (Envelope, error) {
+	id, err := NewEventID()
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	return Envelope{
+		ID:          id,
+		Type:        eventType,
+		Version:     version,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Producer:    producer,
+		Payload:     encodedPayload,
+	}, nil
+}
+
+func EncodeEvent(envelope Envelope) ([]byte, error) {
+	if err := envelope.validate(); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope)
+}
+
+func DecodeEvent(data []byte) (Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return Envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	if err := envelope.validate(); err != nil {
+		return Envelope{}, err
+	}
+
+	return envelope, nil
+}
+
+// payload should be a pointer to the struct registered for e.Type, e.g. *OrderCreated for order.created
+func (e Envelope) DecodePayload(payload any) error {
+	if err := json.Unmarshal(e.Payload, payload); err != nil {
+		return fmt.Errorf("%w: decode %s v%d: %w", ErrInvalidPayload, e.Type, e.Version, err)
+	}
+	return nil
+}
+
+func (e Envelope) validate() error {
+	switch {
+	case e.ID == "":
+		return fmt.Errorf("%w: missing id", ErrInvalidEnvelope)
+	case e.Type == "":
+		return fmt.Errorf("%w: missing type", ErrInvalidEnvelope)
+	case e.Version < 1:
+		return fmt.Errorf("%w: version must be at least 1", ErrInvalidEnvelope)
+	case len(e.Payload) == 0 || string(e.Payload) == "null":
+		return fmt.Errorf("%w: missing payload", ErrInvalidEnvelope)
+	}
+	return nil
+}
+
+// random uuid v4, kept here so the kafka package doesn't need a uuid dependency
+func NewEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,121 @@
+package kafka_test
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	envelope, err := kafka.NewEnvelope(kafka.EventOrderCompleted, 2, "order-1", "order-service", kafka.OrderCompleted{
+		OrderID:     "order-1",
+		TotalAmount: 184500,
+	})
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	envelope.CorrelationID = "corr-1"
+
+	encoded, err := kafka.EncodeEvent(envelope)
+	if err != nil {
+		t.Fatalf("EncodeEvent: %v", err)
+	}
+	decoded, err := kafka.DecodeEvent(encoded)
+	if err != nil {
+		t.Fatalf("DecodeEvent: %v", err)
+	}
+
+	if decoded.ID != envelope.ID || decoded.Type != kafka.EventOrderCompleted || decoded.Version != 2 ||
+		decoded.AggregateID != "order-1" || decoded.Producer != "order-service" || decoded.CorrelationID != "corr-1" ||
+		!decoded.OccurredAt.Equal(envelope.OccurredAt) {
+		t.Fatalf("decoded %+v, want %+v", decoded, envelope)
+	}
+
+	var payload kafka.OrderCompleted
+	if err := decoded.DecodePayload(&payload); err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if payload.OrderID != "order-1" || payload.TotalAmount != 184500 {
+		t.Fatalf("payload = %+v", payload)
+	}
+}
+
+func TestEnvelopeRejectsIncompleteEvents(t *testing.T) {
+	valid := kafka.Envelope{
+		ID:         "evt-1",
+		Type:       kafka.EventOrderCreated,
+		Version:    1,
+		OccurredAt: time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC),
+		Payload:    json.RawMessage(`{}`),
+	}
+
+	tests := []struct {
+		name   string
+		change func(*kafka.Envelope)
+	}{
+		{name: "missing id", change: func(e *kafka.Envelope) { e.ID = "" }},
+		{name: "missing type", change: func(e *kafka.Envelope) { e.Type = "" }},
+		{name: "version zero", change: func(e *kafka.Envelope) { e.Version = 0 }},
+		{name: "missing payload", change: func(e *kafka.Envelope) { e.Payload = nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope := valid
+			tt.change(&envelope)
+
+			if _, err := kafka.EncodeEvent(envelope); !errors.Is(err, kafka.ErrInvalidEnvelope) {
+				t.Fatalf("EncodeEvent error = %v, want %v", err, kafka.ErrInvalidEnvelope)
+			}
+
+			//a producer that skips EncodeEvent must still be caught on the way in
+			raw, err := json.Marshal(envelope)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if _, err := kafka.DecodeEvent(raw); !errors.Is(err, kafka.ErrInvalidEnvelope) {
+				t.Fatalf("DecodeEvent error = %v, want %v", err, kafka.ErrInvalidEnvelope)
+			}
+		})
+	}
+}
+
+func TestDecodeEventRejectsMalformedJSON(t *testing.T) {
+	for _, input := range []string{``, `not json`, `{"id": 1}`, `[]`} {
+		if _, err := kafka.DecodeEvent([]byte(input)); !errors.Is(err, kafka.ErrInvalidEnvelope) {
+			t.Errorf("DecodeEvent(%q) error = %v, want %v", input, err, kafka.ErrInvalidEnvelope)
+		}
+	}
+}
+
+func TestDecodePayloadWrapsErrors(t *testing.T) {
+	envelope := kafka.Envelope{Type: kafka.EventPaymentSucceeded, Version: 1, Payload: json.RawMessage(`{"amount": "lots"}`)}
+
+	var payload kafka.PaymentSucceeded
+	if err := envelope.DecodePayload(&payload); !errors.Is(err, kafka.ErrInvalidPayload) {
+		t.Fatalf("DecodePayload error = %v, want %v", err, kafka.ErrInvalidPayload)
+	}
+}
+
+func TestNewEventIDIsUUIDv4(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id, err := kafka.NewEventID()
+		if err != nil {
+			t.Fatalf("NewEventID: %v", err)
+		}
+		if !pattern.MatchString(id) {
+			t.Fatalf("NewEventID() = %q, not a uuid v4", id)
+		}
+		if seen[id] {
+			t.Fatalf("NewEventID() repeated %q", id)
+		}
+		seen[id] = true
+	}
+}
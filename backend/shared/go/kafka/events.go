@@ -0,0 +1,200 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// bump a version only for breaking payload changes, consumers switch on Type and Version together
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventOrderCancelled     = "order.cancelled"
+	EventOrderCompleted     = "order.completed"
+	EventRefundRequested    = "order.refund_requested"
+	EventOrderRepriced      = "order.repriced"
+
+	// payment-service publishes successful payments as payment.paid
+	EventPaymentSucceeded = "payment.paid"
+	EventPaymentFailed    = "payment.failed"
+	EventPaymentExpired   = "payment.expired"
+
+	OrderCreatedVersion       = 1
+	OrderStatusChangedVersion = 1
+	OrderCancelledVersion     = 1
+	OrderCompletedVersion     = 1
+	RefundRequestedVersion    = 1
+	OrderRepricedVersion      = 1
+	PaymentSucceededVersion   = 1
+	PaymentFailedVersion      = 1
+	PaymentExpiredVersion     = 1
+)
+
+// an amount in whole rupiah, written as a plain json number the same way payment-service writes amounts.
+// an integer so totals survive the trip exactly, a float64 would round them once they pass 2^53
+type Rupiah int64
+
+// accepts numbers and numeric strings, 150000.00 reads as 150000 but a fraction of a rupiah is an invalid payload
+func (r *Rupiah) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+
+	amount, ok := new(big.Rat).SetString(number.String())
+	if !ok || !amount.IsInt() || !amount.Num().IsInt64() {
+		return fmt.Errorf("%w: %s is not a whole rupiah amount", ErrInvalidPayload, number)
+	}
+
+	*r = Rupiah(amount.Num().Int64())
+	return nil
+}
+
+// orders split from one checkout share CheckoutID, payment charges the checkout once
+type OrderCreated struct {
+	OrderID        string             `json:"orderId"`
+	OrderNumber    string             `json:"orderNumber"`
+	CheckoutID     string             `json:"checkoutId,omitempty"`
+	UserID         string             `json:"userId"`
+	Status         string             `json:"status"`
+	Subtotal       Rupiah             `json:"subtotal"`
+	ShippingCost   Rupiah             `json:"shippingCost"`
+	TaxAmount      Rupiah             `json:"taxAmount"`
+	DiscountAmount Rupiah             `json:"discountAmount"`
+	TotalAmount    Rupiah             `json:"totalAmount"`
+	ShippingPhone  string             `json:"shippingPhone"`
+	Items          []OrderCreatedItem `json:"items"`
+	CreatedAt      time.Time          `json:"createdAt"`
+}
+
+type OrderCreatedItem struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	FactoryID string  `json:"factoryId"`
+	SKU       string  `json:"sku"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice Rupiah  `json:"unitPrice"`
+	Subtotal  Rupiah  `json:"subtotal"`
+}
+
+type OrderStatusChanged struct {
+	OrderID       string    `json:"orderId"`
+	OrderNumber   string    `json:"orderNumber"`
+	UserID        string    `json:"userId"`
+	FromStatus    string    `json:"fromStatus"`
+	ToStatus      string    `json:"toStatus"`
+	Reason        string    `json:"reason,omitempty"`
+	ChangedByType string    `json:"changedByType,omitempty"`
+	ChangedAt     time.Time `json:"changedAt"`
+}
+
+// warehouse releases the reservations of the items, payment refunds when RefundRequired is set
+type OrderCancelled struct {
+	OrderID        string               `json:"orderId"`
+	OrderNumber    string               `json:"orderNumber"`
+	UserID         string               `json:"userId"`
+	PreviousStatus string               `json:"previousStatus"`
+	Reason         string               `json:"reason"`
+	CancelledBy    string               `json:"cancelledBy"`
+	RefundRequired bool                 `json:"refundRequired"`
+	TotalAmount    Rupiah               `json:"totalAmount"`
+	Items          []OrderCancelledItem `json:"items"`
+	CancelledAt    time.Time            `json:"cancelledAt"`
+}
+
+type OrderCancelledItem struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	Quantity  int     `json:"quantity"`
+}
+
+// seller payouts and review requests start here, CompletedBy is customer when they confirmed receipt and system after the window
+type OrderCompleted struct {
+	OrderID     string               `json:"orderId"`
+	OrderNumber string               `json:"orderNumber"`
+	UserID      string               `json:"userId"`
+	TotalAmount Rupiah               `json:"totalAmount"`
+	CompletedBy string               `json:"completedBy"`
+	Items       []OrderCompletedItem `json:"items"`
+	DeliveredAt *time.Time           `json:"deliveredAt,omitempty"`
+	CompletedAt time.Time            `json:"completedAt"`
+}
+
+type OrderCompletedItem struct {
+	ProductID string  `json:"productId"`
+	VariantID *string `json:"variantId,omitempty"`
+	FactoryID string  `json:"factoryId"`
+	Quantity  int     `json:"quantity"`
+	Subtotal  Rupiah  `json:"subtotal"`
+}
+
+// written when a return completes, payment-service refunds Amount through RefundMethod, store_credit goes to the wallet
+type RefundRequested struct {
+	ReturnID     string                `json:"returnId"`
+	ReturnNumber string                `json:"returnNumber"`
+	OrderID      string                `json:"orderId"`
+	OrderNumber  string                `json:"orderNumber"`
+	UserID       string                `json:"userId"`
+	ReturnType   string                `json:"returnType"`
+	RefundMethod string                `json:"refundMethod"`
+	Amount       Rupiah                `json:"amount"`
+	FullRefund   bool                  `json:"fullRefund"`
+	Items        []RefundRequestedItem `json:"items"`
+	RequestedAt  time.Time             `json:"requestedAt"`
+}
+
+type RefundRequestedItem struct {
+	OrderItemID string  `json:"orderItemId"`
+	ProductID   string  `json:"productId"`
+	VariantID   *string `json:"variantId,omitempty"`
+	Quantity    int     `json:"quantity"`
+	Amount      Rupiah  `json:"amount"`
+}
+
+// the amounts of an unpaid order changed after checkout, payment charges the new TotalAmount
+type OrderRepriced struct {
+	OrderID              string    `json:"orderId"`
+	OrderNumber          string    `json:"orderNumber"`
+	CheckoutID           string    `json:"checkoutId,omitempty"`
+	UserID               string    `json:"userId"`
+	PreviousShippingCost Rupiah    `json:"previousShippingCost"`
+	ShippingCost         Rupiah    `json:"shippingCost"`
+	TaxAmount            Rupiah    `json:"taxAmount"`
+	DiscountAmount       Rupiah    `json:"discountAmount"`
+	TotalAmount          Rupiah    `json:"totalAmount"`
+	Reason               string    `json:"reason"`
+	RepricedBy           string    `json:"repricedBy,omitempty"`
+	RepricedAt           time.Time `json:"repricedAt"`
+}
+
+type PaymentSucceeded struct {
+	PaymentID            string    `json:"paymentId"`
+	PaymentNumber        string    `json:"paymentNumber"`
+	OrderID              string    `json:"orderId"`
+	UserID               string    `json:"userId"`
+	Amount               Rupiah    `json:"amount"`
+	GatewayFee           Rupiah    `json:"gatewayFee"`
+	NetAmount            Rupiah    `json:"netAmount"`
+	GatewayTransactionID *string   `json:"gatewayTransactionId"`
+	PaidAt               time.Time `json:"paidAt"`
+}
+
+type PaymentFailed struct {
+	PaymentID     string    `json:"paymentId"`
+	PaymentNumber string    `json:"paymentNumber"`
+	OrderID       string    `json:"orderId"`
+	UserID        string    `json:"userId"`
+	FailureReason *string   `json:"failureReason"`
+	FailureCode   *string   `json:"failureCode"`
+	FailedAt      time.Time `json:"failedAt"`
+}
+
+type PaymentExpired struct {
+	PaymentID     string    `json:"paymentId"`
+	PaymentNumber string    `json:"paymentNumber"`
+	OrderID       string    `json:"orderId"`
+	UserID        string    `json:"userId"`
+	ExpiredAt     time.Time `json:"expiredAt"`
+}
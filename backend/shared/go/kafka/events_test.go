@@ -0,0 +1,61 @@
+package kafka_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+func TestRupiahDecodesWholeAmounts(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want kafka.Rupiah
+	}{
+		{name: "number", json: `150000`, want: 150000},
+		{name: "trailing zero cents", json: `150000.00`, want: 150000},
+		{name: "numeric string", json: `"150000"`, want: 150000},
+		{name: "exponent", json: `1.5e5`, want: 150000},
+		{name: "past float64 precision", json: `9007199254740993`, want: 9007199254740993},
+		{name: "negative", json: `-5000`, want: -5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var amount kafka.Rupiah
+			if err := json.Unmarshal([]byte(tt.json), &amount); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", tt.json, err)
+			}
+			if amount != tt.want {
+				t.Fatalf("Unmarshal(%s) = %d, want %d", tt.json, amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestRupiahRejectsFractions(t *testing.T) {
+	for _, input := range []string{`150000.5`, `"0.01"`, `1e-2`, `99999999999999999999`} {
+		var amount kafka.Rupiah
+		if err := json.Unmarshal([]byte(input), &amount); !errors.Is(err, kafka.ErrInvalidPayload) {
+			t.Errorf("Unmarshal(%s) error = %v, want %v", input, err, kafka.ErrInvalidPayload)
+		}
+	}
+}
+
+// consumers in other languages read the amounts as plain json numbers
+func TestRupiahEncodesAsNumber(t *testing.T) {
+	encoded, err := json.Marshal(kafka.PaymentSucceeded{Amount: 150000, GatewayFee: 4440, NetAmount: 145560})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded["amount"] != float64(150000) || decoded["netAmount"] != float64(145560) {
+		t.Fatalf("encoded as %s, want plain numbers", encoded)
+	}
+}
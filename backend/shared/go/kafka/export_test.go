@@ -0,0 +1,8 @@
+package kafka
+
+import "github.com/segmentio/kafka-go"
+
+// the writer kafka.NewProducerFromConfig would build, for checking its settings without a broker
+func (c ProducerConfig) Writer() (*kafka.Writer, error) {
+	return c.writer()
+}
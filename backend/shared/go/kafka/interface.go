@@ -1,11 +1,25 @@
 package kafka
 
-// type Consumer interface {
-// 	ReadMessage(ctx context.Context) ([]byte, []byte, error)
-// 	Close() error
-// }
-
-// type Producer interface {
-// 	ReadMessage(ctx context.Context) ([]byte, []byte, error)
-// 	Close() error
-// }
+import "context"
+
+// implemented by KafkaConsumer and by the in-memory consumer in kafkatest
+type Consumer interface {
+	ReadMessage(ctx context.Context) ([]byte, []byte, error)
+	Read(ctx context.Context) (Message, error)
+	Fetch(ctx context.Context) (Message, error)
+	Commit(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// implemented by KafkaProducer and by the in-memory producer in kafkatest
+type Producer interface {
+	PublishMessage(ctx context.Context, key []byte, value []byte) error
+	Publish(ctx context.Context, msgs ...Message) error
+	PublishEvent(ctx context.Context, envelope Envelope) error
+	Close() error
+}
+
+var (
+	_ Consumer = (*KafkaConsumer)(nil)
+	_ Producer = (*KafkaProducer)(nil)
+)
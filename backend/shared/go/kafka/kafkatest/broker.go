@@ -0,0 +1,259 @@
+package kafkatest
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+var (
+	ErrClosed          = errors.New("kafkatest: closed")
+	ErrMissingTopic    = errors.New("kafkatest: message has no topic")
+	ErrNotAssigned     = errors.New("kafkatest: partition is not assigned to this consumer")
+	ErrNoConsumerGroup = errors.New("kafkatest: commit needs a consumer group")
+)
+
+// in-process stand-in for a kafka cluster, topics are created on first use with the broker's partition count.
+// messages land on the partition kafka.Hash, the balancer of kafka.NewProducer, would pick, so ordering per key holds
+type Broker struct {
+	mu         sync.Mutex
+	partitions int
+	topics     map[string][][]kafka.Message
+	groups     map[groupKey]*group
+	changed    chan struct{} //closed and replaced whenever a fetch could make progress
+	nextMember int
+}
+
+type groupKey struct {
+	groupId string
+	topic   string
+}
+
+type group struct {
+	committed  map[int]int64 //next offset to read per partition
+	members    []*Consumer
+	generation int //bumped on every join and leave, members rewind to committed offsets when it changes
+}
+
+func NewBroker(partitions int) *Broker {
+	if partitions < 1 {
+		partitions = 1
+	}
+
+	return &Broker{
+		partitions: partitions,
+		topics:     make(map[string][][]kafka.Message),
+		groups:     make(map[groupKey]*group),
+		changed:    make(chan struct{}),
+	}
+}
+
+// topic may be empty, the producer then routes every message by its own Topic field like kafka.NewProducer
+func (b *Broker) NewProducer(topic string) *Producer {
+	return &Producer{broker: b, config: kafka.ProducerConfig{Topic: topic}}
+}
+
+// same topic and event type routing as kafka.NewProducerFromConfig, connection settings are ignored
+func (b *Broker) NewProducerFromConfig(config kafka.ProducerConfig) *Producer {
+	return &Producer{broker: b, config: config}
+}
+
+// an empty groupId reads every partition from the beginning and cannot commit
+func (b *Broker) NewConsumer(topic string, groupId string) *Consumer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextMember++
+	consumer := &Consumer{
+		broker:    b,
+		topic:     topic,
+		groupId:   groupId,
+		memberId:  b.nextMember,
+		positions: make(map[int]int64),
+	}
+
+	if groupId != "" {
+		g := b.group(groupId, topic)
+		g.members = append(g.members, consumer)
+		g.generation++
+	}
+	b.topic(topic)
+	b.notify()
+
+	return consumer
+}
+
+// every message written to topic so far, in partition then offset order
+func (b *Broker) Messages(topic string) []kafka.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var messages []kafka.Message
+	for _, partition := range b.topics[topic] {
+		messages = append(messages, partition...)
+	}
+	return messages
+}
+
+// next offset the group reads from partition, 0 when nothing was committed
+func (b *Broker) CommittedOffset(groupId string, topic string, partition int) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	g, ok := b.groups[groupKey{groupId, topic}]
+	if !ok {
+		return 0
+	}
+	return g.committed[partition]
+}
+
+// messages the group has not committed yet across all partitions
+func (b *Broker) Lag(groupId string, topic string) int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lag int64
+	g := b.groups[groupKey{groupId, topic}]
+	for partition, messages := range b.topics[topic] {
+		lag += int64(len(messages))
+		if g != nil {
+			lag -= g.committed[partition]
+		}
+	}
+	return lag
+}
+
+// blocks until the group has committed everything on topic, handy for waiting on asynchronous consumers
+func (b *Broker) WaitForCommit(ctx context.Context, groupId string, topic string) error {
+	for {
+		b.mu.Lock()
+		changed := b.changed
+		b.mu.Unlock()
+
+		if b.Lag(groupId, topic) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// keyless is the producer's round robin counter, the real writer keeps one per writer across all topics
+func (b *Broker) write(msgs []kafka.Message, keyless *uint32) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, msg := range msgs {
+		if msg.Topic == "" {
+			return ErrMissingTopic
+		}
+	}
+
+	for _, msg := range msgs {
+		partitions := b.topic(msg.Topic)
+		partition := b.partitionFor(msg.Key, keyless)
+
+		msg.Partition = partition
+		msg.Offset = int64(len(partitions[partition]))
+		if msg.Time.IsZero() {
+			msg.Time = time.Now()
+		}
+		msg.Headers = copyHeaders(msg.Headers)
+		partitions[partition] = append(partitions[partition], msg)
+	}
+
+	b.notify()
+	return nil
+}
+
+// fnv-1a of the key taken as a signed int32, the way kafka.Hash and sarama compute it. only a nil key goes
+// round robin, an empty one is hashed like any other. callers hold b.mu
+func (b *Broker) partitionFor(key []byte, keyless *uint32) int {
+	if key == nil {
+		partition := int(*keyless % uint32(b.partitions))
+		*keyless++
+		return partition
+	}
+
+	hash := fnv.New32a()
+	hash.Write(key)
+	partition := int32(hash.Sum32()) % int32(b.partitions)
+	if partition < 0 {
+		partition = -partition
+	}
+	return int(partition)
+}
+
+// callers hold b.mu
+func (b *Broker) topic(name string) [][]kafka.Message {
+	partitions, ok := b.topics[name]
+	if !ok {
+		partitions = make([][]kafka.Message, b.partitions)
+		b.topics[name] = partitions
+	}
+	return partitions
+}
+
+func (b *Broker) group(groupId string, topic string) *group {
+	key := groupKey{groupId, topic}
+	g, ok := b.groups[key]
+	if !ok {
+		g = &group{committed: make(map[int]int64)}
+		b.groups[key] = g
+	}
+	return g
+}
+
+func (b *Broker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// partitions are dealt out to members in join order, the same way the range assignor does for a single topic
+func (g *group) assigned(consumer *Consumer, partitions int) []int {
+	index := -1
+	for i, member := range g.members {
+		if member == consumer {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	var assigned []int
+	for partition := index; partition < partitions; partition += len(g.members) {
+		assigned = append(assigned, partition)
+	}
+	return assigned
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(headers))
+	for key, value := range headers {
+		copied[key] = value
+	}
+	return copied
+}
+
+func sortedPartitions(positions map[int]int64) []int {
+	partitions := make([]int, 0, len(positions))
+	for partition := range positions {
+		partitions = append(partitions, partition)
+	}
+	sort.Ints(partitions)
+	return partitions
+}
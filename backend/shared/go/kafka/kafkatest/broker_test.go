@@ -0,0 +1,324 @@
+package kafkatest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka/kafkatest"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const topic = "order_event"
+
+func publish(t *testing.T, producer *kafkatest.Producer, msgs ...kafka.Message) {
+	t.Helper()
+
+	if err := producer.Publish(context.Background(), msgs...); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func fetch(t *testing.T, consumer *kafkatest.Consumer) kafka.Message {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	msg, err := consumer.Fetch(ctx)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	return msg
+}
+
+// fails when a message is waiting, Fetch would otherwise block the test
+func assertEmpty(t *testing.T, consumer *kafkatest.Consumer) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if msg, err := consumer.Fetch(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Fetch = %s/%v, want nothing left", msg.Value, err)
+	}
+}
+
+func TestBrokerKeepsKeyOrder(t *testing.T) {
+	broker := kafkatest.NewBroker(4)
+	producer := broker.NewProducer(topic)
+
+	for i := 0; i < 5; i++ {
+		for _, key := range []string{"order-1", "order-2", "order-3"} {
+			publish(t, producer, kafka.Message{Key: []byte(key), Value: []byte(fmt.Sprintf("%s/%d", key, i))})
+		}
+	}
+
+	partitions := make(map[string]int)
+	next := make(map[string]int)
+	for _, msg := range broker.Messages(topic) {
+		key := string(msg.Key)
+		if partition, ok := partitions[key]; ok && partition != msg.Partition {
+			t.Fatalf("%s written to partitions %d and %d", key, partition, msg.Partition)
+		}
+		partitions[key] = msg.Partition
+
+		if want := fmt.Sprintf("%s/%d", key, next[key]); string(msg.Value) != want {
+			t.Fatalf("read %s, want %s", msg.Value, want)
+		}
+		next[key]++
+	}
+}
+
+func TestBrokerSpreadsKeylessMessages(t *testing.T) {
+	broker := kafkatest.NewBroker(3)
+	producer := broker.NewProducer(topic)
+
+	for i := 0; i < 6; i++ {
+		publish(t, producer, kafka.Message{Value: []byte{byte(i)}})
+	}
+
+	counts := make(map[int]int)
+	for _, msg := range broker.Messages(topic) {
+		counts[msg.Partition]++
+	}
+	for partition := 0; partition < 3; partition++ {
+		if counts[partition] != 2 {
+			t.Fatalf("partition counts = %v, want 2 each", counts)
+		}
+	}
+}
+
+func TestBrokerRejectsMessagesWithoutTopic(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+
+	err := broker.NewProducer("").Publish(context.Background(), kafka.Message{Value: []byte("lost")})
+	if !errors.Is(err, kafkatest.ErrMissingTopic) {
+		t.Fatalf("Publish error = %v, want %v", err, kafkatest.ErrMissingTopic)
+	}
+	if got := broker.Messages(""); len(got) != 0 {
+		t.Fatalf("%d messages written without a topic", len(got))
+	}
+}
+
+// members of one group split the partitions, every message reaches exactly one of them
+func TestConsumerGroupSplitsPartitions(t *testing.T) {
+	broker := kafkatest.NewBroker(2)
+	first := broker.NewConsumer(topic, "notification-service")
+	second := broker.NewConsumer(topic, "notification-service")
+	producer := broker.NewProducer(topic)
+
+	for i := 0; i < 20; i++ {
+		publish(t, producer, kafka.Message{Key: []byte(fmt.Sprintf("order-%d", i)), Value: []byte{byte(i)}})
+	}
+
+	seen := make(map[int]bool)
+	for _, consumer := range []*kafkatest.Consumer{first, second} {
+		partition := -1
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			msg, err := consumer.Read(ctx)
+			cancel()
+			if errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Read: %v", err)
+			}
+
+			if partition >= 0 && msg.Partition != partition {
+				t.Fatalf("one member read partitions %d and %d", partition, msg.Partition)
+			}
+			partition = msg.Partition
+			if seen[int(msg.Value[0])] {
+				t.Fatalf("message %d delivered twice", msg.Value[0])
+			}
+			seen[int(msg.Value[0])] = true
+		}
+	}
+
+	if len(seen) != 20 {
+		t.Fatalf("group read %d messages, want 20", len(seen))
+	}
+	if lag := broker.Lag("notification-service", topic); lag != 0 {
+		t.Fatalf("lag = %d after reading everything", lag)
+	}
+}
+
+// what a member fetched but never committed goes to the member that takes over its partition
+func TestUncommittedMessagesAreRedeliveredAfterRebalance(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	producer := broker.NewProducer(topic)
+	crashing := broker.NewConsumer(topic, "order-service")
+
+	publish(t, producer,
+		kafka.Message{Key: []byte("order-1"), Value: []byte("first")},
+		kafka.Message{Key: []byte("order-1"), Value: []byte("second")},
+	)
+
+	first := fetch(t, crashing)
+	if err := crashing.Commit(context.Background(), first); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	fetch(t, crashing)
+	if lag := broker.Lag("order-service", topic); lag != 1 {
+		t.Fatalf("lag = %d, want the uncommitted message", lag)
+	}
+	if err := crashing.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	replacement := broker.NewConsumer(topic, "order-service")
+	if msg := fetch(t, replacement); string(msg.Value) != "second" {
+		t.Fatalf("replacement read %s, want second", msg.Value)
+	}
+	assertEmpty(t, replacement)
+}
+
+func TestCommitNeverMovesBackwards(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	producer := broker.NewProducer(topic)
+	consumer := broker.NewConsumer(topic, "order-service")
+
+	publish(t, producer, kafka.Message{Value: []byte("a")}, kafka.Message{Value: []byte("b")})
+	a, b := fetch(t, consumer), fetch(t, consumer)
+
+	ctx := context.Background()
+	if err := consumer.Commit(ctx, b); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := consumer.Commit(ctx, a); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if offset := broker.CommittedOffset("order-service", topic, 0); offset != 2 {
+		t.Fatalf("committed offset = %d, want 2", offset)
+	}
+}
+
+func TestConsumerCommitErrors(t *testing.T) {
+	broker := kafkatest.NewBroker(1)
+	publish(t, broker.NewProducer(topic), kafka.Message{Value: []byte("a")})
+	ctx := context.Background()
+
+	reader := broker.NewConsumer(topic, "")
+	msg := fetch(t, reader)
+	if err := reader.Commit(ctx, msg); !errors.Is(err, kafkatest.ErrNoConsumerGroup) {
+		t.Fatalf("Commit without group error = %v, want %v", err, kafkatest.ErrNoConsumerGroup)
+	}
+
+	member := broker.NewConsumer(topic, "order-service")
+	if err := member.Commit(ctx, kafka.Message{Topic: "payment_event"}); !errors.Is(err, kafkatest.ErrNotAssigned) {
+		t.Fatalf("Commit of another topic error = %v, want %v", err, kafkatest.ErrNotAssigned)
+	}
+
+	member.Close()
+	if err := member.Commit(ctx, msg); !errors.Is(err, kafkatest.ErrClosed) {
+		t.Fatalf("Commit after Close error = %v, want %v", err, kafkatest.ErrClosed)
+	}
+	if _, err := member.Fetch(ctx); !errors.Is(err, kafkatest.ErrClosed) {
+		t.Fatalf("Fetch after Close error = %v, want %v", err, kafkatest.ErrClosed)
+	}
+}
+
+func TestWaitForCommit(t *testing.T) {
+	broker := kafkatest.NewBroker(2)
+	publish(t, broker.NewProducer(topic), kafka.Message{Key: []byte("order-1"), Value: []byte("a")})
+	consumer := broker.NewConsumer(topic, "order-service")
+
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		done <- broker.WaitForCommit(ctx, "order-service", topic)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("WaitForCommit returned %v before anything was committed", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := consumer.Read(ctx); err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("WaitForCommit: %v", err)
+	}
+}
+
+// routed by event type and keyed by aggregate like kafka.KafkaProducer, so one order's events stay in order
+func TestPublishEventRoutesByType(t *testing.T) {
+	broker := kafkatest.NewBroker(3)
+	producer := broker.NewProducerFromConfig(kafka.ProducerConfig{
+		Topic:  topic,
+		Routes: map[string]string{kafka.EventPaymentSucceeded: "payment_event"},
+	})
+	ctx := context.Background()
+
+	for _, eventType := range []string{kafka.EventOrderCreated, kafka.EventPaymentSucceeded} {
+		err := producer.PublishEvent(ctx, kafka.Envelope{ID: "evt-" + eventType, Type: eventType, Version: 1, AggregateID: "order-1", Payload: json.RawMessage(`{}`)})
+		if err != nil {
+			t.Fatalf("PublishEvent(%s): %v", eventType, err)
+		}
+	}
+
+	for name, eventType := range map[string]string{topic: kafka.EventOrderCreated, "payment_event": kafka.EventPaymentSucceeded} {
+		reader := broker.NewConsumer(name, "")
+		readCtx, cancel := context.WithTimeout(ctx, time.Second)
+		envelope, err := reader.ReadEvent(readCtx)
+		cancel()
+		if err != nil {
+			t.Fatalf("ReadEvent(%s): %v", name, err)
+		}
+		if envelope.Type != eventType || envelope.AggregateID != "order-1" {
+			t.Fatalf("%s carried %s for %s, want %s", name, envelope.Type, envelope.AggregateID, eventType)
+		}
+		if msgs := broker.Messages(name); len(msgs) != 1 || string(msgs[0].Key) != "order-1" {
+			t.Fatalf("%s holds %+v, want one message keyed order-1", name, msgs)
+		}
+		assertEmpty(t, reader)
+	}
+}
+
+// a test that passes against the fake must see the partitions the real writer would produce
+func TestBrokerPartitionsLikeKafkaHash(t *testing.T) {
+	keys := [][]byte{nil, {}, []byte("order-1"), []byte("6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"), nil}
+	for i := 0; i < 200; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("order-%d", i)))
+		if i%7 == 0 {
+			keys = append(keys, nil)
+		}
+	}
+
+	for _, partitions := range []int{1, 3, 7, 12} {
+		t.Run(fmt.Sprintf("%d partitions", partitions), func(t *testing.T) {
+			broker := kafkatest.NewBroker(partitions)
+			producer := broker.NewProducer("")
+			balancer := &kafkago.Hash{}
+			ids := make([]int, partitions)
+			for i := range ids {
+				ids[i] = i
+			}
+
+			//keyless messages go round robin across every topic the producer writes to, like one writer does
+			want := make(map[byte]int)
+			for i, key := range keys {
+				name := []string{"order_event", "payment_event"}[i%2]
+				publish(t, producer, kafka.Message{Topic: name, Key: key, Value: []byte{byte(i)}})
+				want[byte(i)] = balancer.Balance(kafkago.Message{Key: key}, ids...)
+			}
+
+			for _, msg := range append(broker.Messages("order_event"), broker.Messages("payment_event")...) {
+				if msg.Partition != want[msg.Value[0]] {
+					t.Fatalf("message %d keyed %q written to partition %d, kafka.Hash picks %d", msg.Value[0], msg.Key, msg.Partition, want[msg.Value[0]])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package kafkatest
+
+import (
+	"context"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+var _ kafka.Consumer = (*Consumer)(nil)
+
+type Consumer struct {
+	broker     *Broker
+	topic      string
+	groupId    string
+	memberId   int
+	generation int
+	positions  map[int]int64 //next offset to fetch per assigned partition
+	closed     bool
+}
+
+func (c *Consumer) ReadMessage(ctx context.Context) ([]byte, []byte, error) {
+	msg, err := c.Read(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return msg.Key, msg.Value, nil
+}
+
+// commits before returning, same as kafka.KafkaConsumer.Read
+func (c *Consumer) Read(ctx context.Context) (kafka.Message, error) {
+	msg, err := c.Fetch(ctx)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	if c.groupId == "" {
+		return msg, nil
+	}
+	return msg, c.Commit(ctx, msg)
+}
+
+// blocks until a message is available on one of the assigned partitions or ctx is done
+func (c *Consumer) Fetch(ctx context.Context) (kafka.Message, error) {
+	for {
+		c.broker.mu.Lock()
+		if c.closed {
+			c.broker.mu.Unlock()
+			return kafka.Message{}, ErrClosed
+		}
+
+		c.rebalance()
+		partitions := c.broker.topics[c.topic]
+		for _, partition := range sortedPartitions(c.positions) {
+			position := c.positions[partition]
+			if position < int64(len(partitions[partition])) {
+				c.positions[partition] = position + 1
+				msg := partitions[partition][position]
+				msg.Headers = copyHeaders(msg.Headers)
+				c.broker.mu.Unlock()
+				return msg, nil
+			}
+		}
+
+		changed := c.broker.changed
+		c.broker.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// commits the highest offset per partition among msgs, offsets never move backwards
+func (c *Consumer) Commit(ctx context.Context, msgs ...kafka.Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+	if c.groupId == "" {
+		return ErrNoConsumerGroup
+	}
+
+	g := c.broker.group(c.groupId, c.topic)
+	for _, msg := range msgs {
+		if msg.Topic != c.topic {
+			return ErrNotAssigned
+		}
+		if next := msg.Offset + 1; next > g.committed[msg.Partition] {
+			g.committed[msg.Partition] = next
+		}
+	}
+
+	c.broker.notify()
+	return nil
+}
+
+func (c *Consumer) ReadEvent(ctx context.Context) (kafka.Envelope, error) {
+	_, value, err := c.ReadMessage(ctx)
+	if err != nil {
+		return kafka.Envelope{}, err
+	}
+
+	return kafka.DecodeEvent(value)
+}
+
+// leaves the group, uncommitted messages go to whichever member picks up the partition
+func (c *Consumer) Close() error {
+	c.broker.mu.Lock()
+	defer c.broker.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	if c.groupId != "" {
+		g := c.broker.group(c.groupId, c.topic)
+		for i, member := range g.members {
+			if member == c {
+				g.members = append(g.members[:i], g.members[i+1:]...)
+				break
+			}
+		}
+		g.generation++
+	}
+
+	c.broker.notify()
+	return nil
+}
+
+// after a join or leave every member restarts from the committed offsets of its new partitions. callers hold broker.mu
+func (c *Consumer) rebalance() {
+	if c.groupId == "" {
+		for partition := 0; partition < c.broker.partitions; partition++ {
+			if _, ok := c.positions[partition]; !ok {
+				c.positions[partition] = 0
+			}
+		}
+		return
+	}
+
+	g := c.broker.group(c.groupId, c.topic)
+	if c.generation == g.generation {
+		return
+	}
+
+	c.generation = g.generation
+	c.positions = make(map[int]int64)
+	for _, partition := range g.assigned(c, c.broker.partitions) {
+		c.positions[partition] = g.committed[partition]
+	}
+}
@@ -0,0 +1,56 @@
+package kafkatest
+
+import (
+	"context"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+)
+
+var _ kafka.Producer = (*Producer)(nil)
+
+type Producer struct {
+	broker  *Broker
+	config  kafka.ProducerConfig //only Topic and Routes are used
+	keyless uint32               //round robin position for messages without a key, guarded by broker.mu
+}
+
+func (p *Producer) PublishMessage(ctx context.Context, key []byte, value []byte) error {
+	return p.Publish(ctx, kafka.Message{
+		Key:   key,
+		Value: value,
+	})
+}
+
+// messages without a Topic go to the default topic, like kafka.KafkaProducer
+func (p *Producer) Publish(ctx context.Context, msgs ...kafka.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	routed := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		routed[i] = msg
+		if routed[i].Topic == "" {
+			routed[i].Topic = p.config.Topic
+		}
+	}
+
+	return p.broker.write(routed, &p.keyless)
+}
+
+func (p *Producer) PublishEvent(ctx context.Context, envelope kafka.Envelope) error {
+	value, err := kafka.EncodeEvent(envelope)
+	if err != nil {
+		return err
+	}
+
+	return p.Publish(ctx, kafka.Message{
+		Topic: p.config.TopicFor(envelope.Type),
+		Key:   []byte(envelope.AggregateID),
+		Value: value,
+	})
+}
+
+func (p *Producer) Close() error {
+	return nil
+}
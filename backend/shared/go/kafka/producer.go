@@ -8,25 +8,68 @@ import (
 
 type KafkaProducer struct {
 	writer *kafka.Writer
+	config ProducerConfig
 }
 
-func NewProducer(brokers []string, topic string) *KafkaProducer {
+// every service builds its producers here, so brokers, security and routing come from one place
+func NewProducerFromConfig(config ProducerConfig) (*KafkaProducer, error) {
+	writer, err := config.writer()
+	if err != nil {
+		return nil, err
+	}
+
 	return &KafkaProducer{
-		writer: &kafka.Writer{
-			Addr:     kafka.TCP(brokers...),
-			Topic:    topic,
-			Balancer: &kafka.LeastBytes{},
-		},
+		writer: writer,
+		config: config,
+	}, nil
+}
+
+// plaintext producer with default settings, panics on an empty broker list
+func NewProducer(brokers []string, topic string) *KafkaProducer {
+	producer, err := NewProducerFromConfig(DefaultProducerConfig(brokers, topic))
+	if err != nil {
+		panic(err)
 	}
+
+	return producer
 }
 
+// goes to the default topic
 func (p *KafkaProducer) PublishMessage(ctx context.Context, key []byte, value []byte) error {
 	return p.writer.WriteMessages(ctx, kafka.Message{
+		Topic: p.config.Topic,
 		Key:   key,
 		Value: value,
 	})
 }
 
+// messages without a Topic go to the default topic
+func (p *KafkaProducer) Publish(ctx context.Context, msgs ...Message) error {
+	kafkaMessages := make([]kafka.Message, len(msgs))
+	for i, msg := range msgs {
+		kafkaMessages[i] = toKafkaMessage(msg)
+		if kafkaMessages[i].Topic == "" {
+			kafkaMessages[i].Topic = p.config.Topic
+		}
+	}
+
+	return p.writer.WriteMessages(ctx, kafkaMessages...)
+}
+
+// routed by envelope type, the aggregate id is used as the message key so events of one aggregate stay ordered within a partition
+func (p *KafkaProducer) PublishEvent(ctx context.Context, envelope Envelope) error {
+	value, err := EncodeEvent(envelope)
+	if err != nil {
+		return err
+	}
+
+	return p.writer.WriteMessages(ctx, kafka.Message{
+		Topic: p.config.TopicFor(envelope.Type),
+		Key:   []byte(envelope.AggregateID),
+		Value: value,
+	})
+}
+
 func (p *KafkaProducer) Close() error {
 	return p.writer.Close()
 }
@@ -0,0 +1,114 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers stamped on messages moved to a retry or dead-letter topic
+const (
+	HeaderOriginalTopic = "x-original-topic"
+	HeaderRetryAttempt  = "x-retry-attempt"
+	HeaderNotBefore     = "x-not-before"
+	HeaderLastError     = "x-last-error"
+	HeaderFailureReason = "x-failure-reason"
+	HeaderFailedAt      = "x-failed-at"
+)
+
+// a message is first retried in process, then moved down the retry topic chain and finally to the dead-letter topic
+type RetryPolicy struct {
+	Attempts    int           // in process attempts per topic, including the first one
+	Backoff     time.Duration // initial in process backoff, doubled after every attempt
+	MaxBackoff  time.Duration
+	RetryTopics int           // length of the retry topic chain, 0 sends failures straight to the dead-letter topic
+	TopicDelay  time.Duration // how long a message waits on the first retry topic, doubled per level
+}
+
+// delay before in process attempt attempt+1
+func (p RetryPolicy) AttemptBackoff(attempt int) time.Duration {
+	delay := p.Backoff << attempt
+	if delay <= 0 || delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+func (p RetryPolicy) LevelDelay(level int) time.Duration {
+	return p.TopicDelay << (level - 1)
+}
+
+// payment_event -> payment_event.retry.1 -> payment_event.retry.2 -> payment_event.dlq
+func RetryTopic(topic string, level int) string {
+	return fmt.Sprintf("%s.retry.%d", topic, level)
+}
+
+func DeadLetterTopic(topic string) string {
+	return topic + ".dlq"
+}
+
+func IsDeadLetterTopic(topic string) bool {
+	return strings.HasSuffix(topic, ".dlq")
+}
+
+// a message that can't be decoded fails the same way on every attempt, retrying it only holds up the partition
+func IsNonRetryable(err error) bool {
+	return errors.Is(err, ErrInvalidEnvelope) || errors.Is(err, ErrInvalidPayload)
+}
+
+// builds the message that carries msg one step down sourceTopic's retry chain, level is the position msg was read at.
+// the dead-letter topic is next once the chain is used up or handleErr is not worth retrying
+func NextRetryMessage(msg Message, sourceTopic string, level int, policy RetryPolicy, handleErr error) Message {
+	headers := make(map[string]string, len(msg.Headers)+4)
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	headers[HeaderOriginalTopic] = sourceTopic
+	headers[HeaderRetryAttempt] = strconv.Itoa(level + 1)
+	headers[HeaderLastError] = handleErr.Error()
+
+	next := Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+
+	if level < policy.RetryTopics && !IsNonRetryable(handleErr) {
+		next.Topic = RetryTopic(sourceTopic, level+1)
+		headers[HeaderNotBefore] = strconv.FormatInt(time.Now().Add(policy.LevelDelay(level+1)).UnixMilli(), 10)
+	} else {
+		next.Topic = DeadLetterTopic(sourceTopic)
+		headers[HeaderFailureReason] = handleErr.Error()
+		headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+		delete(headers, HeaderNotBefore)
+	}
+
+	return next
+}
+
+// retry topics are delay ordered, so waiting on the head message holds back only messages that are due later.
+// returns false when ctx was cancelled first
+func WaitUntilDue(ctx context.Context, msg Message) bool {
+	notBefore, err := strconv.ParseInt(msg.Headers[HeaderNotBefore], 10, 64)
+	if err != nil {
+		return ctx.Err() == nil
+	}
+
+	delay := time.Until(time.UnixMilli(notBefore))
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
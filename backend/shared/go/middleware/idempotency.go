@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 100
+	maxIdempotentBodySize   = 1 << 20
+)
+
+var (
+	ErrIdempotencyKeyTooLong  = fmt.Errorf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength)
+	ErrIdempotencyKeyReused   = fmt.Errorf("%s was already used for a different request", IdempotencyKeyHeader)
+	ErrIdempotencyKeyInFlight = fmt.Errorf("a request with this %s is still being processed", IdempotencyKeyHeader)
+	ErrIdempotencyNoCaller    = fmt.Errorf("%s needs an authenticated caller", IdempotencyKeyHeader)
+)
+
+// requests without the header pass straight through. the first response below 500 is stored and replayed to
+// retries with the same key and body, the same key with a different body gets a 409.
+// keys are scoped to the caller UserIDMiddleware put in the context and the route, two users picking the same key
+// never see each other's response
+func IdempotencyMiddleware(store *IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				utils.WriteError(w, http.StatusBadRequest, ErrIdempotencyKeyTooLong)
+				return
+			}
+
+			ctx := r.Context()
+			userId, err := GetUserIdFromContext(ctx)
+			if err != nil || userId == "" {
+				utils.WriteError(w, http.StatusUnauthorized, ErrIdempotencyNoCaller)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := userId + " " + r.Method + " " + r.URL.Path
+			hash := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(hash[:])
+
+			claimed, err := store.Claim(ctx, key, scope, requestHash)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			if !claimed {
+				replay(w, r, store, key, scope, requestHash)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next.ServeHTTP(recorder, r.WithContext(context.WithValue(ctx, idempotencyKeyKey, key)))
+
+			//the client may be gone already, the outcome must still be recorded for its retry
+			storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+
+			if recorder.status >= http.StatusInternalServerError {
+				if err := store.Release(storeCtx, key, scope); err != nil {
+					log.Printf("failed to release idempotency key %s: %v", key, err)
+				}
+				return
+			}
+
+			if err := store.Complete(storeCtx, key, scope, recorder.statusCode(), recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+				log.Printf("failed to store response for idempotency key %s: %v", key, err)
+			}
+		})
+	}
+}
+
+func replay(w http.ResponseWriter, r *http.Request, store *IdempotencyStore, key string, scope string, requestHash string) {
+	record, err := store.Get(r.Context(), key, scope)
+	if err != nil {
+		//released between our claim and the lookup, the client can simply retry
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.WriteError(w, http.StatusConflict, ErrIdempotencyKeyInFlight)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if record.RequestHash != requestHash {
+		utils.WriteError(w, http.StatusConflict, ErrIdempotencyKeyReused)
+		return
+	}
+
+	if record.Status != idempotencyDone {
+		utils.WriteError(w, http.StatusConflict, ErrIdempotencyKeyInFlight)
+		return
+	}
+
+	if record.ContentType != nil && *record.ContentType != "" {
+		w.Header().Set("Content-Type", *record.ContentType)
+	}
+	w.Header().Set(IdempotencyReplayedHeader, strconv.FormatBool(true))
+	w.WriteHeader(record.ResponseStatus)
+	w.Write(record.ResponseBody)
+}
+
+// the key of the request being handled, empty when the request came without one
+func GetIdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey).(string)
+	return key
+}
+
+// passes the response through while keeping a copy to store
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	idempotencyProcessing = "processing"
+	idempotencyDone       = "done"
+)
+
+// one row per key and scope, declared as IdempotencyKey in the service schemas
+type IdempotencyRecord struct {
+	Key            string     `gorm:"column:key;type:varchar(100);primaryKey" json:"key"`
+	Scope          string     `gorm:"column:scope;type:varchar(255);primaryKey" json:"scope"`
+	RequestHash    string     `gorm:"column:requestHash;type:varchar(64);not null" json:"request_hash"`
+	Status         string     `gorm:"column:status;type:varchar(20);not null" json:"status"`
+	ResponseStatus int        `gorm:"column:responseStatus;not null;default:0" json:"response_status"`
+	ContentType    *string    `gorm:"column:contentType;type:varchar(100);null" json:"content_type"`
+	ResponseBody   []byte     `gorm:"column:responseBody;type:bytea;null" json:"-"`
+	CreatedAt      time.Time  `gorm:"column:createdAt;not null" json:"created_at"`
+	CompletedAt    *time.Time `gorm:"column:completedAt;null" json:"completed_at"`
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_key"
+}
+
+type IdempotencyStore struct {
+	db *gorm.DB
+	// a request that never completed, e.g. because the process crashed mid-handle, can be retried after this long
+	lease time.Duration
+}
+
+func NewIdempotencyStore(db *gorm.DB, lease time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{
+		db:    db,
+		lease: lease,
+	}
+}
+
+// returns true when the caller now owns the key and should run the request, false when the key was seen before.
+// a stale claim is only taken over by the same request, a different body still gets the conflict
+func (s *IdempotencyStore) Claim(ctx context.Context, key string, scope string, requestHash string) (bool, error) {
+	results := s.db.WithContext(ctx).Exec(`
+		INSERT INTO idempotency_key ("key", "scope", "requestHash", "status", "createdAt")
+		VALUES (?, ?, ?, ?, NOW())
+		ON CONFLICT ("key", "scope") DO UPDATE
+			SET "createdAt" = NOW()
+			WHERE idempotency_key."status" = ? AND idempotency_key."requestHash" = EXCLUDED."requestHash"
+				AND idempotency_key."createdAt" < NOW() - ? * INTERVAL '1 second'`,
+		key, scope, requestHash, idempotencyProcessing,
+		idempotencyProcessing, s.lease.Seconds(),
+	)
+	if results.Error != nil {
+		return false, results.Error
+	}
+
+	return results.RowsAffected == 1, nil
+}
+
+func (s *IdempotencyStore) Get(ctx context.Context, key string, scope string) (IdempotencyRecord, error) {
+	var record IdempotencyRecord
+
+	results := s.db.WithContext(ctx).
+		Where(`"key" = ? AND "scope" = ?`, key, scope).
+		First(&record)
+	return record, results.Error
+}
+
+// stores the response that is replayed to every retry with the same key
+func (s *IdempotencyStore) Complete(ctx context.Context, key string, scope string, status int, contentType string, body []byte) error {
+	return s.db.WithContext(ctx).
+		Model(&IdempotencyRecord{}).
+		Where(`"key" = ? AND "scope" = ?`, key, scope).
+		Updates(map[string]any{
+			"status":         idempotencyDone,
+			"responseStatus": status,
+			"contentType":    contentType,
+			"responseBody":   body,
+			"completedAt":    time.Now(),
+		}).Error
+}
+
+// gives up a claim after a failed request so a retry runs it again
+func (s *IdempotencyStore) Release(ctx context.Context, key string, scope string) error {
+	return s.db.WithContext(ctx).
+		Where(`"key" = ? AND "scope" = ? AND "status" = ?`, key, scope, idempotencyProcessing).
+		Delete(&IdempotencyRecord{}).Error
+}
+
+// removes keys older than ttl, a retry after that runs the request again
+func (s *IdempotencyStore) Cleanup(ctx context.Context, ttl time.Duration) (int64, error) {
+	results := s.db.WithContext(ctx).
+		Where(`"createdAt" < ?`, time.Now().Add(-ttl)).
+		Delete(&IdempotencyRecord{})
+	return results.RowsAffected, results.Error
+}
+
+// blocks until ctx is cancelled
+func (s *IdempotencyStore) RunCleanup(ctx context.Context, ttl time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.Cleanup(ctx, ttl)
+			if err != nil {
+				log.Printf("idempotency keys cleanup: %v", err)
+				continue
+			}
+
+			if removed > 0 {
+				log.Printf("idempotency keys cleanup: removed %d rows", removed)
+			}
+		}
+	}
+}
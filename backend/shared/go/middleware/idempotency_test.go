@@ -0,0 +1,232 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/middleware"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	idempotencyKey = "checkout-1"
+	userID         = "6f1c2a4e-8b3d-4e5f-9a7b-1c2d3e4f5a6b"
+	scope          = userID + " POST /api/orders"
+	body           = `{"items":[{"productId":"p-1","quantity":1}]}`
+	// sha256 of body
+	bodyHash = "dcb8073030ec4bf98644e82de70bb2567547da176dff863e028d3ea7b311c936"
+)
+
+// answers with status and counts how often the request got through
+type handler struct {
+	status int
+	calls  int
+	key    string
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	h.key = middleware.GetIdempotencyKeyFromContext(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	io.WriteString(w, `{"id":"order-1"}`)
+}
+
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	return db, mock
+}
+
+// the middleware sits behind UserIDMiddleware the way the routes register it
+func serve(db *gorm.DB, next http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	store := middleware.NewIdempotencyStore(db, 5*time.Minute)
+	rec := httptest.NewRecorder()
+	middleware.UserIDMiddleware(middleware.IdempotencyMiddleware(store)(next)).ServeHTTP(rec, req)
+	return rec
+}
+
+func checkoutRequest(key string, requestBody string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/orders", strings.NewReader(requestBody))
+	req.Header.Set("x-user-id", userID)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	return req
+}
+
+func expectClaim(mock sqlmock.Sqlmock, requestHash string, claimed bool) {
+	var affected int64
+	if claimed {
+		affected = 1
+	}
+	mock.ExpectExec(`INSERT INTO idempotency_key .* ON CONFLICT \("key", "scope"\) DO UPDATE`).
+		WithArgs(idempotencyKey, scope, requestHash, "processing", "processing", float64(300)).
+		WillReturnResult(sqlmock.NewResult(0, affected))
+}
+
+func expectStored(mock sqlmock.Sqlmock, status string, requestHash string, responseStatus int) {
+	rows := sqlmock.NewRows([]string{"key", "scope", "requestHash", "status", "responseStatus", "contentType", "responseBody", "createdAt", "completedAt"}).
+		AddRow(idempotencyKey, scope, requestHash, status, responseStatus, "application/json", []byte(`{"id":"order-1"}`), time.Now(), nil)
+	mock.ExpectQuery(`SELECT \* FROM "idempotency_key" WHERE "key" = \$1 AND "scope" = \$2`).
+		WithArgs(idempotencyKey, scope, 1).
+		WillReturnRows(rows)
+}
+
+func TestIdempotencyMiddlewareStoresTheFirstResponse(t *testing.T) {
+	db, mock := mockDB(t)
+	next := &handler{status: http.StatusCreated}
+
+	expectClaim(mock, bodyHash, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "idempotency_key" SET .* WHERE "key" = \$6 AND "scope" = \$7`).
+		WithArgs(sqlmock.AnyArg(), "application/json", []byte(`{"id":"order-1"}`), http.StatusCreated, "done", idempotencyKey, scope).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rec := serve(db, next, checkoutRequest(idempotencyKey, body))
+
+	if rec.Code != http.StatusCreated || next.calls != 1 {
+		t.Fatalf("status %d after %d calls, want %d after 1", rec.Code, next.calls, http.StatusCreated)
+	}
+	if next.key != idempotencyKey {
+		t.Fatalf("handler saw key %q, want %q", next.key, idempotencyKey)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyMiddlewareReplaysRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     string
+		storedHash string
+		status     int
+		replayed   bool
+	}{
+		{name: "same body after completion", stored: "done", storedHash: bodyHash, status: http.StatusCreated, replayed: true},
+		{name: "same body still processing", stored: "processing", storedHash: bodyHash, status: http.StatusConflict},
+		{name: "different body", stored: "done", storedHash: strings.Repeat("0", 64), status: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+			next := &handler{status: http.StatusCreated}
+
+			expectClaim(mock, bodyHash, false)
+			expectStored(mock, tt.stored, tt.storedHash, http.StatusCreated)
+
+			rec := serve(db, next, checkoutRequest(idempotencyKey, body))
+
+			if next.calls != 0 {
+				t.Fatalf("handler ran %d times for a retry", next.calls)
+			}
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if replayed := rec.Header().Get(middleware.IdempotencyReplayedHeader) == "true"; replayed != tt.replayed {
+				t.Fatalf("replayed = %v, want %v", replayed, tt.replayed)
+			}
+			if tt.replayed && rec.Body.String() != `{"id":"order-1"}` {
+				t.Fatalf("replayed body = %s", rec.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// a failed request gives the key back so the retry runs it again
+func TestIdempotencyMiddlewareReleasesServerErrors(t *testing.T) {
+	db, mock := mockDB(t)
+	next := &handler{status: http.StatusInternalServerError}
+
+	expectClaim(mock, bodyHash, true)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "idempotency_key" WHERE "key" = \$1 AND "scope" = \$2 AND "status" = \$3`).
+		WithArgs(idempotencyKey, scope, "processing").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if rec := serve(db, next, checkoutRequest(idempotencyKey, body)); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// none of these reach the store
+func TestIdempotencyMiddlewarePassThroughAndRejections(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+		calls  int
+	}{
+		{
+			name:   "no key",
+			req:    func() *http.Request { return checkoutRequest("", body) },
+			status: http.StatusCreated,
+			calls:  1,
+		},
+		{
+			name:   "key too long",
+			req:    func() *http.Request { return checkoutRequest(strings.Repeat("k", 101), body) },
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+			next := &handler{status: http.StatusCreated}
+
+			rec := serve(db, next, tt.req())
+
+			if rec.Code != tt.status || next.calls != tt.calls {
+				t.Fatalf("status %d after %d calls, want %d after %d", rec.Code, next.calls, tt.status, tt.calls)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// the scope comes from the authenticated caller, a bare x-user-id header without UserIDMiddleware isn't trusted
+func TestIdempotencyMiddlewareNeedsTheCallerFromTheContext(t *testing.T) {
+	db, mock := mockDB(t)
+	next := &handler{status: http.StatusCreated}
+	store := middleware.NewIdempotencyStore(db, 5*time.Minute)
+
+	rec := httptest.NewRecorder()
+	middleware.IdempotencyMiddleware(store)(next).ServeHTTP(rec, checkoutRequest(idempotencyKey, body))
+
+	if rec.Code != http.StatusUnauthorized || next.calls != 0 {
+		t.Fatalf("status %d after %d calls, want %d before the handler", rec.Code, next.calls, http.StatusUnauthorized)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -11,7 +11,8 @@ import (
 type contextKey string
 
 const (
-	userIDKey contextKey = "userID"
+	userIDKey         contextKey = "userID"
+	idempotencyKeyKey contextKey = "idempotencyKey"
 )
 
 func UserIDMiddleware(next http.Handler) http.Handler {
@@ -0,0 +1,119 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"gorm.io/gorm"
+)
+
+// mirrors the ServiceOutbox model that every service schema declares, prisma keeps the camelCase field names as columns
+type ServiceOutbox struct {
+	ID            string          `gorm:"column:id;type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	AggregateType string          `gorm:"column:aggregateType;type:varchar(100);not null" json:"aggregate_type"`
+	AggregateID   string          `gorm:"column:aggregateId;type:uuid;not null" json:"aggregate_id"`
+	EventType     string          `gorm:"column:eventType;type:varchar(100);not null" json:"event_type"`
+	Payload       json.RawMessage `gorm:"column:payload;type:jsonb;not null" json:"payload"`
+	Metadata      json.RawMessage `gorm:"column:metadata;type:jsonb" json:"metadata"`
+	IsPublished   bool            `gorm:"column:isPublished;not null;default:false" json:"is_published"`
+	PublishedAt   *time.Time      `gorm:"column:publishedAt;null" json:"published_at"`
+	RetryCount    int             `gorm:"column:retryCount;not null;default:0" json:"retry_count"`
+	LastError     *string         `gorm:"column:lastError;type:text;null" json:"last_error"`
+	NextAttemptAt *time.Time      `gorm:"column:nextAttemptAt;null" json:"next_attempt_at"` // null until the first failed attempt
+	CreatedAt     time.Time       `gorm:"column:createdAt;not null" json:"created_at"`
+}
+
+func (ServiceOutbox) TableName() string {
+	return "service_outbox"
+}
+
+type Event struct {
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	EventVersion  int
+	CorrelationID string
+	Payload       any
+	Metadata      map[string]string
+}
+
+const (
+	metadataVersion       = "version"
+	metadataCorrelationID = "correlationId"
+)
+
+// tx must be the caller's transaction, the event is only visible to the relay once that transaction commits
+func Write(tx *gorm.DB, event Event) error {
+	if tx == nil {
+		return errors.New("outbox: transaction is nil")
+	}
+
+	if event.AggregateID == "" || event.EventType == "" {
+		return errors.New("outbox: aggregate id and event type are required")
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	row := ServiceOutbox{
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		EventType:     event.EventType,
+		Payload:       payload,
+	}
+
+	//envelope fields that have no column of their own travel in metadata until the relay builds the envelope
+	metadata := map[string]string{}
+	for key, value := range event.Metadata {
+		metadata[key] = value
+	}
+
+	version := event.EventVersion
+	if version < 1 {
+		version = 1
+	}
+	metadata[metadataVersion] = strconv.Itoa(version)
+
+	if event.CorrelationID != "" {
+		metadata[metadataCorrelationID] = event.CorrelationID
+	}
+
+	encodedMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	row.Metadata = encodedMetadata
+
+	return tx.Create(&row).Error
+}
+
+// the outbox row id doubles as the event id, so a redelivered event can be recognised downstream
+func (row ServiceOutbox) Envelope(producer string) (kafka.Envelope, error) {
+	metadata := map[string]string{}
+	if len(row.Metadata) > 0 {
+		if err := json.Unmarshal(row.Metadata, &metadata); err != nil {
+			return kafka.Envelope{}, err
+		}
+	}
+
+	version, err := strconv.Atoi(metadata[metadataVersion])
+	if err != nil || version < 1 {
+		version = 1
+	}
+
+	return kafka.Envelope{
+		ID:            row.ID,
+		Type:          row.EventType,
+		Version:       version,
+		AggregateID:   row.AggregateID,
+		OccurredAt:    row.CreatedAt.UTC(),
+		Producer:      producer,
+		CorrelationID: metadata[metadataCorrelationID],
+		Payload:       row.Payload,
+	}, nil
+}
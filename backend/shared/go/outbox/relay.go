@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// satisfied by every kafka.Producer, including the in-memory one in kafkatest
+type Publisher interface {
+	PublishEvent(ctx context.Context, envelope kafka.Envelope) error
+}
+
+type RelayConfig struct {
+	Producer     string // service name stamped on every envelope
+	PollInterval time.Duration
+	BatchSize    int
+	MaxRetries   int           // rows that failed this many times are logged as stuck on every attempt, but still retried
+	BaseBackoff  time.Duration // delay before the first retry, doubled on every further retry
+	MaxBackoff   time.Duration // a row keeps being retried at this interval for as long as the broker is down
+}
+
+func DefaultRelayConfig(producer string) RelayConfig {
+	return RelayConfig{
+		Producer:     producer,
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxRetries:   10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   5 * time.Minute,
+	}
+}
+
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	config    RelayConfig
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, config RelayConfig) *Relay {
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		config:    config,
+	}
+}
+
+// blocks until ctx is cancelled, safe to run on every replica since rows are claimed with SKIP LOCKED
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+// keeps relaying full batches so a backlog is cleared without waiting for the next tick
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		relayed, err := r.RelayBatch(ctx)
+		if err != nil {
+			log.Printf("outbox relay: %v", err)
+			return
+		}
+
+		if relayed < r.config.BatchSize {
+			return
+		}
+	}
+}
+
+// publishes one batch of pending rows and returns how many rows were claimed
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	var claimed int
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []ServiceOutbox
+
+		//the events were committed with their aggregates, so rows are never given up on, a failed one waits for its nextAttemptAt
+		results := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where(`"isPublished" = ?`, false).
+			Where(`"nextAttemptAt" IS NULL OR "nextAttemptAt" <= NOW()`).
+			Order(`"createdAt"`).
+			Limit(r.config.BatchSize).
+			Find(&rows)
+		if results.Error != nil {
+			return results.Error
+		}
+
+		claimed = len(rows)
+		for _, row := range rows {
+			if err := r.publish(ctx, tx, row); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return claimed, err
+}
+
+// a failed publish is recorded on the row rather than returned, so one bad event doesn't block the batch
+func (r *Relay) publish(ctx context.Context, tx *gorm.DB, row ServiceOutbox) error {
+	publishErr := r.publishEnvelope(ctx, row)
+	if publishErr != nil {
+		attempt := row.RetryCount + 1
+		if attempt >= r.config.MaxRetries {
+			log.Printf("outbox relay: STUCK %s %s failed %d times, retrying every %s: %v", row.EventType, row.ID, attempt, r.backoff(attempt), publishErr)
+		} else {
+			log.Printf("outbox relay: failed to publish %s %s (attempt %d): %v", row.EventType, row.ID, attempt, publishErr)
+		}
+
+		lastError := publishErr.Error()
+		return tx.Model(&ServiceOutbox{}).
+			Where("id = ?", row.ID).
+			Updates(map[string]any{
+				"retryCount":    gorm.Expr(`"retryCount" + 1`),
+				"lastError":     lastError,
+				"nextAttemptAt": gorm.Expr(`NOW() + ? * INTERVAL '1 second'`, r.backoff(attempt).Seconds()),
+			}).Error
+	}
+
+	return tx.Model(&ServiceOutbox{}).
+		Where("id = ?", row.ID).
+		Updates(map[string]any{
+			"isPublished": true,
+			"publishedAt": time.Now(),
+			"lastError":   nil,
+		}).Error
+}
+
+// delay after the given failed attempt, BaseBackoff doubled per earlier failure and capped at MaxBackoff
+func (r *Relay) backoff(attempt int) time.Duration {
+	delay := r.config.BaseBackoff
+	for i := 1; i < attempt && delay < r.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > r.config.MaxBackoff {
+		return r.config.MaxBackoff
+	}
+	return delay
+}
+
+func (r *Relay) publishEnvelope(ctx context.Context, row ServiceOutbox) error {
+	envelope, err := row.Envelope(r.config.Producer)
+	if err != nil {
+		return err
+	}
+
+	//the publisher picks the topic from the event type
+	return r.publisher.PublishEvent(ctx, envelope)
+}
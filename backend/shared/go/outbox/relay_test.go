@@ -0,0 +1,211 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/kafka"
+	"github.com/Flow-Indo/LAKOO/backend/shared/go/outbox"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var createdAt = time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+var outboxColumns = []string{
+	"id", "aggregateType", "aggregateId", "eventType", "payload", "metadata",
+	"isPublished", "publishedAt", "retryCount", "lastError", "nextAttemptAt", "createdAt",
+}
+
+// fails every event whose id is in failing, records the rest
+type publisher struct {
+	failing   map[string]bool
+	published []kafka.Envelope
+}
+
+func (p *publisher) PublishEvent(ctx context.Context, envelope kafka.Envelope) error {
+	if p.failing[envelope.ID] {
+		return errors.New("broker down")
+	}
+	p.published = append(p.published, envelope)
+	return nil
+}
+
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	return db, mock
+}
+
+func pendingRows(rows ...[]driver.Value) *sqlmock.Rows {
+	result := sqlmock.NewRows(outboxColumns)
+	for _, row := range rows {
+		result.AddRow(row...)
+	}
+	return result
+}
+
+func pendingRow(id string, eventType string, retryCount int) []driver.Value {
+	return []driver.Value{
+		id, "order", "agg-" + id, eventType, []byte(`{"orderId":"agg-` + id + `"}`), []byte(`{"version":"2","correlationId":"corr-` + id + `"}`),
+		false, nil, retryCount, nil, nil, createdAt,
+	}
+}
+
+func expectClaim(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "service_outbox" WHERE "isPublished" = \$1 AND \("nextAttemptAt" IS NULL OR "nextAttemptAt" <= NOW\(\)\) ORDER BY "createdAt" LIMIT \$2 FOR UPDATE SKIP LOCKED`).
+		WithArgs(false, 100).
+		WillReturnRows(rows)
+}
+
+func expectPublished(mock sqlmock.Sqlmock, id string) {
+	mock.ExpectExec(`UPDATE "service_outbox" SET "isPublished"=\$1,"lastError"=\$2,"publishedAt"=\$3 WHERE id = \$4`).
+		WithArgs(true, nil, sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestRelayBatchPublishesPendingRows(t *testing.T) {
+	db, mock := mockDB(t)
+	pub := &publisher{}
+
+	expectClaim(mock, pendingRows(pendingRow("evt-1", "order.created", 0), pendingRow("evt-2", "order.paid", 3)))
+	expectPublished(mock, "evt-1")
+	expectPublished(mock, "evt-2")
+	mock.ExpectCommit()
+
+	claimed, err := outbox.NewRelay(db, pub, outbox.DefaultRelayConfig("order-service")).RelayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("RelayBatch: %v", err)
+	}
+	if claimed != 2 {
+		t.Fatalf("claimed %d rows, want 2", claimed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pub.published) != 2 {
+		t.Fatalf("published %d events, want 2", len(pub.published))
+	}
+	want := kafka.Envelope{
+		ID:            "evt-1",
+		Type:          "order.created",
+		Version:       2,
+		AggregateID:   "agg-evt-1",
+		OccurredAt:    createdAt,
+		Producer:      "order-service",
+		CorrelationID: "corr-evt-1",
+		Payload:       json.RawMessage(`{"orderId":"agg-evt-1"}`),
+	}
+	got := pub.published[0]
+	if got.ID != want.ID || got.Type != want.Type || got.Version != want.Version || got.AggregateID != want.AggregateID ||
+		!got.OccurredAt.Equal(want.OccurredAt) || got.Producer != want.Producer || got.CorrelationID != want.CorrelationID ||
+		string(got.Payload) != string(want.Payload) {
+		t.Fatalf("envelope = %+v, want %+v", got, want)
+	}
+}
+
+// the failed row is pushed back by its backoff, the rest of the batch still goes out
+func TestRelayBatchBacksOffFailedRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryCount int
+		backoff    float64
+	}{
+		{name: "first failure", retryCount: 0, backoff: 1},
+		{name: "doubled per earlier failure", retryCount: 3, backoff: 8},
+		{name: "capped at MaxBackoff", retryCount: 20, backoff: 300},
+		{name: "past MaxRetries still retried", retryCount: 40, backoff: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := mockDB(t)
+			pub := &publisher{failing: map[string]bool{"evt-1": true}}
+
+			expectClaim(mock, pendingRows(pendingRow("evt-1", "order.created", tt.retryCount), pendingRow("evt-2", "order.paid", 0)))
+			mock.ExpectExec(`UPDATE "service_outbox" SET "lastError"=\$1,"nextAttemptAt"=NOW\(\) \+ \$2 \* INTERVAL '1 second',"retryCount"="retryCount" \+ 1 WHERE id = \$3`).
+				WithArgs("broker down", tt.backoff, "evt-1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectPublished(mock, "evt-2")
+			mock.ExpectCommit()
+
+			claimed, err := outbox.NewRelay(db, pub, outbox.DefaultRelayConfig("order-service")).RelayBatch(context.Background())
+			if err != nil {
+				t.Fatalf("RelayBatch: %v", err)
+			}
+			if claimed != 2 {
+				t.Fatalf("claimed %d rows, want 2", claimed)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+			if len(pub.published) != 1 || pub.published[0].ID != "evt-2" {
+				t.Fatalf("published %+v, want only evt-2", pub.published)
+			}
+		})
+	}
+}
+
+// the claim is given back with the rollback, another replica or the next tick picks the rows up again
+func TestRelayBatchRollsBackWhenTheRowCannotBeUpdated(t *testing.T) {
+	db, mock := mockDB(t)
+	pub := &publisher{}
+
+	expectClaim(mock, pendingRows(pendingRow("evt-1", "order.created", 0)))
+	mock.ExpectExec(`UPDATE "service_outbox"`).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	if _, err := outbox.NewRelay(db, pub, outbox.DefaultRelayConfig("order-service")).RelayBatch(context.Background()); err == nil {
+		t.Fatal("RelayBatch succeeded, want the update error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnvelopeDefaultsToVersionOne(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+	}{
+		{name: "no metadata"},
+		{name: "no version", metadata: `{"correlationId":"corr-1"}`},
+		{name: "version zero", metadata: `{"version":"0"}`},
+		{name: "version not a number", metadata: `{"version":"v2"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := outbox.ServiceOutbox{ID: "evt-1", EventType: "order.created", Payload: json.RawMessage(`{}`), CreatedAt: createdAt}
+			if tt.metadata != "" {
+				row.Metadata = json.RawMessage(tt.metadata)
+			}
+
+			envelope, err := row.Envelope("order-service")
+			if err != nil {
+				t.Fatalf("Envelope: %v", err)
+			}
+			if envelope.Version != 1 {
+				t.Fatalf("version = %d, want 1", envelope.Version)
+			}
+		})
+	}
+}